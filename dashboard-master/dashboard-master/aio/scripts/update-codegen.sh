@@ -33,9 +33,18 @@ source "${ROOT_DIR}/aio/scripts/conf.sh"
   --output-base "$(dirname "${BASH_SOURCE[0]}")/.."
 
 # Remove old generated client
+"${CODEGEN_BIN}" "deepcopy,client,informer,lister" \
+  github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client github.com/kubernetes/dashboard/src/app/backend/federatedlearning \
+  apis:v1alpha1 \
+  --go-header-file "${ROOT_DIR}"/aio/scripts/license-header.go.txt \
+  --output-base "$(dirname "${BASH_SOURCE[0]}")/.."
+
 rm -rf ./src/app/backend/plugin/client
 # Move generated deepcopy funcs and client
 mv "$(dirname "${BASH_SOURCE[0]}")"/../github.com/kubernetes/dashboard/src/app/backend/plugin/apis/v1alpha1/zz_generated.deepcopy.go ./src/app/backend/plugin/apis/v1alpha1
 mv "$(dirname "${BASH_SOURCE[0]}")"/../github.com/kubernetes/dashboard/src/app/backend/plugin/client ./src/app/backend/plugin
 # Remove empty directory
+rm -rf ./src/app/backend/federatedlearning/client
+mv "$(dirname "${BASH_SOURCE[0]}")"/../github.com/kubernetes/dashboard/src/app/backend/federatedlearning/apis/v1alpha1/zz_generated.deepcopy.go ./src/app/backend/federatedlearning/apis/v1alpha1
+mv "$(dirname "${BASH_SOURCE[0]}")"/../github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client ./src/app/backend/federatedlearning
 rm -rf ./aio/github.com
@@ -168,6 +168,126 @@ func (self *holderBuilder) SetLocaleConfig(localeConfig string) *holderBuilder {
 	return self
 }
 
+// SetFLStorage 'fl-storage' argument of Dashboard binary.
+func (self *holderBuilder) SetFLStorage(flStorage string) *holderBuilder {
+	self.holder.flStorage = flStorage
+	return self
+}
+
+// SetFLStorageDir 'fl-storage-dir' argument of Dashboard binary.
+func (self *holderBuilder) SetFLStorageDir(flStorageDir string) *holderBuilder {
+	self.holder.flStorageDir = flStorageDir
+	return self
+}
+
+// SetFLClientTimeout 'fl-client-timeout' argument of Dashboard binary.
+func (self *holderBuilder) SetFLClientTimeout(flClientTimeout int) *holderBuilder {
+	self.holder.flClientTimeout = flClientTimeout
+	return self
+}
+
+// SetFLTokenAudience 'fl-token-audience' argument of Dashboard binary.
+func (self *holderBuilder) SetFLTokenAudience(flTokenAudience string) *holderBuilder {
+	self.holder.flTokenAudience = flTokenAudience
+	return self
+}
+
+// SetFLUpstreamURL 'fl-upstream-url' argument of Dashboard binary.
+func (self *holderBuilder) SetFLUpstreamURL(flUpstreamURL string) *holderBuilder {
+	self.holder.flUpstreamURL = flUpstreamURL
+	return self
+}
+
+// SetFLUpstreamInterval 'fl-upstream-interval' argument of Dashboard binary.
+func (self *holderBuilder) SetFLUpstreamInterval(flUpstreamInterval int) *holderBuilder {
+	self.holder.flUpstreamInterval = flUpstreamInterval
+	return self
+}
+
+// SetFLUpstreamTokenFile 'fl-upstream-token-file' argument of Dashboard binary.
+func (self *holderBuilder) SetFLUpstreamTokenFile(flUpstreamTokenFile string) *holderBuilder {
+	self.holder.flUpstreamTokenFile = flUpstreamTokenFile
+	return self
+}
+
+// SetFLGRPCPort 'fl-grpc-port' argument of Dashboard binary.
+func (self *holderBuilder) SetFLGRPCPort(flGRPCPort int) *holderBuilder {
+	self.holder.flGRPCPort = flGRPCPort
+	return self
+}
+
+// SetFLGRPCCertFile 'fl-grpc-tls-cert-file' argument of Dashboard binary.
+func (self *holderBuilder) SetFLGRPCCertFile(flGRPCCertFile string) *holderBuilder {
+	self.holder.flGRPCCertFile = flGRPCCertFile
+	return self
+}
+
+// SetFLGRPCKeyFile 'fl-grpc-tls-key-file' argument of Dashboard binary.
+func (self *holderBuilder) SetFLGRPCKeyFile(flGRPCKeyFile string) *holderBuilder {
+	self.holder.flGRPCKeyFile = flGRPCKeyFile
+	return self
+}
+
+// SetFLGRPCClientCAFile 'fl-grpc-client-ca-file' argument of Dashboard binary.
+func (self *holderBuilder) SetFLGRPCClientCAFile(flGRPCClientCAFile string) *holderBuilder {
+	self.holder.flGRPCClientCAFile = flGRPCClientCAFile
+	return self
+}
+
+// SetFLController 'fl-controller' argument of Dashboard binary.
+func (self *holderBuilder) SetFLController(flController bool) *holderBuilder {
+	self.holder.flController = flController
+	return self
+}
+
+// SetFLSigningKeySecret 'fl-signing-key-secret' argument of Dashboard binary.
+func (self *holderBuilder) SetFLSigningKeySecret(flSigningKeySecret string) *holderBuilder {
+	self.holder.flSigningKeySecret = flSigningKeySecret
+	return self
+}
+
+// SetFLMaxUpdateSize 'fl-max-update-size' argument of Dashboard binary.
+func (self *holderBuilder) SetFLMaxUpdateSize(flMaxUpdateSize int) *holderBuilder {
+	self.holder.flMaxUpdateSize = flMaxUpdateSize
+	return self
+}
+
+// SetFLMaxUpdatesPerVersion 'fl-max-updates-per-version' argument of Dashboard binary.
+func (self *holderBuilder) SetFLMaxUpdatesPerVersion(flMaxUpdatesPerVersion int) *holderBuilder {
+	self.holder.flMaxUpdatesPerVersion = flMaxUpdatesPerVersion
+	return self
+}
+
+// SetFLMaxPendingUpdates 'fl-max-pending-updates' argument of Dashboard binary.
+func (self *holderBuilder) SetFLMaxPendingUpdates(flMaxPendingUpdates int) *holderBuilder {
+	self.holder.flMaxPendingUpdates = flMaxPendingUpdates
+	return self
+}
+
+// SetFLClientRateLimit 'fl-client-rate-limit' argument of Dashboard binary.
+func (self *holderBuilder) SetFLClientRateLimit(flClientRateLimit float64) *holderBuilder {
+	self.holder.flClientRateLimit = flClientRateLimit
+	return self
+}
+
+// SetFLClientRateBurst 'fl-client-rate-burst' argument of Dashboard binary.
+func (self *holderBuilder) SetFLClientRateBurst(flClientRateBurst int) *holderBuilder {
+	self.holder.flClientRateBurst = flClientRateBurst
+	return self
+}
+
+// SetFLAddressRateLimit 'fl-address-rate-limit' argument of Dashboard binary.
+func (self *holderBuilder) SetFLAddressRateLimit(flAddressRateLimit float64) *holderBuilder {
+	self.holder.flAddressRateLimit = flAddressRateLimit
+	return self
+}
+
+// SetFLAddressRateBurst 'fl-address-rate-burst' argument of Dashboard binary.
+func (self *holderBuilder) SetFLAddressRateBurst(flAddressRateBurst int) *holderBuilder {
+	self.holder.flAddressRateBurst = flAddressRateBurst
+	return self
+}
+
 // GetHolderBuilder returns singleton instance of argument holder builder.
 func GetHolderBuilder() *holderBuilder {
 	return builder
@@ -55,6 +55,28 @@ type holder struct {
 	enableSkipLogin bool
 
 	localeConfig string
+
+	flStorage       string
+	flStorageDir    string
+	flClientTimeout int
+	flTokenAudience string
+
+	flUpstreamURL          string
+	flUpstreamInterval     int
+	flUpstreamTokenFile    string
+	flGRPCPort             int
+	flGRPCCertFile         string
+	flGRPCKeyFile          string
+	flGRPCClientCAFile     string
+	flController           bool
+	flSigningKeySecret     string
+	flMaxUpdateSize        int
+	flMaxUpdatesPerVersion int
+	flMaxPendingUpdates    int
+	flClientRateLimit      float64
+	flClientRateBurst      int
+	flAddressRateLimit     float64
+	flAddressRateBurst     int
 }
 
 // GetInsecurePort 'insecure-port' argument of Dashboard binary.
@@ -184,3 +206,103 @@ func (self *holder) GetNamespace() string {
 func (self *holder) GetLocaleConfig() string {
 	return self.localeConfig
 }
+
+// GetFLStorage 'fl-storage' argument of Dashboard binary.
+func (self *holder) GetFLStorage() string {
+	return self.flStorage
+}
+
+// GetFLStorageDir 'fl-storage-dir' argument of Dashboard binary.
+func (self *holder) GetFLStorageDir() string {
+	return self.flStorageDir
+}
+
+// GetFLClientTimeout 'fl-client-timeout' argument of Dashboard binary.
+func (self *holder) GetFLClientTimeout() int {
+	return self.flClientTimeout
+}
+
+// GetFLTokenAudience 'fl-token-audience' argument of Dashboard binary.
+func (self *holder) GetFLTokenAudience() string {
+	return self.flTokenAudience
+}
+
+// GetFLUpstreamURL 'fl-upstream-url' argument of Dashboard binary.
+func (self *holder) GetFLUpstreamURL() string {
+	return self.flUpstreamURL
+}
+
+// GetFLUpstreamInterval 'fl-upstream-interval' argument of Dashboard binary.
+func (self *holder) GetFLUpstreamInterval() int {
+	return self.flUpstreamInterval
+}
+
+// GetFLUpstreamTokenFile 'fl-upstream-token-file' argument of Dashboard binary.
+func (self *holder) GetFLUpstreamTokenFile() string {
+	return self.flUpstreamTokenFile
+}
+
+// GetFLGRPCPort 'fl-grpc-port' argument of Dashboard binary.
+func (self *holder) GetFLGRPCPort() int {
+	return self.flGRPCPort
+}
+
+// GetFLGRPCCertFile 'fl-grpc-tls-cert-file' argument of Dashboard binary.
+func (self *holder) GetFLGRPCCertFile() string {
+	return self.flGRPCCertFile
+}
+
+// GetFLGRPCKeyFile 'fl-grpc-tls-key-file' argument of Dashboard binary.
+func (self *holder) GetFLGRPCKeyFile() string {
+	return self.flGRPCKeyFile
+}
+
+// GetFLGRPCClientCAFile 'fl-grpc-client-ca-file' argument of Dashboard binary.
+func (self *holder) GetFLGRPCClientCAFile() string {
+	return self.flGRPCClientCAFile
+}
+
+// GetFLController 'fl-controller' argument of Dashboard binary.
+func (self *holder) GetFLController() bool {
+	return self.flController
+}
+
+// GetFLSigningKeySecret 'fl-signing-key-secret' argument of Dashboard binary.
+func (self *holder) GetFLSigningKeySecret() string {
+	return self.flSigningKeySecret
+}
+
+// GetFLMaxUpdateSize 'fl-max-update-size' argument of Dashboard binary.
+func (self *holder) GetFLMaxUpdateSize() int {
+	return self.flMaxUpdateSize
+}
+
+// GetFLMaxUpdatesPerVersion 'fl-max-updates-per-version' argument of Dashboard binary.
+func (self *holder) GetFLMaxUpdatesPerVersion() int {
+	return self.flMaxUpdatesPerVersion
+}
+
+// GetFLMaxPendingUpdates 'fl-max-pending-updates' argument of Dashboard binary.
+func (self *holder) GetFLMaxPendingUpdates() int {
+	return self.flMaxPendingUpdates
+}
+
+// GetFLClientRateLimit 'fl-client-rate-limit' argument of Dashboard binary.
+func (self *holder) GetFLClientRateLimit() float64 {
+	return self.flClientRateLimit
+}
+
+// GetFLClientRateBurst 'fl-client-rate-burst' argument of Dashboard binary.
+func (self *holder) GetFLClientRateBurst() int {
+	return self.flClientRateBurst
+}
+
+// GetFLAddressRateLimit 'fl-address-rate-limit' argument of Dashboard binary.
+func (self *holder) GetFLAddressRateLimit() float64 {
+	return self.flAddressRateLimit
+}
+
+// GetFLAddressRateBurst 'fl-address-rate-burst' argument of Dashboard binary.
+func (self *holder) GetFLAddressRateBurst() int {
+	return self.flAddressRateBurst
+}
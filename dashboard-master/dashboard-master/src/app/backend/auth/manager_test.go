@@ -26,6 +26,7 @@ import (
 	clientapi "github.com/kubernetes/dashboard/src/app/backend/client/api"
 	"github.com/kubernetes/dashboard/src/app/backend/errors"
 
+	flclientset "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/clientset/versioned"
 	pluginclientset "github.com/kubernetes/dashboard/src/app/backend/plugin/client/clientset/versioned"
 	v1 "k8s.io/api/authorization/v1"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -68,6 +69,10 @@ func (self *fakeClientManager) InsecurePluginClient() pluginclientset.Interface
 	return nil
 }
 
+func (self *fakeClientManager) InsecureFederatedLearningClient() flclientset.Interface {
+	return nil
+}
+
 func (self *fakeClientManager) SetTokenManager(manager authApi.TokenManager) {}
 
 func (self *fakeClientManager) Config(req *restful.Request) (*rest.Config, error) {
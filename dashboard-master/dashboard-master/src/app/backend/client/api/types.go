@@ -25,6 +25,7 @@ import (
 	"k8s.io/client-go/tools/clientcmd/api"
 
 	authApi "github.com/kubernetes/dashboard/src/app/backend/auth/api"
+	flclientset "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/clientset/versioned"
 	pluginclientset "github.com/kubernetes/dashboard/src/app/backend/plugin/client/clientset/versioned"
 )
 
@@ -45,6 +46,7 @@ type ClientManager interface {
 	PluginClient(req *restful.Request) (pluginclientset.Interface, error)
 	InsecureAPIExtensionsClient() apiextensionsclientset.Interface
 	InsecurePluginClient() pluginclientset.Interface
+	InsecureFederatedLearningClient() flclientset.Interface
 	CanI(req *restful.Request, ssar *v1.SelfSubjectAccessReview) bool
 	Config(req *restful.Request) (*rest.Config, error)
 	ClientCmdConfig(req *restful.Request) (clientcmd.ClientConfig, error)
@@ -32,6 +32,7 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
 
+	flclientset "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/clientset/versioned"
 	pluginclientset "github.com/kubernetes/dashboard/src/app/backend/plugin/client/clientset/versioned"
 	"github.com/kubernetes/dashboard/src/app/backend/resource/customresourcedefinition"
 
@@ -85,6 +86,9 @@ type clientManager struct {
 	// Plugin client created without providing auth info. It uses permissions granted to
 	// service account used by dashboard or kubeconfig file if it was passed during dashboard init.
 	insecurePluginClient pluginclientset.Interface
+	// Federated learning client created without providing auth info. It uses permissions granted to
+	// service account used by dashboard or kubeconfig file if it was passed during dashboard init.
+	insecureFederatedLearningClient flclientset.Interface
 	// Kubernetes client created without providing auth info. It uses permissions granted to
 	// service account used by dashboard or kubeconfig file if it was passed during dashboard init.
 	insecureClient kubernetes.Interface
@@ -175,6 +179,13 @@ func (self *clientManager) InsecurePluginClient() pluginclientset.Interface {
 	return self.insecurePluginClient
 }
 
+// InsecureFederatedLearningClient returns federated learning client that was created without providing
+// auth info. It uses permissions granted to service account used by dashboard or kubeconfig file
+// if it was passed during dashboard init.
+func (self *clientManager) InsecureFederatedLearningClient() flclientset.Interface {
+	return self.insecureFederatedLearningClient
+}
+
 // InsecureConfig returns kubernetes client config that used privileges of dashboard service account
 // or kubeconfig file if it was passed during dashboard init.
 func (self *clientManager) InsecureConfig() *rest.Config {
@@ -545,9 +556,15 @@ func (self *clientManager) initInsecureClients() {
 		panic(err)
 	}
 
+	flclient, err := flclientset.NewForConfig(self.insecureConfig)
+	if err != nil {
+		panic(err)
+	}
+
 	self.insecureClient = k8sClient
 	self.insecureAPIExtensionsClient = apiextensionsclient
 	self.insecurePluginClient = pluginclient
+	self.insecureFederatedLearningClient = flclient
 }
 
 func (self *clientManager) initInsecureConfig() {
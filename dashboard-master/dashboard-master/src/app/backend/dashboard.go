@@ -25,8 +25,10 @@ import (
 	"os"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
 
 	"github.com/kubernetes/dashboard/src/app/backend/args"
 	"github.com/kubernetes/dashboard/src/app/backend/auth"
@@ -36,6 +38,13 @@ import (
 	"github.com/kubernetes/dashboard/src/app/backend/cert/ecdsa"
 	"github.com/kubernetes/dashboard/src/app/backend/client"
 	clientapi "github.com/kubernetes/dashboard/src/app/backend/client/api"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning"
+	flinformers "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/informers/externalversions"
+	flcontroller "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/controller"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/flgrpc"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/kubeauth"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/kubestore"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/signing"
 	"github.com/kubernetes/dashboard/src/app/backend/handler"
 	"github.com/kubernetes/dashboard/src/app/backend/integration"
 	integrationapi "github.com/kubernetes/dashboard/src/app/backend/integration/api"
@@ -69,6 +78,26 @@ var (
 	argDisableSettingsAuthorizer = pflag.Bool("disable-settings-authorizer", false, "disables settings page user authorizer so anyone can access settings page")
 	argNamespace                 = pflag.String("namespace", getEnv("POD_NAMESPACE", "kube-system"), "if non-default namespace is used encryption key will be created in the specified namespace")
 	localeConfig                 = pflag.String("locale-config", "./locale_conf.json", "path to file containing the locale configuration")
+	argFLStorage                 = pflag.String("fl-storage", "memory", "storage of the federated learning coordinator state, should be one of 'memory', 'filesystem' or 'kubernetes'")
+	argFLStorageDir              = pflag.String("fl-storage-dir", "/var/lib/dashboard/federated-learning", "directory in which the federated learning coordinator keeps its state, or only model weights when 'kubernetes' storage is used")
+	argFLClientTimeout           = pflag.Int("fl-client-timeout", int(federatedlearning.DefaultClientTimeout.Seconds()), "time in seconds after which a federated learning client that did not send a heartbeat is marked offline, set to 0 to never mark clients offline")
+	argFLTokenAudience           = pflag.String("fl-token-audience", "", "audience of ServiceAccount tokens with which federated learning clients authenticate, if empty tokens issued for the API server are accepted")
+	argFLUpstreamURL             = pflag.String("fl-upstream-url", "", "address of the API of an upstream federated learning coordinator, e.g. https://global-dashboard/api/v1, models linked to an upstream model are synchronized with it")
+	argFLUpstreamInterval        = pflag.Int("fl-upstream-interval", 60, "time interval in seconds between synchronizations with the upstream federated learning coordinator")
+	argFLUpstreamTokenFile       = pflag.String("fl-upstream-token-file", "", "file containing the bearer token with which the coordinator authenticates to the upstream federated learning coordinator, e.g. a projected ServiceAccount token")
+	argFLGRPCPort                = pflag.Int("fl-grpc-port", 0, "port on which the federated learning coordinator serves its client API over gRPC, set to 0 to disable the gRPC API")
+	argFLGRPCCertFile            = pflag.String("fl-grpc-tls-cert-file", "", "file containing the x509 certificate with which the federated learning gRPC API is served, if empty the API is served without TLS")
+	argFLGRPCKeyFile             = pflag.String("fl-grpc-tls-key-file", "", "file containing the x509 private key matching --fl-grpc-tls-cert-file")
+	argFLGRPCClientCAFile        = pflag.String("fl-grpc-client-ca-file", "", "file containing the CA certificates with which the client certificates of federated learning gRPC clients are verified, if not empty clients must present a certificate (mutual TLS)")
+	argFLController              = pflag.Bool("fl-controller", false, "enables the controller that keeps federated learning models in sync with FederatedModel objects, requires the FederatedModel custom resource definition")
+	argFLSigningKeySecret        = pflag.String("fl-signing-key-secret", "", "name of the secret in the dashboard namespace holding the Ed25519 key with which the federated learning coordinator signs model versions, a key is generated if the secret does not exist, if empty versions are not signed")
+	argFLMaxUpdateSize           = pflag.Int("fl-max-update-size", 0, "maximum size in bytes of the weights of a federated learning update, models may configure their own quota, set to 0 for no limit")
+	argFLMaxUpdatesPerVersion    = pflag.Int("fl-max-updates-per-version", 0, "number of updates a federated learning client may submit based on the same model version, models may configure their own quota, set to 0 for no limit")
+	argFLMaxPendingUpdates       = pflag.Int("fl-max-pending-updates", 0, "number of updates a federated learning model holds until they are aggregated, updates based on the oldest version are evicted to make room for newer ones, set to 0 for no limit")
+	argFLClientRateLimit         = pflag.Float64("fl-client-rate-limit", 0, "number of requests per second a federated learning client may send to the coordinator, set to 0 to disable rate limiting")
+	argFLClientRateBurst         = pflag.Int("fl-client-rate-burst", 10, "number of requests a federated learning client may send in a burst when --fl-client-rate-limit is set")
+	argFLAddressRateLimit        = pflag.Float64("fl-address-rate-limit", 0, "number of requests per second the federated learning coordinator accepts from a remote address before authenticating them, set to 0 to disable rate limiting")
+	argFLAddressRateBurst        = pflag.Int("fl-address-rate-burst", 100, "number of requests the federated learning coordinator accepts from a remote address in a burst when --fl-address-rate-limit is set")
 )
 
 func main() {
@@ -114,7 +143,7 @@ func main() {
 	integrationManager := integration.NewIntegrationManager(clientManager)
 
 	// Init federated learning
-	flApi := initFederatedLearning()
+	flApi := initFederatedLearning(clientManager)
 
 	switch metricsProvider := args.Holder.GetMetricsProvider(); metricsProvider {
 	case "sidecar":
@@ -191,12 +220,131 @@ func main() {
 	select {}
 }
 
-func initFederatedLearning() *federatedlearning.API {
-	coordinator := federatedlearning.NewCoordinator()
-	api := federatedlearning.NewAPI(coordinator)
+func initFederatedLearning(clientManager clientapi.ClientManager) *federatedlearning.API {
+	coordinator, err := initFederatedLearningCoordinator(clientManager)
+	if err != nil {
+		log.Fatalf("Error while initializing federated learning coordinator. Reason: %s", err)
+	}
+
+	coordinator.SetClientTimeout(time.Duration(args.Holder.GetFLClientTimeout()) * time.Second)
+	quota := federatedlearning.QuotaConfig{MaxUpdateSize: args.Holder.GetFLMaxUpdateSize(),
+		MaxUpdatesPerVersion: args.Holder.GetFLMaxUpdatesPerVersion(),
+		MaxPendingUpdates:    args.Holder.GetFLMaxPendingUpdates()}
+	if err := coordinator.SetDefaultQuota(quota); err != nil {
+		log.Fatalf("Invalid federated learning quota. Reason: %s", err)
+	}
+	coordinator.SetRateLimit(args.Holder.GetFLClientRateLimit(), args.Holder.GetFLClientRateBurst())
+	coordinator.SetAddressRateLimit(args.Holder.GetFLAddressRateLimit(), args.Holder.GetFLAddressRateBurst())
+	if secretName := args.Holder.GetFLSigningKeySecret(); secretName != "" {
+		initFederatedLearningSigner(clientManager, coordinator, secretName)
+	}
+	prometheus.MustRegister(coordinator.Collector())
+	go coordinator.Run(make(chan struct{}))
+	if upstreamURL := args.Holder.GetFLUpstreamURL(); upstreamURL != "" {
+		transport := http.DefaultTransport
+		if tokenFile := args.Holder.GetFLUpstreamTokenFile(); tokenFile != "" {
+			transport = federatedlearning.NewTokenFileTransport(tokenFile, transport)
+		}
+
+		upstream := federatedlearning.NewHTTPUpstream(&http.Client{Transport: transport, Timeout: time.Minute},
+			upstreamURL)
+		interval := time.Duration(args.Holder.GetFLUpstreamInterval()) * time.Second
+		log.Printf("Synchronizing federated learning models with upstream coordinator %s every %s", upstreamURL,
+			interval)
+		go coordinator.RunUpstream(upstream, interval, make(chan struct{}))
+	}
+	var audiences []string
+	if audience := args.Holder.GetFLTokenAudience(); audience != "" {
+		audiences = append(audiences, audience)
+	}
+
+	authenticator := kubeauth.NewTokenReviewAuthenticator(clientManager.InsecureClient(), audiences...)
+	if args.Holder.GetFLController() {
+		flClient := clientManager.InsecureFederatedLearningClient()
+		factory := flinformers.NewSharedInformerFactory(flClient, 5*time.Minute)
+		controller := flcontroller.NewController(coordinator, flClient, clientManager.InsecureClient(),
+			factory.FederatedLearning().V1alpha1().FederatedModels())
+		stopCh := make(chan struct{})
+		factory.Start(stopCh)
+		go controller.Run(stopCh)
+	}
+
+	if port := args.Holder.GetFLGRPCPort(); port != 0 {
+		go serveFederatedLearningGRPC(coordinator, authenticator, port)
+	}
+
+	api := federatedlearning.NewAPI(coordinator, authenticator)
+	go api.Run(make(chan struct{}))
 	return api
 }
 
+// initFederatedLearningSigner signs the model versions published by the coordinator with a key synchronized
+// with the given secret.
+func initFederatedLearningSigner(clientManager clientapi.ClientManager, coordinator *federatedlearning.Coordinator,
+	secretName string) {
+	synchronizerManager := sync.NewSynchronizerManager(clientManager.InsecureClient())
+	keySynchronizer := synchronizerManager.Secret(args.Holder.GetNamespace(), secretName)
+
+	// Register synchronizer. Overwatch will be responsible for restarting it in case of error.
+	sync.Overwatch.RegisterSynchronizer(keySynchronizer, sync.AlwaysRestart)
+
+	keyHolder, err := signing.NewKeyHolder(keySynchronizer, args.Holder.GetNamespace(), secretName)
+	if err != nil {
+		log.Fatalf("Error while initializing federated learning signing key. Reason: %s", err)
+	}
+	if err := coordinator.SetSigner(keyHolder); err != nil {
+		log.Fatalf("Error while signing federated learning models. Reason: %s", err)
+	}
+}
+
+// serveFederatedLearningGRPC serves the client API of the federated learning coordinator over gRPC.
+func serveFederatedLearningGRPC(coordinator *federatedlearning.Coordinator, authenticator federatedlearning.Authenticator,
+	port int) {
+	var opts []grpc.ServerOption
+	if certFile := args.Holder.GetFLGRPCCertFile(); certFile != "" {
+		creds, err := flgrpc.ServerCredentials(certFile, args.Holder.GetFLGRPCKeyFile(),
+			args.Holder.GetFLGRPCClientCAFile())
+		if err != nil {
+			log.Fatalf("Error while loading federated learning gRPC certificates. Reason: %s", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	} else if args.Holder.GetFLGRPCClientCAFile() != "" {
+		log.Fatal("--fl-grpc-client-ca-file requires --fl-grpc-tls-cert-file")
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", args.Holder.GetBindAddress(), port))
+	if err != nil {
+		log.Fatalf("Error while listening for federated learning gRPC requests. Reason: %s", err)
+	}
+
+	log.Printf("Serving federated learning gRPC API on port: %d", port)
+	log.Fatal(flgrpc.NewServer(coordinator, authenticator, opts...).Serve(listener))
+}
+
+func initFederatedLearningCoordinator(clientManager clientapi.ClientManager) (*federatedlearning.Coordinator, error) {
+	switch storage := args.Holder.GetFLStorage(); storage {
+	case "memory":
+		return federatedlearning.NewCoordinator(), nil
+	case "filesystem":
+		store, err := federatedlearning.NewFileStore(args.Holder.GetFLStorageDir())
+		if err != nil {
+			return nil, err
+		}
+
+		return federatedlearning.NewPersistentCoordinator(store)
+	case "kubernetes":
+		weights, err := federatedlearning.NewFileBlobStore(args.Holder.GetFLStorageDir())
+		if err != nil {
+			return nil, err
+		}
+
+		store := kubestore.NewStore(clientManager.InsecureClient(), args.Holder.GetNamespace(), weights)
+		return federatedlearning.NewPersistentCoordinator(store)
+	default:
+		return nil, fmt.Errorf("invalid federated learning storage: %s", storage)
+	}
+}
+
 func initAuthManager(clientManager clientapi.ClientManager) authApi.AuthManager {
 	insecureClient := clientManager.InsecureClient()
 
@@ -254,6 +402,26 @@ func initArgHolder() {
 	builder.SetEnableSkipLogin(*argEnableSkip)
 	builder.SetNamespace(*argNamespace)
 	builder.SetLocaleConfig(*localeConfig)
+	builder.SetFLStorage(*argFLStorage)
+	builder.SetFLStorageDir(*argFLStorageDir)
+	builder.SetFLClientTimeout(*argFLClientTimeout)
+	builder.SetFLTokenAudience(*argFLTokenAudience)
+	builder.SetFLUpstreamURL(*argFLUpstreamURL)
+	builder.SetFLUpstreamInterval(*argFLUpstreamInterval)
+	builder.SetFLUpstreamTokenFile(*argFLUpstreamTokenFile)
+	builder.SetFLGRPCPort(*argFLGRPCPort)
+	builder.SetFLGRPCCertFile(*argFLGRPCCertFile)
+	builder.SetFLGRPCKeyFile(*argFLGRPCKeyFile)
+	builder.SetFLGRPCClientCAFile(*argFLGRPCClientCAFile)
+	builder.SetFLController(*argFLController)
+	builder.SetFLSigningKeySecret(*argFLSigningKeySecret)
+	builder.SetFLMaxUpdateSize(*argFLMaxUpdateSize)
+	builder.SetFLMaxUpdatesPerVersion(*argFLMaxUpdatesPerVersion)
+	builder.SetFLMaxPendingUpdates(*argFLMaxPendingUpdates)
+	builder.SetFLClientRateLimit(*argFLClientRateLimit)
+	builder.SetFLClientRateBurst(*argFLClientRateBurst)
+	builder.SetFLAddressRateLimit(*argFLAddressRateLimit)
+	builder.SetFLAddressRateBurst(*argFLAddressRateBurst)
 }
 
 /**
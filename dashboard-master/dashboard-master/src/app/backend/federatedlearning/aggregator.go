@@ -0,0 +1,153 @@
+package federatedlearning
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// FedAvg is the name of the federated averaging aggregator.
+const FedAvg = "fedavg"
+
+// Aggregator combines client updates that are based on the same global model version into the
+// weights of the next version.
+type Aggregator interface {
+	// Aggregate returns the weights of the version following model, computed from the given updates.
+	Aggregate(model *GlobalModel, updates []*ModelUpdate) (*Aggregation, error)
+}
+
+// Aggregation is the result of combining updates.
+type Aggregation struct {
+	Weights []byte
+	// Discarded are IDs of clients whose updates were discarded as outliers. Coordinate-wise aggregators
+	// report updates that were dropped from most coordinates, even if some of their values contributed.
+	Discarded []string
+}
+
+// AggregatorFactory creates an aggregator from the aggregation configuration of a model.
+type AggregatorFactory func(config AggregationConfig) (Aggregator, error)
+
+// AggregationConfig configures how updates of a model are combined into new versions.
+type AggregationConfig struct {
+	// Algorithm is the name of a registered aggregator. Defaults to FedAvg.
+	Algorithm string `json:"algorithm"`
+	// TrimFraction is the fraction of the largest and of the smallest values of every weight dropped by
+	// TrimmedMean. Defaults to DefaultTrimFraction.
+	TrimFraction float64 `json:"trimFraction,omitempty"`
+	// ByzantineClients is the number of faulty clients tolerated by MultiKrum.
+	ByzantineClients int `json:"byzantineClients,omitempty"`
+	// KrumSelect is the number of updates MultiKrum averages. Defaults to the number of updates minus
+	// ByzantineClients.
+	KrumSelect int `json:"krumSelect,omitempty"`
+}
+
+var (
+	aggregatorsMu sync.RWMutex
+	aggregators   = map[string]AggregatorFactory{
+		FedAvg:      func(AggregationConfig) (Aggregator, error) { return &fedAvgAggregator{}, nil },
+		Median:      newMedianAggregator,
+		TrimmedMean: newTrimmedMeanAggregator,
+		MultiKrum:   newMultiKrumAggregator,
+	}
+)
+
+// RegisterAggregator makes an aggregator available under the given name. Registering the same name
+// twice replaces the previous factory.
+func RegisterAggregator(name string, factory AggregatorFactory) {
+	aggregatorsMu.Lock()
+	defer aggregatorsMu.Unlock()
+	aggregators[name] = factory
+}
+
+// Aggregators returns names of all registered aggregators.
+func Aggregators() []string {
+	aggregatorsMu.RLock()
+	defer aggregatorsMu.RUnlock()
+
+	names := make([]string, 0, len(aggregators))
+	for name := range aggregators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewAggregator creates the aggregator described by config.
+func NewAggregator(config AggregationConfig) (Aggregator, error) {
+	name := config.Algorithm
+	if name == "" {
+		name = FedAvg
+	}
+
+	aggregatorsMu.RLock()
+	factory, exists := aggregators[name]
+	aggregatorsMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown aggregator: %s", name)
+	}
+
+	return factory(config)
+}
+
+// fedAvgAggregator implements federated averaging (McMahan et al.). Every update carries the full
+// set of locally trained weights, and the new weights are their mean weighted by the number of
+// samples each client trained on.
+type fedAvgAggregator struct{}
+
+// Aggregate implements Aggregator interface. See Aggregator for more information.
+func (a *fedAvgAggregator) Aggregate(model *GlobalModel, updates []*ModelUpdate) (*Aggregation, error) {
+	vectors, err := decodeUpdates(model, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := make([]float64, len(vectors[0]))
+	var total float64
+	for i, weights := range vectors {
+		samples := sampleWeight(updates[i])
+		for j, w := range weights {
+			sum[j] += float64(w) * samples
+		}
+		total += samples
+	}
+
+	result := make([]float32, len(sum))
+	for i := range sum {
+		result[i] = float32(sum[i] / total)
+	}
+
+	return &Aggregation{Weights: encodeWeights(result)}, nil
+}
+
+// decodeUpdates decodes the weights of all updates and checks that they have the same length.
+func decodeUpdates(model *GlobalModel, updates []*ModelUpdate) ([][]float32, error) {
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("no updates to aggregate for model %s", model.ID)
+	}
+
+	vectors := make([][]float32, len(updates))
+	for i, update := range updates {
+		weights, err := decodeWeights(update.WeightUpdate)
+		if err != nil {
+			return nil, fmt.Errorf("update from client %s: %v", update.ClientID, err)
+		}
+
+		if i > 0 && len(weights) != len(vectors[0]) {
+			return nil, fmt.Errorf("update from client %s has %d weights, expected %d", update.ClientID,
+				len(weights), len(vectors[0]))
+		}
+		vectors[i] = weights
+	}
+
+	return vectors, nil
+}
+
+// sampleWeight returns the weight of an update in a sample weighted average. Updates that do not
+// report their sample count are counted as a single sample.
+func sampleWeight(update *ModelUpdate) float64 {
+	if update.NumSamples <= 0 {
+		return 1
+	}
+
+	return float64(update.NumSamples)
+}
@@ -0,0 +1,65 @@
+package federatedlearning
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFedAvgAggregate(t *testing.T) {
+	cases := []struct {
+		info     string
+		updates  []*ModelUpdate
+		expected []float32
+	}{
+		{
+			"should weight updates by sample count",
+			[]*ModelUpdate{
+				{ClientID: "a", WeightUpdate: encodeWeights([]float32{1, 2}), NumSamples: 1},
+				{ClientID: "b", WeightUpdate: encodeWeights([]float32{4, 8}), NumSamples: 2},
+			},
+			[]float32{3, 6},
+		},
+		{
+			"should count updates without sample count as single sample",
+			[]*ModelUpdate{
+				{ClientID: "a", WeightUpdate: encodeWeights([]float32{1, 1})},
+				{ClientID: "b", WeightUpdate: encodeWeights([]float32{3, 5})},
+			},
+			[]float32{2, 3},
+		},
+	}
+
+	aggregator, err := NewAggregator(AggregationConfig{})
+	if err != nil {
+		t.Fatalf("NewAggregator(): unexpected error: %v", err)
+	}
+
+	for _, c := range cases {
+		aggregation, err := aggregator.Aggregate(&GlobalModel{ID: "test"}, c.updates)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.info, err)
+		}
+
+		actual, _ := decodeWeights(aggregation.Weights)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.info, c.expected, actual)
+		}
+	}
+}
+
+func TestFedAvgAggregateMismatchedLength(t *testing.T) {
+	aggregator, _ := NewAggregator(AggregationConfig{Algorithm: FedAvg})
+	_, err := aggregator.Aggregate(&GlobalModel{ID: "test"}, []*ModelUpdate{
+		{ClientID: "a", WeightUpdate: encodeWeights([]float32{1, 2})},
+		{ClientID: "b", WeightUpdate: encodeWeights([]float32{1})},
+	})
+	if err == nil {
+		t.Error("expected error for updates of different length")
+	}
+}
+
+func TestNewAggregatorUnknown(t *testing.T) {
+	if _, err := NewAggregator(AggregationConfig{Algorithm: "unknown"}); err == nil {
+		t.Error("expected error for unknown aggregator")
+	}
+}
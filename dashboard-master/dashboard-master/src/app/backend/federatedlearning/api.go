@@ -1,53 +1,140 @@
 package federatedlearning
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/emicklei/go-restful/v3"
 )
 
+// entityOverhead is the size allowed for the metadata of a JSON encoded update.
+const entityOverhead = 64 << 10
+
 // API provides the HTTP API for the federated learning coordinator.
 type API struct {
-	coordinator *Coordinator
+	coordinator   *Coordinator
+	authenticator Authenticator
+	uploads       *uploads
+}
+
+// WaitResponse is returned to clients that were not selected for the current round of a model.
+type WaitResponse struct {
+	// Status is always "wait".
+	Status            string `json:"status"`
+	ModelID           string `json:"modelId"`
+	Round             int    `json:"round"`
+	RetryAfterSeconds int    `json:"retryAfterSeconds"`
 }
 
-// NewAPI creates a new API.
-func NewAPI(coordinator *Coordinator) *API {
-	return &API{coordinator: coordinator}
+// NewAPI creates a new API. Client requests are authenticated with the given authenticator.
+func NewAPI(coordinator *Coordinator, authenticator Authenticator) *API {
+	return &API{coordinator: coordinator, authenticator: authenticator, uploads: newUploads()}
 }
 
 // RegisterRoutes registers the API routes.
 func (a *API) RegisterRoutes(ws *restful.WebService) {
 	ws.Route(ws.POST("/fl/register").To(a.registerClient))
+	ws.Route(ws.GET("/fl/events").To(a.streamEvents).Produces("text/event-stream"))
+	ws.Route(ws.GET("/fl/clients").To(a.listClients))
+	ws.Route(ws.GET("/fl/clients/{clientId}").To(a.getClient))
+	ws.Route(ws.POST("/fl/clients/{clientId}/heartbeat").To(a.heartbeat))
+	ws.Route(ws.GET("/fl/model").To(a.listModels))
+	ws.Route(ws.POST("/fl/model").To(a.createModel))
 	ws.Route(ws.GET("/fl/model/{modelId}").To(a.getModel))
+	ws.Route(ws.GET("/fl/model/{modelId}/weights").To(a.downloadModelWeights).
+		Produces("application/octet-stream"))
+	ws.Route(ws.GET("/fl/model/{modelId}/versions").To(a.listVersions))
+	ws.Route(ws.GET("/fl/model/{modelId}/versions/{version}").To(a.getModelVersion))
+	ws.Route(ws.POST("/fl/model/{modelId}/versions/{version}/evaluation").To(a.submitEvaluation))
+	ws.Route(ws.GET("/fl/model/{modelId}/versions/{version}/weights").To(a.downloadVersionWeights).
+		Produces("application/octet-stream"))
+	ws.Route(ws.POST("/fl/model/{modelId}/rollback").To(a.rollbackModel))
 	ws.Route(ws.POST("/fl/model/{modelId}/update").To(a.submitModelUpdate))
+	ws.Route(ws.PUT("/fl/model/{modelId}/update/weights").To(a.uploadUpdateWeights).
+		Consumes("application/octet-stream"))
+	ws.Route(ws.HEAD("/fl/model/{modelId}/update/weights").To(a.uploadStatus))
+	ws.Route(ws.GET("/fl/model/{modelId}/round").To(a.getRound))
+	ws.Route(ws.POST("/fl/model/{modelId}/round").To(a.openRound))
+	ws.Route(ws.GET("/fl/model/{modelId}/secagg").To(a.getSecureAggregation))
+	ws.Route(ws.POST("/fl/model/{modelId}/secagg/keys").To(a.advertiseKeys))
+	ws.Route(ws.POST("/fl/model/{modelId}/secagg/shares").To(a.shareKeys))
+	ws.Route(ws.GET("/fl/model/{modelId}/secagg/shares").To(a.receiveShares))
+	ws.Route(ws.POST("/fl/model/{modelId}/secagg/masked").To(a.submitMaskedInput))
+	ws.Route(ws.POST("/fl/model/{modelId}/secagg/unmask").To(a.submitUnmaskingShares))
 }
 
+// registerClient registers the caller and enrolls it in the model given in the request body.
 func (a *API) registerClient(req *restful.Request, resp *restful.Response) {
-	var registrationReq struct {
-		ModelID string `json:"modelId"`
+	identity, err := a.authorizeClient(req, "")
+	if err != nil {
+		writeClientError(resp, err)
+		return
 	}
-	if err := req.ReadEntity(&registrationReq); err != nil {
+
+	registration := new(ClientRegistration)
+	if err := req.ReadEntity(registration); err != nil {
 		resp.WriteError(http.StatusBadRequest, err)
 		return
 	}
 
-	client, err := a.coordinator.RegisterClient(req.Request.RemoteAddr)
+	client, err := a.coordinator.RegisterClient(identity, registration)
+	if err != nil {
+		resp.WriteError(httpStatus(err), err)
+		return
+	}
+
+	resp.WriteEntity(client)
+}
+
+func (a *API) listClients(req *restful.Request, resp *restful.Response) {
+	resp.WriteEntity(a.coordinator.ListClients())
+}
+
+func (a *API) getClient(req *restful.Request, resp *restful.Response) {
+	client, err := a.coordinator.GetClient(req.PathParameter("clientId"))
+	if err != nil {
+		resp.WriteError(httpStatus(err), err)
+		return
+	}
+
+	resp.WriteEntity(client)
+}
+
+func (a *API) heartbeat(req *restful.Request, resp *restful.Response) {
+	if _, err := a.authorizeClient(req, req.PathParameter("clientId")); err != nil {
+		writeClientError(resp, err)
+		return
+	}
+
+	client, err := a.coordinator.Heartbeat(req.PathParameter("clientId"))
 	if err != nil {
-		resp.WriteError(http.StatusInternalServerError, err)
+		resp.WriteError(httpStatus(err), err)
 		return
 	}
 
 	resp.WriteEntity(client)
 }
 
+// getModel returns the latest global model. Clients that fetch the model for training pass their ID in the
+// clientId query parameter, which marks them as training. Clients that were not selected for the current
+// round are told to wait.
 func (a *API) getModel(req *restful.Request, resp *restful.Response) {
 	modelID := req.PathParameter("modelId")
 
-	model, err := a.coordinator.GetModel(modelID)
+	var model *GlobalModel
+	var err error
+	if clientID := req.QueryParameter("clientId"); clientID != "" {
+		if _, err = a.authorizeClient(req, clientID); err == nil {
+			model, err = a.coordinator.FetchModel(modelID, clientID)
+		}
+	} else {
+		model, err = a.coordinator.GetModel(modelID)
+	}
 	if err != nil {
-		resp.WriteError(http.StatusNotFound, err)
+		writeClientError(resp, err)
 		return
 	}
 
@@ -55,16 +142,160 @@ func (a *API) getModel(req *restful.Request, resp *restful.Response) {
 }
 
 func (a *API) submitModelUpdate(req *restful.Request, resp *restful.Response) {
+	// Requests are limited before they are read, the size of the weights is checked by the coordinator.
+	modelID := req.PathParameter("modelId")
+	maxSize := a.coordinator.Quota(modelID).MaxUpdateSize
+	if maxSize > 0 {
+		req.Request.Body = http.MaxBytesReader(resp.ResponseWriter, req.Request.Body, maxEntitySize(maxSize))
+	}
+
 	var update ModelUpdate
 	if err := req.ReadEntity(&update); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err = fmt.Errorf("%w: request exceeds %d bytes, model %s accepts updates of at most %d bytes",
+				ErrUpdateTooLarge, tooLarge.Limit, modelID, maxSize)
+			a.coordinator.observeRejectedRequest(modelID, err)
+			writeClientError(resp, err)
+			return
+		}
 		resp.WriteError(http.StatusBadRequest, err)
 		return
 	}
 
+	identity, err := a.authorizeClient(req, update.ClientID)
+	if err != nil {
+		writeClientError(resp, err)
+		return
+	}
+
+	update.ClientID = identity.ClientID
 	if err := a.coordinator.SubmitModelUpdate(&update); err != nil {
-		resp.WriteError(http.StatusInternalServerError, err)
+		writeClientError(resp, err)
 		return
 	}
 
 	resp.WriteHeader(http.StatusAccepted)
-}
\ No newline at end of file
+}
+
+func (a *API) getRound(req *restful.Request, resp *restful.Response) {
+	round, err := a.coordinator.GetRound(req.PathParameter("modelId"))
+	if err != nil {
+		resp.WriteError(httpStatus(err), err)
+		return
+	}
+
+	resp.WriteEntity(round)
+}
+
+// openRound opens a new round. The request body may override the round configuration of the model.
+func (a *API) openRound(req *restful.Request, resp *restful.Response) {
+	var config *RoundConfig
+	if req.Request.ContentLength != 0 {
+		config = new(RoundConfig)
+		if err := req.ReadEntity(config); err != nil {
+			resp.WriteError(http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	round, err := a.coordinator.OpenRound(req.PathParameter("modelId"), config)
+	if err != nil {
+		resp.WriteError(httpStatus(err), err)
+		return
+	}
+
+	resp.WriteHeaderAndEntity(http.StatusCreated, round)
+}
+
+func (a *API) listModels(req *restful.Request, resp *restful.Response) {
+	resp.WriteEntity(a.coordinator.ListModels())
+}
+
+func (a *API) createModel(req *restful.Request, resp *restful.Response) {
+	spec := new(ModelSpec)
+	if err := req.ReadEntity(spec); err != nil {
+		resp.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	model, err := a.coordinator.CreateModel(spec)
+	if err != nil {
+		resp.WriteError(httpStatus(err), err)
+		return
+	}
+
+	resp.WriteHeaderAndEntity(http.StatusCreated, model)
+}
+
+func (a *API) listVersions(req *restful.Request, resp *restful.Response) {
+	versions, err := a.coordinator.ListVersions(req.PathParameter("modelId"))
+	if err != nil {
+		resp.WriteError(httpStatus(err), err)
+		return
+	}
+
+	resp.WriteEntity(versions)
+}
+
+func (a *API) getModelVersion(req *restful.Request, resp *restful.Response) {
+	version, err := strconv.Atoi(req.PathParameter("version"))
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, fmt.Errorf("invalid version: %s", req.PathParameter("version")))
+		return
+	}
+
+	model, err := a.coordinator.GetModelVersion(req.PathParameter("modelId"), version)
+	if err != nil {
+		resp.WriteError(httpStatus(err), err)
+		return
+	}
+
+	resp.WriteEntity(model)
+}
+
+// rollbackModel makes the version given in the request body the current version of the model.
+func (a *API) rollbackModel(req *restful.Request, resp *restful.Response) {
+	var rollbackReq struct {
+		Version int `json:"version"`
+	}
+	if err := req.ReadEntity(&rollbackReq); err != nil {
+		resp.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	model, err := a.coordinator.RollbackModel(req.PathParameter("modelId"), rollbackReq.Version)
+	if err != nil {
+		resp.WriteError(httpStatus(err), err)
+		return
+	}
+
+	resp.WriteEntity(model)
+}
+
+// maxEntitySize returns the size of a JSON encoded update whose weights have maxSize bytes. Weights are
+// encoded in base64, metadata may take up to entityOverhead bytes.
+func maxEntitySize(maxSize int) int64 {
+	return int64(maxSize+2)/3*4 + entityOverhead
+}
+
+// writeClientError writes an error returned to a training client. Clients that were not selected for the
+// current round get a WaitResponse with 503 status and a Retry-After header. Clients that exceeded their
+// request rate are told when to try again with a Retry-After header.
+func writeClientError(resp *restful.Response, err error) {
+	var limited *RateLimitError
+	if errors.As(err, &limited) {
+		resp.AddHeader("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+	}
+
+	var wait *WaitError
+	if !errors.As(err, &wait) {
+		resp.WriteError(httpStatus(err), err)
+		return
+	}
+
+	retryAfter := int(math.Ceil(wait.RetryAfter.Seconds()))
+	resp.AddHeader("Retry-After", strconv.Itoa(retryAfter))
+	resp.WriteHeaderAndEntity(http.StatusServiceUnavailable, &WaitResponse{Status: "wait", ModelID: wait.ModelID,
+		Round: wait.Round, RetryAfterSeconds: retryAfter})
+}
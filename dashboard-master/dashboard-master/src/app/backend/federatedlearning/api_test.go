@@ -0,0 +1,216 @@
+package federatedlearning
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/tensor"
+)
+
+// testAuthenticator authenticates clients whose bearer token is their client ID.
+type testAuthenticator struct{}
+
+func (testAuthenticator) Authenticate(req *http.Request) (*Identity, error) {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return nil, fmt.Errorf("%w: bearer token is required", ErrUnauthenticated)
+	}
+
+	return &Identity{ClientID: token, PodUID: token}, nil
+}
+
+func newTestAPIServer(c *Coordinator) *httptest.Server {
+	ws := new(restful.WebService)
+	ws.Path("/api/v1").Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON)
+	NewAPI(c, testAuthenticator{}).RegisterRoutes(ws)
+
+	container := restful.NewContainer()
+	container.Add(ws)
+	return httptest.NewServer(container)
+}
+
+func doTestRequest(t *testing.T, method, url string, body interface{}, out interface{}) int {
+	return doTestClientRequest(t, "", method, url, body, out)
+}
+
+// doTestClientRequest sends a request authenticated as the given client.
+func doTestClientRequest(t *testing.T, clientID, method, url string, body interface{}, out interface{}) int {
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatalf("failed to encode request: %v", err)
+		}
+	}
+
+	req, _ := http.NewRequest(method, url, &reader)
+	req.Header.Set("Content-Type", restful.MIME_JSON)
+	if clientID != "" {
+		req.Header.Set("Authorization", "Bearer "+clientID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: unexpected error: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: failed to decode response: %v", method, url, err)
+		}
+	}
+
+	return resp.StatusCode
+}
+
+func TestAPIModelLifecycle(t *testing.T) {
+	server := newTestAPIServer(NewCoordinator())
+	defer server.Close()
+	base := server.URL + "/api/v1/fl/model"
+
+	spec := &ModelSpec{ID: "rrm-power-control", Description: "power control", Weights: encodeWeights([]float32{1})}
+	model := new(GlobalModel)
+	if status := doTestRequest(t, http.MethodPost, base, spec, model); status != http.StatusCreated {
+		t.Fatalf("expected status %d when creating model, got %d", http.StatusCreated, status)
+	}
+	if model.Version != 1 || model.Description != "power control" {
+		t.Errorf("unexpected model: %+v", model)
+	}
+
+	if status := doTestRequest(t, http.MethodPost, base, spec, nil); status != http.StatusConflict {
+		t.Errorf("expected status %d when creating existing model, got %d", http.StatusConflict, status)
+	}
+
+	models := make([]*GlobalModel, 0)
+	if status := doTestRequest(t, http.MethodGet, base, nil, &models); status != http.StatusOK || len(models) != 1 {
+		t.Errorf("expected single model, got status %d with %d models", status, len(models))
+	}
+
+	round := new(Round)
+	if status := doTestRequest(t, http.MethodGet, base+"/rrm-power-control/round", nil, round); status != http.StatusOK ||
+		round.State != RoundOpen {
+		t.Errorf("expected open round, got status %d with %+v", status, round)
+	}
+
+	if status := doTestRequest(t, http.MethodGet, base+"/rrm-power-control/versions/2", nil, nil); status != http.StatusNotFound {
+		t.Errorf("expected status %d for missing version, got %d", http.StatusNotFound, status)
+	}
+
+	rollback := map[string]int{"version": 1}
+	if status := doTestRequest(t, http.MethodPost, base+"/rrm-power-control/rollback", rollback, model); status != http.StatusOK {
+		t.Errorf("expected status %d for rollback to current version, got %d", http.StatusOK, status)
+	}
+}
+
+func TestAPIClientIdentity(t *testing.T) {
+	c := newTestCoordinator(t, 2)
+	if _, err := c.CreateModel(&ModelSpec{ID: "other", Weights: encodeWeights([]float32{0, 0})}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	server := newTestAPIServer(c)
+	defer server.Close()
+
+	register, body := server.URL+"/api/v1/fl/register", map[string]string{"modelId": "test"}
+	if status := doTestRequest(t, http.MethodPost, register, body, nil); status != http.StatusUnauthorized {
+		t.Errorf("expected unauthenticated registration to be rejected, got %d", status)
+	}
+
+	for _, id := range []string{"a", "b"} {
+		client := new(Client)
+		status := doTestClientRequest(t, id, http.MethodPost, register, body, client)
+		if status != http.StatusOK || client.ID != id || client.ModelID != "test" {
+			t.Fatalf("expected client %s to be enrolled in model test, got %d %+v", id, status, client)
+		}
+	}
+
+	if status := doTestClientRequest(t, "a", http.MethodPost, register, body, nil); status != http.StatusOK {
+		t.Errorf("expected client to be able to register again, got %d", status)
+	}
+
+	cases := []struct {
+		info     string
+		caller   string
+		update   *ModelUpdate
+		expected int
+	}{
+		{"should reject update on behalf of another client", "a", &ModelUpdate{ClientID: "b", ModelID: "test"},
+			http.StatusForbidden},
+		{"should reject update of model the client is not enrolled in", "a",
+			&ModelUpdate{ClientID: "a", ModelID: "other"}, http.StatusForbidden},
+		{"should accept update without client ID", "b", &ModelUpdate{ModelID: "test"}, http.StatusAccepted},
+	}
+
+	for _, tc := range cases {
+		tc.update.BaseVersion = 1
+		tc.update.WeightUpdate = encodeWeights([]float32{1, 1})
+		url := fmt.Sprintf("%s/api/v1/fl/model/%s/update", server.URL, tc.update.ModelID)
+		if status := doTestClientRequest(t, tc.caller, http.MethodPost, url, tc.update, nil); status != tc.expected {
+			t.Errorf("%s: expected status %d, got %d", tc.info, tc.expected, status)
+		}
+	}
+
+	if round, _ := c.GetRound("test"); len(round.Participants) != 1 || round.Participants[0] != "b" {
+		t.Errorf("expected update to be attributed to the caller, got %+v", round)
+	}
+}
+
+func TestAPITensorLayout(t *testing.T) {
+	c := NewCoordinator()
+	encode := func(dtype tensor.DType, kernelShape []int, values ...float32) []byte {
+		kernel, _ := tensor.New("kernel", dtype, kernelShape, values[:2])
+		bias, _ := tensor.New("bias", dtype, []int{1}, values[2:])
+		data, err := tensor.Encode([]*tensor.Tensor{kernel, bias})
+		if err != nil {
+			t.Fatalf("Encode(): unexpected error: %v", err)
+		}
+		return data
+	}
+
+	spec := &ModelSpec{ID: "test", Weights: encode(tensor.Float32, []int{2, 1}, 0, 0, 0),
+		Config: ModelConfig{Round: RoundConfig{MinParticipants: 2}}}
+	if _, err := c.CreateModel(spec); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		c.RegisterClient(&Identity{ClientID: id, PodUID: id}, &ClientRegistration{ModelID: "test"})
+	}
+	server := newTestAPIServer(c)
+	defer server.Close()
+
+	cases := []struct {
+		info     string
+		caller   string
+		weights  []byte
+		expected int
+	}{
+		{"should reject flat vector", "a", encodeWeights([]float32{1, 1, 1}), http.StatusBadRequest},
+		{"should reject other shapes", "a", encode(tensor.Float32, []int{1, 2}, 1, 1, 1), http.StatusBadRequest},
+		{"should accept float16 tensors", "a", encode(tensor.Float16, []int{2, 1}, 2, 4, 6), http.StatusAccepted},
+		{"should accept int8 tensors", "b", encode(tensor.Int8, []int{2, 1}, 0, 0, 0), http.StatusAccepted},
+	}
+
+	url := server.URL + "/api/v1/fl/model/test/update"
+	for _, tc := range cases {
+		update := &ModelUpdate{ModelID: "test", BaseVersion: 1, WeightUpdate: tc.weights, NumSamples: 1}
+		if status := doTestClientRequest(t, tc.caller, http.MethodPost, url, update, nil); status != tc.expected {
+			t.Errorf("%s: expected status %d, got %d", tc.info, tc.expected, status)
+		}
+	}
+
+	model, _ := c.GetModel("test")
+	expected := []tensor.Spec{{Name: "kernel", DType: tensor.Float32, Shape: []int{2, 1}},
+		{Name: "bias", DType: tensor.Float32, Shape: []int{1}}}
+	if model.Version != 2 || !reflect.DeepEqual(model.Schema, expected) {
+		t.Fatalf("expected version 2 with the layout of the model, got %d with %v", model.Version, model.Schema)
+	}
+	if weights, _ := decodeWeights(model.Weights); !reflect.DeepEqual(weights, []float32{1, 2, 3}) {
+		t.Errorf("expected aggregated weights [1 2 3], got %v", weights)
+	}
+}
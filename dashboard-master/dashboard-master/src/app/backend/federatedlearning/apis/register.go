@@ -0,0 +1,6 @@
+package apis
+
+// GroupName is the group name of the federated learning API.
+const (
+	GroupName = "federatedlearning.dashboard.k8s.io"
+)
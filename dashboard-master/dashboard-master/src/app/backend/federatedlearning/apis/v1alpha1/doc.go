@@ -0,0 +1,7 @@
+// +k8s:deepcopy-gen=package
+// +groupName=federatedlearning.dashboard.k8s.io
+// +groupGoName=FederatedLearning
+
+// Package v1alpha1 is the v1alpha1 version of the federated learning API. It lets models trained by the
+// coordinator be declared as FederatedModel objects.
+package v1alpha1
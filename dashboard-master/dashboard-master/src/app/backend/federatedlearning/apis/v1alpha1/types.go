@@ -0,0 +1,90 @@
+package v1alpha1
+
+import (
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionReady is the condition telling whether the model of a FederatedModel is in sync with its spec.
+const ConditionReady = "Ready"
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FederatedModel declares a model trained by the federated learning coordinator.
+type FederatedModel struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FederatedModelSpec   `json:"spec"`
+	Status FederatedModelStatus `json:"status,omitempty"`
+}
+
+// FederatedModelSpec holds the desired state of a model.
+type FederatedModelSpec struct {
+	// ModelID is the ID of the model in the coordinator. Defaults to the name of the object.
+	ModelID string `json:"modelId,omitempty"`
+	// Description describes the model. It is the description of the first version.
+	Description string `json:"description,omitempty"`
+	// InitialWeights are the weights of the first version of the model. They are only read when the model
+	// is created.
+	InitialWeights WeightsSource `json:"initialWeights"`
+	// Selector selects the pods of the xApps that may enroll in the model. They have to run in the
+	// namespace of the object. If it is nil, every client may enroll.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Limits limit the updates accepted in the training rounds of the model.
+	Limits UpdateLimits `json:"limits,omitempty"`
+}
+
+// WeightsSource references weights encoded the way the coordinator expects them.
+type WeightsSource struct {
+	// ConfigMapKeyRef selects a key of a ConfigMap in the namespace of the object. Binary data is preferred
+	// over string data under the same key.
+	ConfigMapKeyRef *coreV1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// UpdateLimits limit the updates accepted in a training round.
+type UpdateLimits struct {
+	// MinParticipants is the number of updates a round needs to be aggregated.
+	MinParticipants int `json:"minParticipants,omitempty"`
+	// MaxParticipants caps the number of updates accepted in a round. Zero means no cap.
+	MaxParticipants int `json:"maxParticipants,omitempty"`
+	// RoundDurationSeconds is the time after which a round is aggregated or failed. Zero means no deadline.
+	RoundDurationSeconds int `json:"roundDurationSeconds,omitempty"`
+}
+
+// FederatedModelStatus holds the observed state of a model.
+type FederatedModelStatus struct {
+	// ObservedGeneration is the generation of the spec the status was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// ModelID is the ID of the model in the coordinator.
+	ModelID string `json:"modelId,omitempty"`
+	// CurrentVersion is the version of the model served to clients.
+	CurrentVersion int `json:"currentVersion,omitempty"`
+	// Digest is the digest of the weights of the current version.
+	Digest string `json:"digest,omitempty"`
+	// Round is the number of the latest training round.
+	Round int `json:"round,omitempty"`
+	// Clients are the clients enrolled in the model.
+	Clients []ParticipantStatus `json:"clients,omitempty"`
+	// Conditions describe the state of the model, see ConditionReady.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ParticipantStatus is the status of a client enrolled in a model.
+type ParticipantStatus struct {
+	// ID is the ID of the client, the namespace and name of its pod.
+	ID string `json:"id"`
+	// Status is the status of the client, e.g. "available", "training" or "offline".
+	Status string `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FederatedModelList holds a list of FederatedModel objects.
+type FederatedModelList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []FederatedModel `json:"items"`
+}
@@ -0,0 +1,294 @@
+package federatedlearning
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Names of the built-in staleness functions. They follow FedAsync (Xie et al.), where the staleness of an
+// update is the number of versions created since its base version.
+const (
+	// StalenessConstant does not discount stale updates.
+	StalenessConstant = "constant"
+	// StalenessPolynomial discounts an update with staleness t by (1+t)^-a.
+	StalenessPolynomial = "polynomial"
+	// StalenessHinge does not discount updates up to staleness b and discounts older ones by
+	// 1/(a(t-b)+1).
+	StalenessHinge = "hinge"
+)
+
+// Defaults of the asynchronous training configuration.
+const (
+	// DefaultBufferSize folds every update into the model as it arrives.
+	DefaultBufferSize = 1
+	// DefaultMixingRate is the fraction of the buffered change applied to the model.
+	DefaultMixingRate = 0.5
+	// DefaultStalenessExponent is the exponent a of StalenessPolynomial.
+	DefaultStalenessExponent = 0.5
+	// DefaultHingeSlope and DefaultHingeThreshold are the parameters a and b of StalenessHinge.
+	DefaultHingeSlope     = 10
+	DefaultHingeThreshold = 4
+)
+
+// AsyncConfig configures asynchronous training of a model. Instead of waiting for the clients of a round,
+// updates are buffered as they arrive and folded into a new version once BufferSize updates were received,
+// as in FedBuff (Nguyen et al.). A buffer size of 1 folds every update immediately, as in FedAsync.
+//
+// Updates may be trained on any earlier version. The change an update makes to its base version is
+// discounted by the staleness function, averaged over the buffer weighted by sample counts, and added to
+// the current version scaled by MixingRate. Rounds of asynchronous models are buffers: their quorum and cap
+// are the buffer size, and a partially filled buffer is folded when the round deadline passes.
+type AsyncConfig struct {
+	Enabled bool `json:"enabled"`
+	// BufferSize is the number of updates folded into a new version at once. Defaults to DefaultBufferSize.
+	BufferSize int `json:"bufferSize,omitempty"`
+	// MixingRate is the fraction of the buffered change applied to the current version, between 0 and 1.
+	// Defaults to DefaultMixingRate.
+	MixingRate float64 `json:"mixingRate,omitempty"`
+	// Staleness is the name of a registered staleness function. Defaults to StalenessPolynomial.
+	Staleness string `json:"staleness,omitempty"`
+	// StalenessExponent is the exponent of StalenessPolynomial. Defaults to DefaultStalenessExponent.
+	StalenessExponent float64 `json:"stalenessExponent,omitempty"`
+	// HingeSlope and HingeThreshold parameterize StalenessHinge. They default to DefaultHingeSlope and
+	// DefaultHingeThreshold.
+	HingeSlope     float64 `json:"hingeSlope,omitempty"`
+	HingeThreshold int     `json:"hingeThreshold,omitempty"`
+	// MaxStaleness is the staleness above which updates are refused. Zero accepts updates of any version.
+	MaxStaleness int `json:"maxStaleness,omitempty"`
+}
+
+// bufferSize returns the configured buffer size.
+func (c AsyncConfig) bufferSize() int {
+	if c.BufferSize <= 0 {
+		return DefaultBufferSize
+	}
+
+	return c.BufferSize
+}
+
+// mixingRate returns the configured mixing rate.
+func (c AsyncConfig) mixingRate() float64 {
+	if c.MixingRate == 0 {
+		return DefaultMixingRate
+	}
+
+	return c.MixingRate
+}
+
+// validate checks the asynchronous training configuration of a model.
+func (c AsyncConfig) validate(config ModelConfig) error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.BufferSize < 0 || c.MaxStaleness < 0 {
+		return fmt.Errorf("buffer size and maximum staleness must not be negative")
+	}
+	if c.MixingRate < 0 || c.MixingRate > 1 {
+		return fmt.Errorf("mixing rate must be between 0 and 1, got %g", c.MixingRate)
+	}
+	if _, err := NewStalenessFunction(c); err != nil {
+		return err
+	}
+
+	if config.Aggregation.Algorithm != "" && config.Aggregation.Algorithm != FedAvg {
+		return fmt.Errorf("asynchronous training requires the %s aggregator, got %s", FedAvg,
+			config.Aggregation.Algorithm)
+	}
+	if config.Privacy.enabled() || config.SecureAggregation.Enabled {
+		return fmt.Errorf("asynchronous training cannot be combined with differential privacy or secure " +
+			"aggregation")
+	}
+
+	return nil
+}
+
+// StalenessFunction returns the factor the change of an update with the given staleness is discounted by.
+type StalenessFunction func(staleness int) float64
+
+// StalenessFactory creates a staleness function from the asynchronous training configuration of a model.
+type StalenessFactory func(config AsyncConfig) (StalenessFunction, error)
+
+var (
+	stalenessMu        sync.RWMutex
+	stalenessFunctions = map[string]StalenessFactory{
+		StalenessConstant:   newConstantStaleness,
+		StalenessPolynomial: newPolynomialStaleness,
+		StalenessHinge:      newHingeStaleness,
+	}
+)
+
+// RegisterStalenessFunction makes a staleness function available under the given name. Registering the
+// same name twice replaces the previous factory.
+func RegisterStalenessFunction(name string, factory StalenessFactory) {
+	stalenessMu.Lock()
+	defer stalenessMu.Unlock()
+	stalenessFunctions[name] = factory
+}
+
+// StalenessFunctions returns names of all registered staleness functions.
+func StalenessFunctions() []string {
+	stalenessMu.RLock()
+	defer stalenessMu.RUnlock()
+
+	names := make([]string, 0, len(stalenessFunctions))
+	for name := range stalenessFunctions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewStalenessFunction creates the staleness function described by config.
+func NewStalenessFunction(config AsyncConfig) (StalenessFunction, error) {
+	name := config.Staleness
+	if name == "" {
+		name = StalenessPolynomial
+	}
+
+	stalenessMu.RLock()
+	factory, exists := stalenessFunctions[name]
+	stalenessMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown staleness function: %s", name)
+	}
+
+	return factory(config)
+}
+
+func newConstantStaleness(AsyncConfig) (StalenessFunction, error) {
+	return func(int) float64 { return 1 }, nil
+}
+
+func newPolynomialStaleness(config AsyncConfig) (StalenessFunction, error) {
+	exponent := config.StalenessExponent
+	if exponent == 0 {
+		exponent = DefaultStalenessExponent
+	}
+	if exponent < 0 {
+		return nil, fmt.Errorf("staleness exponent must not be negative, got %g", exponent)
+	}
+
+	return func(staleness int) float64 {
+		return math.Pow(float64(staleness+1), -exponent)
+	}, nil
+}
+
+func newHingeStaleness(config AsyncConfig) (StalenessFunction, error) {
+	slope, threshold := config.HingeSlope, config.HingeThreshold
+	if slope == 0 {
+		slope = DefaultHingeSlope
+	}
+	if threshold == 0 {
+		threshold = DefaultHingeThreshold
+	}
+	if slope < 0 || threshold < 0 {
+		return nil, fmt.Errorf("hinge slope and threshold must not be negative")
+	}
+
+	return func(staleness int) float64 {
+		if staleness <= threshold {
+			return 1
+		}
+		return 1 / (slope*float64(staleness-threshold) + 1)
+	}, nil
+}
+
+// checkStaleness checks that an update of an asynchronous model is based on an existing version that is not
+// too old. Must be called with the lock held.
+func (c *Coordinator) checkStaleness(model *GlobalModel, update *ModelUpdate) error {
+	config := c.configs[model.ID].Async
+	staleness := model.Version - update.BaseVersion
+	if staleness < 0 {
+		return fmt.Errorf("%w: update from client %s is based on version %d, model %s is at version %d",
+			ErrStaleUpdate, update.ClientID, update.BaseVersion, model.ID, model.Version)
+	}
+	if config.MaxStaleness > 0 && staleness > config.MaxStaleness {
+		return fmt.Errorf("%w: update from client %s is %d versions behind model %s, at most %d are accepted",
+			ErrStaleUpdate, update.ClientID, staleness, model.ID, config.MaxStaleness)
+	}
+
+	if _, err := c.getVersion(model.ID, update.BaseVersion); err != nil {
+		return fmt.Errorf("%w: update from client %s: %v", ErrStaleUpdate, update.ClientID, err)
+	}
+
+	return nil
+}
+
+// baseVersions returns the versions updates of an asynchronous model are based on. Versions whose weights
+// cannot be loaded are left out. Must be called with the lock held.
+func (c *Coordinator) baseVersions(modelID string, updates []*ModelUpdate) map[int]*GlobalModel {
+	bases := make(map[int]*GlobalModel)
+	for _, update := range updates {
+		if _, exists := bases[update.BaseVersion]; exists {
+			continue
+		}
+
+		base, err := c.getVersion(modelID, update.BaseVersion)
+		if err != nil {
+			log.Printf("Failed to load base version of update from client %s: %v", update.ClientID, err)
+			continue
+		}
+		bases[update.BaseVersion] = base
+	}
+
+	return bases
+}
+
+// asyncAggregate folds buffered updates into the current version of an asynchronous model.
+func asyncAggregate(job *aggregationJob) (*Aggregation, error) {
+	config := job.config.Async
+	staleness, err := NewStalenessFunction(config)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := decodeWeights(job.model.Weights)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := decodeUpdates(job.model, job.updates)
+	if err != nil {
+		return nil, err
+	}
+
+	var total float64
+	for _, update := range job.updates {
+		total += sampleWeight(update)
+	}
+
+	change := make([]float64, len(current))
+	for i, update := range job.updates {
+		base, exists := job.bases[update.BaseVersion]
+		if !exists {
+			return nil, fmt.Errorf("base version %d of update from client %s is not available",
+				update.BaseVersion, update.ClientID)
+		}
+
+		baseWeights, err := decodeWeights(base.Weights)
+		if err != nil {
+			return nil, err
+		}
+		if len(baseWeights) != len(current) || len(vectors[i]) != len(current) {
+			return nil, fmt.Errorf("update from client %s has %d weights and is based on %d weights, model "+
+				"has %d", update.ClientID, len(vectors[i]), len(baseWeights), len(current))
+		}
+
+		factor := sampleWeight(update) / total * staleness(job.model.Version-update.BaseVersion)
+		for j, w := range vectors[i] {
+			change[j] += factor * float64(w-baseWeights[j])
+		}
+	}
+
+	rate := config.mixingRate()
+	result := make([]float32, len(current))
+	for i, w := range current {
+		result[i] = float32(float64(w) + rate*change[i])
+	}
+
+	return &Aggregation{Weights: encodeWeights(result)}, nil
+}
@@ -0,0 +1,122 @@
+package federatedlearning
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestAsyncCoordinator(t *testing.T, config AsyncConfig, round RoundConfig, clients ...string) *Coordinator {
+	config.Enabled = true
+	c := NewCoordinator()
+	spec := &ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}),
+		Config: ModelConfig{Round: round, Async: config}}
+	if _, err := c.CreateModel(spec); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+
+	for _, id := range clients {
+		c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"})
+	}
+
+	return c
+}
+
+func submitAsyncUpdate(c *Coordinator, client string, baseVersion int, weights []float32) error {
+	return c.SubmitModelUpdate(&ModelUpdate{ClientID: client, ModelID: "test", BaseVersion: baseVersion,
+		WeightUpdate: encodeWeights(weights), NumSamples: 1})
+}
+
+func TestAsyncFoldsUpdates(t *testing.T) {
+	c := newTestAsyncCoordinator(t, AsyncConfig{StalenessExponent: 1}, RoundConfig{}, "a", "b")
+
+	steps := []struct {
+		info        string
+		client      string
+		baseVersion int
+		weights     []float32
+		expected    []float32
+	}{
+		{"fresh update should be mixed into the model", "a", 1, []float32{2, 2}, []float32{1, 1}},
+		{"update one version behind should be discounted by half", "b", 1, []float32{4, 4}, []float32{2, 2}},
+		{"change of update should be relative to its base version", "a", 2, []float32{3, 3}, []float32{2.5, 2.5}},
+	}
+
+	for i, step := range steps {
+		if err := submitAsyncUpdate(c, step.client, step.baseVersion, step.weights); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.info, err)
+		}
+
+		model, _ := c.GetModel("test")
+		weights, _ := decodeWeights(model.Weights)
+		if model.Version != i+2 || !reflect.DeepEqual(weights, step.expected) {
+			t.Errorf("%s: expected version %d with weights %v, got version %d with %v", step.info, i+2,
+				step.expected, model.Version, weights)
+		}
+	}
+}
+
+func TestAsyncBuffer(t *testing.T) {
+	c := newTestAsyncCoordinator(t, AsyncConfig{BufferSize: 2, MixingRate: 1, MaxStaleness: 1},
+		RoundConfig{DurationSeconds: 10}, "a", "b", "c")
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	submitAsyncUpdate(c, "a", 1, []float32{2, 2})
+	if model, _ := c.GetModel("test"); model.Version != 1 {
+		t.Fatalf("expected update to be buffered, got version %d", model.Version)
+	}
+
+	submitAsyncUpdate(c, "b", 1, []float32{4, 4})
+	model, _ := c.GetModel("test")
+	weights, _ := decodeWeights(model.Weights)
+	if expected := []float32{3, 3}; model.Version != 2 || !reflect.DeepEqual(weights, expected) {
+		t.Fatalf("expected full buffer to be folded into version 2 with weights %v, got version %d with %v",
+			expected, model.Version, weights)
+	}
+
+	// A partially filled buffer is folded at the deadline.
+	submitAsyncUpdate(c, "c", 2, []float32{5, 5})
+	now = now.Add(11 * time.Second)
+	c.checkRounds()
+	if model, _ := c.GetModel("test"); model.Version != 3 {
+		t.Fatalf("expected partial buffer to be folded at deadline, got version %d", model.Version)
+	}
+
+	if err := submitAsyncUpdate(c, "a", 1, []float32{1, 1}); !errors.Is(err, ErrStaleUpdate) {
+		t.Errorf("expected update beyond maximum staleness to be refused, got %v", err)
+	}
+	if err := submitAsyncUpdate(c, "a", 4, []float32{1, 1}); !errors.Is(err, ErrStaleUpdate) {
+		t.Errorf("expected update of unknown version to be refused, got %v", err)
+	}
+}
+
+func TestStalenessFunctions(t *testing.T) {
+	cases := []struct {
+		config   AsyncConfig
+		expected []float64
+	}{
+		{AsyncConfig{Staleness: StalenessConstant}, []float64{1, 1, 1, 1}},
+		{AsyncConfig{Staleness: StalenessPolynomial}, []float64{1, 1 / math.Sqrt(2), 1 / math.Sqrt(3), 0.5}},
+		{AsyncConfig{Staleness: StalenessHinge, HingeSlope: 1, HingeThreshold: 1}, []float64{1, 1, 0.5, 1.0 / 3}},
+	}
+
+	for _, tc := range cases {
+		staleness, err := NewStalenessFunction(tc.config)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.config.Staleness, err)
+		}
+
+		for i, expected := range tc.expected {
+			if actual := staleness(i); math.Abs(actual-expected) > 1e-9 {
+				t.Errorf("%s: expected factor %g at staleness %d, got %g", tc.config.Staleness, expected, i, actual)
+			}
+		}
+	}
+
+	if _, err := NewStalenessFunction(AsyncConfig{Staleness: "unknown"}); err == nil {
+		t.Error("expected error for unknown staleness function")
+	}
+}
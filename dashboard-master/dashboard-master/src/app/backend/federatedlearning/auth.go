@@ -0,0 +1,68 @@
+package federatedlearning
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// Identity is the identity of an authenticated client. Clients run in pods, so the client ID is derived
+// from the pod the caller runs in.
+type Identity struct {
+	// ClientID is the stable ID of the client, see PodClientID.
+	ClientID       string
+	Namespace      string
+	PodName        string
+	PodUID         string
+	ServiceAccount string
+}
+
+// Authenticator authenticates clients calling the API.
+type Authenticator interface {
+	// Authenticate returns the identity of the caller of the request or an error wrapping
+	// ErrUnauthenticated.
+	Authenticate(req *http.Request) (*Identity, error)
+}
+
+// PodClientID returns the client ID of a client running in the given pod. It stays the same when the
+// containers of the pod are restarted, and when a pod of a StatefulSet is recreated.
+func PodClientID(namespace, podName string) string {
+	return namespace + ":" + podName
+}
+
+// RemoteHost returns the host of the remote address of a request, the address requests are rate limited by
+// before they are authenticated.
+func RemoteHost(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+
+	return remoteAddr
+}
+
+// authorizeClient authenticates the caller of the request and checks that it is the given client. An empty
+// client ID is accepted, the returned identity tells the ID of the caller. Requests from remote addresses or
+// of clients that exceeded their request rate are rejected with a RateLimitError. Addresses are checked
+// before authentication, which may be expensive.
+func (a *API) authorizeClient(req *restful.Request, clientID string) (*Identity, error) {
+	if err := a.coordinator.CheckAddressRateLimit(RemoteHost(req.Request.RemoteAddr)); err != nil {
+		return nil, err
+	}
+
+	identity, err := a.authenticator.Authenticate(req.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.coordinator.CheckRateLimit(identity.ClientID); err != nil {
+		return nil, err
+	}
+
+	if clientID != "" && clientID != identity.ClientID {
+		return nil, fmt.Errorf("%w: caller %s is not client %s", ErrClientMismatch, identity.ClientID, clientID)
+	}
+
+	return identity, nil
+}
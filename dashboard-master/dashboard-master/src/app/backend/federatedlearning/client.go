@@ -1,12 +1,64 @@
-
 package federatedlearning
 
-import "time"
+import (
+	"time"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/compress"
+)
+
+// Statuses of a client.
+const (
+	// ClientAvailable is the status of a client that is alive and not training.
+	ClientAvailable = "available"
+	// ClientTraining is the status of a client that fetched a model and did not submit its update yet.
+	ClientTraining = "training"
+	// ClientOffline is the status of a client that was not seen for longer than the client timeout.
+	ClientOffline = "offline"
+)
+
+// ClientRegistration is sent by a client to enroll in a model.
+type ClientRegistration struct {
+	ModelID string `json:"modelId"`
+	// NumSamples is the size of the local data set of the client. It is used by selection strategies.
+	NumSamples int `json:"numSamples"`
+	// Compression is the compression of model updates the client supports. Unsupported compression is not
+	// used for its updates.
+	Compression compress.Options `json:"compression"`
+}
 
 // Client represents a registered xApp that can participate in training.
 type Client struct {
-    ID         string    `json:"id"`         // Kubernetes Pod Name/UID for uniqueness
-    Status     string    `json:"status"`     // e.g., "available", "training", "offline"
-    RegisteredAt time.Time `json:"registeredAt"`
-    LastSeen   time.Time `json:"lastSeen"`
+	ID        string `json:"id"` // Namespace and name of the pod, see PodClientID
+	Namespace string `json:"namespace,omitempty"`
+	PodName   string `json:"podName,omitempty"`
+	PodUID    string `json:"podUid,omitempty"`
+	// ModelID is the model the client is enrolled in. Clients can only train the model they are enrolled in.
+	ModelID      string    `json:"modelId"`
+	Status       string    `json:"status"` // e.g., "available", "training", "offline"
+	RegisteredAt time.Time `json:"registeredAt"`
+	LastSeen     time.Time `json:"lastSeen"`
+	// LastSelected is the time the client was last invited to a round.
+	LastSelected *time.Time `json:"lastSelected,omitempty"`
+	// NumSamples is the size of the local data set of the client, as reported at registration or with its
+	// latest update.
+	NumSamples int `json:"numSamples,omitempty"`
+	// Compression is the compression of model updates negotiated at registration, the compression
+	// offered for the model that the client supports.
+	Compression compress.Options `json:"compression"`
+}
+
+// EnrollmentPolicy decides which clients may enroll in a model.
+type EnrollmentPolicy interface {
+	// CheckEnrollment returns an error wrapping ErrClientNotEnrolled if the client may not enroll in the
+	// model.
+	CheckEnrollment(identity *Identity, modelID string) error
+}
+
+// SetEnrollmentPolicy sets the policy deciding which clients may enroll in a model. Clients that already
+// enrolled are only checked when they register again.
+func (c *Coordinator) SetEnrollmentPolicy(policy EnrollmentPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.enrollment = policy
 }
@@ -0,0 +1,140 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/apis/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeFederatedModels implements FederatedModelInterface
+type FakeFederatedModels struct {
+	Fake *FakeFederatedLearningV1alpha1
+	ns   string
+}
+
+var federatedmodelsResource = schema.GroupVersionResource{Group: "federatedlearning.dashboard.k8s.io", Version: "v1alpha1", Resource: "federatedmodels"}
+
+var federatedmodelsKind = schema.GroupVersionKind{Group: "federatedlearning.dashboard.k8s.io", Version: "v1alpha1", Kind: "FederatedModel"}
+
+// Get takes name of the federatedModel, and returns the corresponding federatedModel object, and an error if there is any.
+func (c *FakeFederatedModels) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.FederatedModel, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(federatedmodelsResource, c.ns, name), &v1alpha1.FederatedModel{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.FederatedModel), err
+}
+
+// List takes label and field selectors, and returns the list of FederatedModels that match those selectors.
+func (c *FakeFederatedModels) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.FederatedModelList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(federatedmodelsResource, federatedmodelsKind, c.ns, opts), &v1alpha1.FederatedModelList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.FederatedModelList{ListMeta: obj.(*v1alpha1.FederatedModelList).ListMeta}
+	for _, item := range obj.(*v1alpha1.FederatedModelList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested federatedModels.
+func (c *FakeFederatedModels) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(federatedmodelsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a federatedModel and creates it.  Returns the server's representation of the federatedModel, and an error, if there is any.
+func (c *FakeFederatedModels) Create(ctx context.Context, federatedModel *v1alpha1.FederatedModel, opts v1.CreateOptions) (result *v1alpha1.FederatedModel, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(federatedmodelsResource, c.ns, federatedModel), &v1alpha1.FederatedModel{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.FederatedModel), err
+}
+
+// Update takes the representation of a federatedModel and updates it. Returns the server's representation of the federatedModel, and an error, if there is any.
+func (c *FakeFederatedModels) Update(ctx context.Context, federatedModel *v1alpha1.FederatedModel, opts v1.UpdateOptions) (result *v1alpha1.FederatedModel, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(federatedmodelsResource, c.ns, federatedModel), &v1alpha1.FederatedModel{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.FederatedModel), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeFederatedModels) UpdateStatus(ctx context.Context, federatedModel *v1alpha1.FederatedModel, opts v1.UpdateOptions) (*v1alpha1.FederatedModel, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(federatedmodelsResource, "status", c.ns, federatedModel), &v1alpha1.FederatedModel{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.FederatedModel), err
+}
+
+// Delete takes name of the federatedModel and deletes it. Returns an error if one occurs.
+func (c *FakeFederatedModels) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteActionWithOptions(federatedmodelsResource, c.ns, name, opts), &v1alpha1.FederatedModel{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeFederatedModels) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(federatedmodelsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.FederatedModelList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched federatedModel.
+func (c *FakeFederatedModels) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.FederatedModel, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(federatedmodelsResource, c.ns, name, pt, data, subresources...), &v1alpha1.FederatedModel{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.FederatedModel), err
+}
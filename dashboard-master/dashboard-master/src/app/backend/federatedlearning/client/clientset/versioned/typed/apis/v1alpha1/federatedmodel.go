@@ -0,0 +1,193 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/apis/v1alpha1"
+	scheme "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// FederatedModelsGetter has a method to return a FederatedModelInterface.
+// A group's client should implement this interface.
+type FederatedModelsGetter interface {
+	FederatedModels(namespace string) FederatedModelInterface
+}
+
+// FederatedModelInterface has methods to work with FederatedModel resources.
+type FederatedModelInterface interface {
+	Create(ctx context.Context, federatedModel *v1alpha1.FederatedModel, opts v1.CreateOptions) (*v1alpha1.FederatedModel, error)
+	Update(ctx context.Context, federatedModel *v1alpha1.FederatedModel, opts v1.UpdateOptions) (*v1alpha1.FederatedModel, error)
+	UpdateStatus(ctx context.Context, federatedModel *v1alpha1.FederatedModel, opts v1.UpdateOptions) (*v1alpha1.FederatedModel, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.FederatedModel, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.FederatedModelList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.FederatedModel, err error)
+	FederatedModelExpansion
+}
+
+// federatedModels implements FederatedModelInterface
+type federatedModels struct {
+	client rest.Interface
+	ns     string
+}
+
+// newFederatedModels returns a FederatedModels
+func newFederatedModels(c *FederatedLearningV1alpha1Client, namespace string) *federatedModels {
+	return &federatedModels{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the federatedModel, and returns the corresponding federatedModel object, and an error if there is any.
+func (c *federatedModels) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.FederatedModel, err error) {
+	result = &v1alpha1.FederatedModel{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("federatedmodels").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of FederatedModels that match those selectors.
+func (c *federatedModels) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.FederatedModelList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.FederatedModelList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("federatedmodels").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested federatedModels.
+func (c *federatedModels) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("federatedmodels").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a federatedModel and creates it.  Returns the server's representation of the federatedModel, and an error, if there is any.
+func (c *federatedModels) Create(ctx context.Context, federatedModel *v1alpha1.FederatedModel, opts v1.CreateOptions) (result *v1alpha1.FederatedModel, err error) {
+	result = &v1alpha1.FederatedModel{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("federatedmodels").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(federatedModel).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a federatedModel and updates it. Returns the server's representation of the federatedModel, and an error, if there is any.
+func (c *federatedModels) Update(ctx context.Context, federatedModel *v1alpha1.FederatedModel, opts v1.UpdateOptions) (result *v1alpha1.FederatedModel, err error) {
+	result = &v1alpha1.FederatedModel{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("federatedmodels").
+		Name(federatedModel.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(federatedModel).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *federatedModels) UpdateStatus(ctx context.Context, federatedModel *v1alpha1.FederatedModel, opts v1.UpdateOptions) (result *v1alpha1.FederatedModel, err error) {
+	result = &v1alpha1.FederatedModel{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("federatedmodels").
+		Name(federatedModel.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(federatedModel).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the federatedModel and deletes it. Returns an error if one occurs.
+func (c *federatedModels) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("federatedmodels").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *federatedModels) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("federatedmodels").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched federatedModel.
+func (c *federatedModels) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.FederatedModel, err error) {
+	result = &v1alpha1.FederatedModel{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("federatedmodels").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	apisv1alpha1 "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/apis/v1alpha1"
+	versioned "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/clientset/versioned"
+	internalinterfaces "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/listers/apis/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// FederatedModelInformer provides access to a shared informer and lister for
+// FederatedModels.
+type FederatedModelInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.FederatedModelLister
+}
+
+type federatedModelInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewFederatedModelInformer constructs a new informer for FederatedModel type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFederatedModelInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredFederatedModelInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredFederatedModelInformer constructs a new informer for FederatedModel type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredFederatedModelInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.FederatedLearningV1alpha1().FederatedModels(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.FederatedLearningV1alpha1().FederatedModels(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&apisv1alpha1.FederatedModel{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *federatedModelInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredFederatedModelInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *federatedModelInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&apisv1alpha1.FederatedModel{}, f.defaultInformer)
+}
+
+func (f *federatedModelInformer) Lister() v1alpha1.FederatedModelLister {
+	return v1alpha1.NewFederatedModelLister(f.Informer().GetIndexer())
+}
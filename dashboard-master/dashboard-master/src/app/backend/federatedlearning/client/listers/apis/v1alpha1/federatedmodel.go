@@ -0,0 +1,97 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/apis/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// FederatedModelLister helps list FederatedModels.
+// All objects returned here must be treated as read-only.
+type FederatedModelLister interface {
+	// List lists all FederatedModels in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.FederatedModel, err error)
+	// FederatedModels returns an object that can list and get FederatedModels.
+	FederatedModels(namespace string) FederatedModelNamespaceLister
+	FederatedModelListerExpansion
+}
+
+// federatedModelLister implements the FederatedModelLister interface.
+type federatedModelLister struct {
+	indexer cache.Indexer
+}
+
+// NewFederatedModelLister returns a new FederatedModelLister.
+func NewFederatedModelLister(indexer cache.Indexer) FederatedModelLister {
+	return &federatedModelLister{indexer: indexer}
+}
+
+// List lists all FederatedModels in the indexer.
+func (s *federatedModelLister) List(selector labels.Selector) (ret []*v1alpha1.FederatedModel, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.FederatedModel))
+	})
+	return ret, err
+}
+
+// FederatedModels returns an object that can list and get FederatedModels.
+func (s *federatedModelLister) FederatedModels(namespace string) FederatedModelNamespaceLister {
+	return federatedModelNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// FederatedModelNamespaceLister helps list and get FederatedModels.
+// All objects returned here must be treated as read-only.
+type FederatedModelNamespaceLister interface {
+	// List lists all FederatedModels in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.FederatedModel, err error)
+	// Get retrieves the FederatedModel from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.FederatedModel, error)
+	FederatedModelNamespaceListerExpansion
+}
+
+// federatedModelNamespaceLister implements the FederatedModelNamespaceLister
+// interface.
+type federatedModelNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all FederatedModels in the indexer for a given namespace.
+func (s federatedModelNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.FederatedModel, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.FederatedModel))
+	})
+	return ret, err
+}
+
+// Get retrieves the FederatedModel from the indexer for a given namespace and name.
+func (s federatedModelNamespaceLister) Get(name string) (*v1alpha1.FederatedModel, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("federatedmodel"), name)
+	}
+	return obj.(*v1alpha1.FederatedModel), nil
+}
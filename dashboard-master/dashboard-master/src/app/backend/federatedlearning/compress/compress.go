@@ -0,0 +1,343 @@
+// Package compress implements compression of federated learning model updates.
+//
+// Clients can send the difference of their weights to the base version instead of the weights themselves
+// (delta), send only the largest values of the difference (top-k sparsification) and quantize the values they
+// send to 8 bits. The values left out by sparsification and the rounding errors of quantization are kept by
+// the Compressor and added to the next update, so that they are not lost (error feedback).
+//
+// A compressed update is encoded little-endian as:
+//
+//	magic      "FLCZ"
+//	version    uint8, currently 1
+//	flags      uint8, flagDelta | flagSparse | flagQuantized
+//	length     uint32, number of weights of the model
+//	count      uint32, number of values sent
+//	indices    count uint32 ascending indices of the values, only present for sparse updates
+//	scale      float32, only present for quantized updates, a value v represents v times the scale
+//	values     count int8 values if quantized, count float32 values otherwise
+package compress
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// magic starts every compressed update.
+var magic = []byte("FLCZ")
+
+// formatVersion is the version of the binary layout written by Compressor.
+const formatVersion = 1
+
+// Flags of a compressed update.
+const (
+	flagDelta     = 1 << 0
+	flagSparse    = 1 << 1
+	flagQuantized = 1 << 2
+)
+
+// headerSize is the size of the fixed part of a compressed update.
+const headerSize = 14
+
+// Options select the compression of model updates.
+type Options struct {
+	// Delta sends the difference of the weights to the base version of the update.
+	Delta bool `json:"delta,omitempty"`
+	// TopK is the fraction of the values of a delta update that is sent, the values with the largest
+	// magnitude are kept. Zero sends all values. Requires Delta.
+	TopK float64 `json:"topK,omitempty"`
+	// Quantize quantizes the values sent to 8 bits.
+	Quantize bool `json:"quantize,omitempty"`
+}
+
+// Enabled returns true if any compression is selected.
+func (o Options) Enabled() bool {
+	return o.Delta || o.TopK > 0 || o.Quantize
+}
+
+// Validate checks the options.
+func (o Options) Validate() error {
+	if o.TopK < 0 || o.TopK > 1 {
+		return fmt.Errorf("top-k fraction must be between 0 and 1, got %g", o.TopK)
+	}
+	if o.TopK > 0 && !o.Delta {
+		return fmt.Errorf("top-k sparsification requires delta updates")
+	}
+
+	return nil
+}
+
+// Negotiate returns the compression both sides support: o offered by the coordinator and supported by the
+// client. Clients that support top-k sparsification set TopK to the smallest fraction they are willing to
+// send, the larger of both fractions is used.
+func (o Options) Negotiate(supported Options) Options {
+	result := Options{Delta: o.Delta && supported.Delta, Quantize: o.Quantize && supported.Quantize}
+	if result.Delta && o.TopK > 0 && supported.TopK > 0 {
+		result.TopK = math.Max(o.TopK, supported.TopK)
+	}
+
+	return result
+}
+
+// Allows returns true if updates compressed with used may be sent to a coordinator that negotiated o.
+// Updates may always be sent with less compression than negotiated.
+func (o Options) Allows(used Options) bool {
+	return (!used.Delta || o.Delta) && (used.TopK == 0 || o.TopK > 0) && (!used.Quantize || o.Quantize)
+}
+
+// MaxValues returns the number of values of a model with length weights that updates compressed with o send
+// at most.
+func (o Options) MaxValues(length int) int {
+	if o.TopK == 0 {
+		return length
+	}
+
+	return min(max(int(math.Ceil(o.TopK*float64(length))), 1), length)
+}
+
+// Check returns an error unless the compressed update data may be sent to a coordinator that negotiated o.
+// Updates may not send more values than the negotiated top-k fraction, even if they are not sparse.
+func (o Options) Check(data []byte) error {
+	used, err := Inspect(data)
+	if err != nil {
+		return err
+	}
+	if !o.Allows(used) {
+		return fmt.Errorf("compression %+v was not negotiated, allowed is %+v", used, o)
+	}
+
+	length, count := int(binary.LittleEndian.Uint32(data[6:])), int(binary.LittleEndian.Uint32(data[10:]))
+	if maxValues := o.MaxValues(length); count > maxValues {
+		return fmt.Errorf("update sends %d of %d values, at most %d were negotiated", count, length, maxValues)
+	}
+
+	return nil
+}
+
+// IsCompressed returns true if data starts like a compressed update.
+func IsCompressed(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// Compressor compresses the updates of a single client. It keeps the compression error of previous updates
+// and adds it to the next one, so it has to be reused across rounds.
+type Compressor struct {
+	options  Options
+	residual []float32
+}
+
+// NewCompressor creates a compressor using options.
+func NewCompressor(options Options) (*Compressor, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Compressor{options: options}, nil
+}
+
+// Compress compresses weights trained from base. Base is only used by delta compression and may be nil
+// otherwise.
+func (c *Compressor) Compress(weights, base []float32) ([]byte, error) {
+	values := append([]float32(nil), weights...)
+	if c.options.Delta {
+		if len(base) != len(weights) {
+			return nil, fmt.Errorf("base has %d weights, update has %d", len(base), len(weights))
+		}
+		if len(c.residual) != len(values) {
+			c.residual = make([]float32, len(values))
+		}
+		for i := range values {
+			values[i] = values[i] - base[i] + c.residual[i]
+		}
+	}
+
+	indices := c.selectValues(values)
+	sent := make([]float32, len(indices))
+	for i, index := range indices {
+		sent[i] = values[index]
+	}
+
+	var flags uint8
+	var scale float32
+	var quantized []int8
+	if c.options.Delta {
+		flags |= flagDelta
+	}
+	if len(indices) < len(values) {
+		flags |= flagSparse
+	}
+	if c.options.Quantize {
+		flags |= flagQuantized
+		scale, quantized = quantize(sent)
+		for i := range sent {
+			sent[i] = float32(quantized[i]) * scale
+		}
+	}
+
+	// Whatever was not transmitted exactly is carried over to the next update.
+	if c.options.Delta {
+		copy(c.residual, values)
+		for i, index := range indices {
+			c.residual[index] -= sent[i]
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write(magic)
+	buf.WriteByte(formatVersion)
+	buf.WriteByte(flags)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(values)))
+	binary.Write(&buf, binary.LittleEndian, uint32(len(indices)))
+	if flags&flagSparse != 0 {
+		binary.Write(&buf, binary.LittleEndian, indices)
+	}
+	if flags&flagQuantized != 0 {
+		binary.Write(&buf, binary.LittleEndian, scale)
+		binary.Write(&buf, binary.LittleEndian, quantized)
+	} else {
+		binary.Write(&buf, binary.LittleEndian, sent)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// selectValues returns the ascending indices of the values to send.
+func (c *Compressor) selectValues(values []float32) []uint32 {
+	indices := make([]uint32, len(values))
+	for i := range indices {
+		indices[i] = uint32(i)
+	}
+	k := c.options.MaxValues(len(values))
+	if k >= len(values) {
+		return indices
+	}
+
+	sort.SliceStable(indices, func(i, j int) bool {
+		return math.Abs(float64(values[indices[i]])) > math.Abs(float64(values[indices[j]]))
+	})
+	indices = indices[:k]
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	return indices
+}
+
+// quantize quantizes values symmetrically to 8 bits with the scale that maps the largest magnitude to 127.
+func quantize(values []float32) (float32, []int8) {
+	var max float64
+	for _, v := range values {
+		max = math.Max(max, math.Abs(float64(v)))
+	}
+
+	quantized := make([]int8, len(values))
+	if max == 0 {
+		return 0, quantized
+	}
+
+	scale := float32(max / 127)
+	for i, v := range values {
+		quantized[i] = int8(math.Max(-127, math.Min(127, math.Round(float64(v/scale)))))
+	}
+
+	return scale, quantized
+}
+
+// Inspect returns the compression used by a compressed update.
+func Inspect(data []byte) (Options, error) {
+	if !IsCompressed(data) || len(data) < headerSize {
+		return Options{}, fmt.Errorf("data is not a compressed update")
+	}
+	if data[4] != formatVersion {
+		return Options{}, fmt.Errorf("unsupported compressed update version %d", data[4])
+	}
+
+	flags := data[5]
+	if flags&^(flagDelta|flagSparse|flagQuantized) != 0 {
+		return Options{}, fmt.Errorf("unknown compression flags %#x", flags)
+	}
+
+	options := Options{Delta: flags&flagDelta != 0, Quantize: flags&flagQuantized != 0}
+	if flags&flagSparse != 0 {
+		length, count := binary.LittleEndian.Uint32(data[6:]), binary.LittleEndian.Uint32(data[10:])
+		if length > 0 {
+			options.TopK = float64(count) / float64(length)
+		}
+	}
+
+	return options, nil
+}
+
+// Decompress restores the weights of a compressed update. Base are the weights of the version the update
+// was trained from, they are only used by delta updates and may be nil otherwise.
+func Decompress(data []byte, base []float32) ([]float32, error) {
+	options, err := Inspect(data)
+	if err != nil {
+		return nil, err
+	}
+	flags := data[5]
+	sparse := flags&flagSparse != 0
+
+	length := int(binary.LittleEndian.Uint32(data[6:]))
+	count := int(binary.LittleEndian.Uint32(data[10:]))
+	valueSize := 4
+	if options.Quantize {
+		valueSize = 1
+	}
+	expected := int64(headerSize) + int64(count)*int64(valueSize)
+	if sparse {
+		expected += int64(count) * 4
+	}
+	if options.Quantize {
+		expected += 4
+	}
+	if int64(len(data)) != expected {
+		return nil, fmt.Errorf("compressed update of %d values has %d bytes, expected %d", count, len(data), expected)
+	}
+	if sparse && !options.Delta {
+		return nil, fmt.Errorf("sparse updates have to be delta updates")
+	}
+	if count > length || !sparse && count != length {
+		return nil, fmt.Errorf("compressed update sends %d of %d values", count, length)
+	}
+	if options.Delta && len(base) != length {
+		return nil, fmt.Errorf("delta update of %d weights cannot be applied to %d weights", length, len(base))
+	}
+
+	offset := headerSize
+	indices := make([]int, count)
+	for i := range indices {
+		if !sparse {
+			indices[i] = i
+			continue
+		}
+
+		indices[i] = int(binary.LittleEndian.Uint32(data[offset:]))
+		offset += 4
+		if indices[i] >= length || i > 0 && indices[i] <= indices[i-1] {
+			return nil, fmt.Errorf("invalid index %d at position %d", indices[i], i)
+		}
+	}
+
+	weights := make([]float32, length)
+	if options.Delta {
+		copy(weights, base)
+	}
+
+	var scale float32
+	if options.Quantize {
+		scale = math.Float32frombits(binary.LittleEndian.Uint32(data[offset:]))
+		offset += 4
+	}
+	for _, index := range indices {
+		var value float32
+		if options.Quantize {
+			value = float32(int8(data[offset])) * scale
+		} else {
+			value = math.Float32frombits(binary.LittleEndian.Uint32(data[offset:]))
+		}
+		offset += valueSize
+		weights[index] += value
+	}
+
+	return weights, nil
+}
@@ -0,0 +1,142 @@
+package compress
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	base := []float32{1, 1, 1, 1}
+	weights := []float32{1.5, 0.9, 3, 1}
+	cases := []struct {
+		info      string
+		options   Options
+		expected  []float32
+		tolerance float64
+	}{
+		{"should send weights unchanged", Options{}, weights, 0},
+		{"should apply delta to base", Options{Delta: true}, weights, 0},
+		{"should send largest values of delta", Options{Delta: true, TopK: 0.5}, []float32{1.5, 1, 3, 1}, 0},
+		{"should quantize values", Options{Quantize: true}, weights, 3.0 / 127 / 2},
+		{"should combine all compressions", Options{Delta: true, TopK: 0.25, Quantize: true},
+			[]float32{1, 1, 3, 1}, 1e-6},
+	}
+
+	for _, tc := range cases {
+		compressor, err := NewCompressor(tc.options)
+		if err != nil {
+			t.Fatalf("%s: NewCompressor(): unexpected error: %v", tc.info, err)
+		}
+
+		data, err := compressor.Compress(weights, base)
+		if err != nil {
+			t.Fatalf("%s: Compress(): unexpected error: %v", tc.info, err)
+		}
+		if !IsCompressed(data) {
+			t.Errorf("%s: expected compressed data to be recognized", tc.info)
+		}
+		if used, _ := Inspect(data); !tc.options.Negotiate(tc.options).Allows(used) {
+			t.Errorf("%s: expected used compression %+v to be allowed by %+v", tc.info, used, tc.options)
+		}
+
+		actual, err := Decompress(data, base)
+		if err != nil {
+			t.Fatalf("%s: Decompress(): unexpected error: %v", tc.info, err)
+		}
+		for i := range tc.expected {
+			if math.Abs(float64(actual[i]-tc.expected[i])) > tc.tolerance {
+				t.Errorf("%s: expected %v, got %v", tc.info, tc.expected, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestCompressErrorFeedback(t *testing.T) {
+	compressor, _ := NewCompressor(Options{Delta: true, TopK: 0.5})
+	base := []float32{0, 0}
+
+	// The smaller value is held back in the first update and sent with the second.
+	first, _ := compressor.Compress([]float32{2, 1}, base)
+	if weights, _ := Decompress(first, base); !reflect.DeepEqual(weights, []float32{2, 0}) {
+		t.Errorf("expected first update [2 0], got %v", weights)
+	}
+
+	second, _ := compressor.Compress([]float32{0, 0.5}, base)
+	if weights, _ := Decompress(second, base); !reflect.DeepEqual(weights, []float32{0, 1.5}) {
+		t.Errorf("expected second update [0 1.5], got %v", weights)
+	}
+}
+
+func TestDecompressInvalid(t *testing.T) {
+	compressor, _ := NewCompressor(Options{Delta: true, TopK: 0.5})
+	data, _ := compressor.Compress([]float32{1, 2, 3, 4}, make([]float32, 4))
+	unordered := append([]byte(nil), data...)
+	// Swap the two indices.
+	copy(unordered[14:], data[18:22])
+	copy(unordered[18:], data[14:18])
+
+	cases := []struct {
+		info string
+		data []byte
+		base []float32
+	}{
+		{"should reject uncompressed data", []byte{0, 0, 128, 63}, nil},
+		{"should reject truncated data", data[:len(data)-1], make([]float32, 4)},
+		{"should reject base of other size", data, make([]float32, 3)},
+		{"should reject unordered indices", unordered, make([]float32, 4)},
+		{"should reject unknown flags", append([]byte("FLCZ\x01\x80"), data[6:]...), make([]float32, 4)},
+	}
+
+	for _, tc := range cases {
+		if _, err := Decompress(tc.data, tc.base); err == nil {
+			t.Errorf("%s: expected error", tc.info)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	negotiated := Options{Delta: true, TopK: 0.25}
+	base := make([]float32, 10)
+	weights := []float32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	cases := []struct {
+		info    string
+		options Options
+		valid   bool
+	}{
+		{"should accept negotiated fraction rounded up", Options{Delta: true, TopK: 0.25}, true},
+		{"should accept smaller fraction", Options{Delta: true, TopK: 0.1}, true},
+		{"should reject larger fraction", Options{Delta: true, TopK: 0.5}, false},
+		{"should reject dense delta", Options{Delta: true}, false},
+		{"should reject compression that was not negotiated", Options{Delta: true, TopK: 0.1, Quantize: true}, false},
+	}
+
+	for _, tc := range cases {
+		compressor, _ := NewCompressor(tc.options)
+		data, _ := compressor.Compress(weights, base)
+		if err := negotiated.Check(data); tc.valid != (err == nil) {
+			t.Errorf("%s: expected valid %t, got %v", tc.info, tc.valid, err)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	offered := Options{Delta: true, TopK: 0.1, Quantize: true}
+	cases := []struct {
+		info      string
+		supported Options
+		expected  Options
+	}{
+		{"should disable unsupported compression", Options{}, Options{}},
+		{"should use larger top-k fraction", Options{Delta: true, TopK: 0.2}, Options{Delta: true, TopK: 0.2}},
+		{"should not sparsify without delta", Options{TopK: 0.2, Quantize: true}, Options{Quantize: true}},
+	}
+
+	for _, tc := range cases {
+		if actual := offered.Negotiate(tc.supported); actual != tc.expected {
+			t.Errorf("%s: expected %+v, got %+v", tc.info, tc.expected, actual)
+		}
+	}
+}
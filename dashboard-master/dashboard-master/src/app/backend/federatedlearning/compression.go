@@ -0,0 +1,51 @@
+package federatedlearning
+
+import (
+	"fmt"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/compress"
+)
+
+// decompressUpdate returns a copy of an update whose compressed weights were replaced by the weights they
+// encode, in the layout of the model. Updates that are not compressed are returned as they are. Must be
+// called with the lock held.
+func (c *Coordinator) decompressUpdate(client *Client, model *GlobalModel, update *ModelUpdate) (*ModelUpdate, error) {
+	if !compress.IsCompressed(update.WeightUpdate) {
+		return update, nil
+	}
+
+	used, err := compress.Inspect(update.WeightUpdate)
+	if err != nil {
+		return nil, fmt.Errorf("%w from client %s: %v", ErrInvalidUpdate, update.ClientID, err)
+	}
+
+	negotiated := c.configs[model.ID].Compression.Negotiate(client.Compression)
+	if err := negotiated.Check(update.WeightUpdate); err != nil {
+		return nil, fmt.Errorf("%w from client %s for model %s: %v", ErrInvalidUpdate, update.ClientID, model.ID,
+			err)
+	}
+
+	var base []float32
+	if used.Delta {
+		version, err := c.getVersion(model.ID, update.BaseVersion)
+		if err != nil {
+			return nil, err
+		}
+		if base, err = decodeWeights(version.Weights); err != nil {
+			return nil, fmt.Errorf("failed to decode weights of model %s version %d: %v", model.ID,
+				update.BaseVersion, err)
+		}
+	}
+
+	weights, err := compress.Decompress(update.WeightUpdate, base)
+	if err != nil {
+		return nil, fmt.Errorf("%w from client %s: %v", ErrInvalidUpdate, update.ClientID, err)
+	}
+
+	result := *update
+	if result.WeightUpdate, err = encodeLike(model.Schema, encodeWeights(weights)); err != nil {
+		return nil, fmt.Errorf("%w from client %s: %v", ErrInvalidUpdate, update.ClientID, err)
+	}
+
+	return &result, nil
+}
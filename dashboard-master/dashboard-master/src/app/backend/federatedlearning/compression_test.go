@@ -0,0 +1,79 @@
+package federatedlearning
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/compress"
+)
+
+func TestCompressedUpdates(t *testing.T) {
+	c := NewCoordinator()
+	config := ModelConfig{
+		Round:       RoundConfig{MinParticipants: 2},
+		Compression: compress.Options{Delta: true, TopK: 0.25},
+	}
+	if _, err := c.CreateModel(&ModelSpec{ID: "test", Weights: encodeWeights([]float32{1, 1, 1, 1}), Config: config}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+
+	supported := map[string]compress.Options{"a": {Delta: true, TopK: 0.5, Quantize: true}, "b": {}}
+	for id, options := range supported {
+		client, err := c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test", Compression: options})
+		if err != nil {
+			t.Fatalf("RegisterClient(%s): unexpected error: %v", id, err)
+		}
+		if expected := config.Compression.Negotiate(options); client.Compression != expected {
+			t.Errorf("expected client %s to negotiate %+v, got %+v", id, expected, client.Compression)
+		}
+	}
+
+	base := []float32{1, 1, 1, 1}
+	compressed := func(options compress.Options, weights []float32) []byte {
+		compressor, _ := compress.NewCompressor(options)
+		data, err := compressor.Compress(weights, base)
+		if err != nil {
+			t.Fatalf("Compress(): unexpected error: %v", err)
+		}
+		return data
+	}
+
+	cases := []struct {
+		info    string
+		client  string
+		weights []byte
+		err     error
+	}{
+		{"should reject compression that was not negotiated", "a",
+			compressed(compress.Options{Quantize: true}, []float32{1, 1, 1, 1}), ErrInvalidUpdate},
+		{"should reject compression the client does not support", "b",
+			compressed(compress.Options{Delta: true}, []float32{1, 1, 1, 1}), ErrInvalidUpdate},
+		{"should reject more values than the negotiated top-k fraction", "a",
+			compressed(compress.Options{Delta: true, TopK: 0.75}, []float32{5, 1, 2, 3}), ErrInvalidUpdate},
+		{"should reject dense delta of client that negotiated top-k", "a",
+			compressed(compress.Options{Delta: true}, []float32{5, 1, 2, 3}), ErrInvalidUpdate},
+		{"should accept negotiated compression", "a",
+			compressed(compress.Options{Delta: true, TopK: 0.5}, []float32{5, 1, 1, 3}), nil},
+		{"should accept uncompressed update", "b", encodeWeights([]float32{1, 1, 3, 1}), nil},
+	}
+
+	for _, tc := range cases {
+		err := c.SubmitModelUpdate(&ModelUpdate{ClientID: tc.client, ModelID: "test", BaseVersion: 1,
+			WeightUpdate: tc.weights, NumSamples: 1})
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected error %v, got %v", tc.info, tc.err, err)
+		}
+
+		// The compressed update sends two of four float32 values with their indices.
+		if round, _ := c.GetRound("test"); round.Number == 1 && len(round.Participants) == 1 &&
+			(round.ReceivedBytes != 30 || round.UpdateBytes != 16 || round.CompressionRatio != 16.0/30) {
+			t.Errorf("%s: expected 30 bytes received and 16 bytes decompressed, got %+v", tc.info, round)
+		}
+	}
+
+	model, _ := c.GetModel("test")
+	if weights, _ := decodeWeights(model.Weights); !reflect.DeepEqual(weights, []float32{3, 1, 2, 2}) {
+		t.Errorf("expected aggregated weights [3 1 2 2], got %v", weights)
+	}
+}
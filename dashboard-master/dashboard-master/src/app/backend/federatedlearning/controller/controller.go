@@ -0,0 +1,429 @@
+// Package controller keeps the models of a federated learning coordinator in sync with FederatedModel
+// objects, so that models can be declared in the cluster instead of being created through the API.
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	fl "github.com/kubernetes/dashboard/src/app/backend/federatedlearning"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/apis/v1alpha1"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/clientset/versioned"
+	informers "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/informers/externalversions/apis/v1alpha1"
+	listers "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/listers/apis/v1alpha1"
+)
+
+// Reasons of the ready condition of FederatedModel objects.
+const (
+	// ReasonSynced tells that the model is in sync with the object.
+	ReasonSynced = "Synced"
+	// ReasonConflict tells that another object declares the same model.
+	ReasonConflict = "ModelIDConflict"
+	// ReasonInvalid tells that the model could not be created from the spec of the object.
+	ReasonInvalid = "InvalidModel"
+	// ReasonSyncFailed tells that syncing failed and is retried.
+	ReasonSyncFailed = "SyncFailed"
+)
+
+const (
+	// requestTimeout is the timeout of requests to the API server.
+	requestTimeout = 30 * time.Second
+	// maxSyncRetries is the number of times syncing an object is retried before it is given up until the
+	// object or its model change.
+	maxSyncRetries = 10
+)
+
+// Controller reconciles FederatedModel objects with the coordinator. Models declared by an object are
+// created from its initial weights and their round configuration follows its update limits. The status of
+// an object reports the current version and enrolled clients of its model. Deleting an object leaves the
+// model and its versions in the coordinator.
+type Controller struct {
+	coordinator *fl.Coordinator
+	client      versioned.Interface
+	kubeClient  kubernetes.Interface
+	lister      listers.FederatedModelLister
+	synced      cache.InformerSynced
+	queue       workqueue.RateLimitingInterface
+
+	mu sync.Mutex
+	// owners maps model IDs to the keys of the objects declaring them. The first object declaring a model
+	// owns it.
+	owners map[string]string
+	// models maps the keys of objects to the IDs of the models they own.
+	models map[string]string
+}
+
+// NewController creates a controller reconciling the objects of the informer with the coordinator.
+// Weights are read from ConfigMaps and pods are checked against selectors with kubeClient.
+func NewController(coordinator *fl.Coordinator, client versioned.Interface, kubeClient kubernetes.Interface,
+	informer informers.FederatedModelInformer) *Controller {
+	c := &Controller{
+		coordinator: coordinator,
+		client:      client,
+		kubeClient:  kubeClient,
+		lister:      informer.Lister(),
+		synced:      informer.Informer().HasSynced,
+		queue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "FederatedModels"),
+		owners:      make(map[string]string),
+		models:      make(map[string]string),
+	}
+
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueue,
+		UpdateFunc: func(_, object interface{}) { c.enqueue(object) },
+		DeleteFunc: c.enqueue,
+	})
+	return c
+}
+
+// Run reconciles objects until stopCh is closed. Once the informer synced, the controller becomes the
+// enrollment policy of the coordinator.
+func (c *Controller) Run(stopCh <-chan struct{}) {
+	defer c.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, c.synced) {
+		log.Print("Federated model informer did not sync")
+		return
+	}
+
+	c.coordinator.SetEnrollmentPolicy(c)
+	go c.watchEvents(stopCh)
+	go func() {
+		for c.processNextItem() {
+		}
+	}()
+
+	log.Print("Started federated model controller")
+	<-stopCh
+}
+
+// watchEvents refreshes the status of objects whose models changed.
+func (c *Controller) watchEvents(stopCh <-chan struct{}) {
+	events, cancel := c.coordinator.Subscribe("")
+	defer cancel()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			c.mu.Lock()
+			key, exists := c.owners[event.ModelID]
+			c.mu.Unlock()
+			if exists {
+				c.queue.Add(key)
+			}
+		}
+	}
+}
+
+func (c *Controller) enqueue(object interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(object)
+	if err != nil {
+		log.Printf("Error getting key of federated model: %v", err)
+		return
+	}
+
+	c.queue.Add(key)
+}
+
+func (c *Controller) processNextItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	err := c.sync(key.(string))
+	switch {
+	case err == nil:
+		c.queue.Forget(key)
+	case c.queue.NumRequeues(key) < maxSyncRetries:
+		log.Printf("Error syncing federated model %s, retrying: %v", key, err)
+		c.queue.AddRateLimited(key)
+	default:
+		log.Printf("Error syncing federated model %s, giving up: %v", key, err)
+		c.queue.Forget(key)
+	}
+
+	return true
+}
+
+// sync reconciles the object with the given key with the coordinator.
+func (c *Controller) sync(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	object, err := c.lister.FederatedModels(namespace).Get(name)
+	if k8sErrors.IsNotFound(err) {
+		c.release(key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	modelID := ModelID(object)
+	if owner := c.claim(modelID, key); owner != key {
+		return c.updateStatus(object, nil, metaV1.ConditionFalse, ReasonConflict,
+			fmt.Sprintf("model %s is declared by %s", modelID, owner))
+	}
+
+	model, err := c.coordinator.GetModel(modelID)
+	if errors.Is(err, fl.ErrModelNotFound) {
+		model, err = c.createModel(object, modelID)
+	} else if err == nil {
+		err = c.configureModel(modelID, object.Spec.Limits)
+	}
+
+	switch {
+	case errors.Is(err, fl.ErrInvalidModel):
+		// The spec has to be fixed, retrying does not help.
+		return c.updateStatus(object, nil, metaV1.ConditionFalse, ReasonInvalid, err.Error())
+	case err != nil:
+		if statusErr := c.updateStatus(object, nil, metaV1.ConditionFalse, ReasonSyncFailed,
+			err.Error()); statusErr != nil {
+			log.Printf("Error updating status of federated model %s: %v", key, statusErr)
+		}
+		return err
+	}
+
+	return c.updateStatus(object, model, metaV1.ConditionTrue, ReasonSynced, "")
+}
+
+// claim makes the object with the given key the owner of the model unless another object owns it, and
+// returns the owner.
+func (c *Controller) claim(modelID, key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if owner, exists := c.owners[modelID]; exists && owner != key {
+		return owner
+	}
+
+	// The model ID of the object changed, the object gives up its previous model.
+	if previous, exists := c.models[key]; exists && previous != modelID {
+		delete(c.owners, previous)
+		c.enqueueDeclaring(previous)
+	}
+
+	c.owners[modelID] = key
+	c.models[key] = modelID
+	return key
+}
+
+// release gives up the model of a deleted object.
+func (c *Controller) release(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if modelID, exists := c.models[key]; exists {
+		delete(c.owners, modelID)
+		delete(c.models, key)
+		c.enqueueDeclaring(modelID)
+	}
+}
+
+// enqueueDeclaring enqueues the objects declaring a model, so that one of them takes over an abandoned
+// model.
+func (c *Controller) enqueueDeclaring(modelID string) {
+	objects, err := c.lister.List(labels.Everything())
+	if err != nil {
+		log.Printf("Error listing federated models: %v", err)
+		return
+	}
+
+	for _, object := range objects {
+		if ModelID(object) == modelID {
+			c.enqueue(object)
+		}
+	}
+}
+
+// createModel creates a model from the initial weights of the object.
+func (c *Controller) createModel(object *v1alpha1.FederatedModel, modelID string) (*fl.GlobalModel, error) {
+	weights, err := c.initialWeights(object)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &fl.ModelSpec{
+		ID:          modelID,
+		Description: object.Spec.Description,
+		Weights:     weights,
+		Config:      fl.ModelConfig{Round: roundConfig(object.Spec.Limits)},
+	}
+	model, err := c.coordinator.CreateModel(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Created federated learning model %s declared by %s/%s", modelID, object.Namespace, object.Name)
+	return model, nil
+}
+
+// initialWeights reads the initial weights referenced by the object.
+func (c *Controller) initialWeights(object *v1alpha1.FederatedModel) ([]byte, error) {
+	ref := object.Spec.InitialWeights.ConfigMapKeyRef
+	if ref == nil {
+		return nil, fmt.Errorf("%w: initialWeights has to reference a ConfigMap key", fl.ErrInvalidModel)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	configMap, err := c.kubeClient.CoreV1().ConfigMaps(object.Namespace).Get(ctx, ref.Name, metaV1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ConfigMap %s with initial weights: %v", ref.Name, err)
+	}
+
+	if weights, exists := configMap.BinaryData[ref.Key]; exists {
+		return weights, nil
+	}
+	if weights, exists := configMap.Data[ref.Key]; exists {
+		return []byte(weights), nil
+	}
+
+	return nil, fmt.Errorf("ConfigMap %s has no key %s", ref.Name, ref.Key)
+}
+
+// configureModel applies the update limits of an object to an existing model.
+func (c *Controller) configureModel(modelID string, limits v1alpha1.UpdateLimits) error {
+	config := c.coordinator.GetModelConfig(modelID)
+	round := roundConfig(limits)
+	if config.Round == round {
+		return nil
+	}
+
+	config.Round = round
+	return c.coordinator.SetModelConfig(modelID, config)
+}
+
+// updateStatus updates the status of an object if it changed. model is nil if the model of the object
+// could not be synced.
+func (c *Controller) updateStatus(object *v1alpha1.FederatedModel, model *fl.GlobalModel,
+	ready metaV1.ConditionStatus, reason, message string) error {
+	status := object.Status.DeepCopy()
+	status.ObservedGeneration = object.Generation
+	if model != nil {
+		status.ModelID = model.ID
+		status.CurrentVersion = model.Version
+		status.Digest = model.Digest
+		if round, err := c.coordinator.GetRound(model.ID); err == nil {
+			status.Round = round.Number
+		}
+
+		status.Clients = nil
+		for _, client := range c.coordinator.ListClients() {
+			if client.ModelID == model.ID {
+				status.Clients = append(status.Clients, v1alpha1.ParticipantStatus{ID: client.ID,
+					Status: client.Status})
+			}
+		}
+	}
+
+	meta.SetStatusCondition(&status.Conditions, metaV1.Condition{
+		Type:               v1alpha1.ConditionReady,
+		Status:             ready,
+		ObservedGeneration: object.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	if equality.Semantic.DeepEqual(&object.Status, status) {
+		return nil
+	}
+
+	updated := object.DeepCopy()
+	updated.Status = *status
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	_, err := c.client.FederatedLearningV1alpha1().FederatedModels(object.Namespace).UpdateStatus(ctx, updated,
+		metaV1.UpdateOptions{})
+	return err
+}
+
+// CheckEnrollment implements EnrollmentPolicy interface. See EnrollmentPolicy for more information. Clients
+// may enroll in models declared with a selector only if they run in a pod matching it, in the namespace of
+// the declaring object.
+func (c *Controller) CheckEnrollment(identity *fl.Identity, modelID string) error {
+	c.mu.Lock()
+	key, exists := c.owners[modelID]
+	c.mu.Unlock()
+	if !exists {
+		return nil
+	}
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	object, err := c.lister.FederatedModels(namespace).Get(name)
+	if k8sErrors.IsNotFound(err) || (err == nil && object.Spec.Selector == nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	selector, err := metaV1.LabelSelectorAsSelector(object.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("%w: invalid selector of model %s: %v", fl.ErrClientNotEnrolled, modelID, err)
+	}
+
+	if identity.Namespace != namespace {
+		return fmt.Errorf("%w: client %s does not run in namespace %s of model %s", fl.ErrClientNotEnrolled,
+			identity.ClientID, namespace, modelID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	pod, err := c.kubeClient.CoreV1().Pods(namespace).Get(ctx, identity.PodName, metaV1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return fmt.Errorf("%w: pod of client %s not found", fl.ErrClientNotEnrolled, identity.ClientID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get pod of client %s: %v", identity.ClientID, err)
+	}
+
+	if !selector.Matches(labels.Set(pod.Labels)) {
+		return fmt.Errorf("%w: pod of client %s does not match the selector of model %s", fl.ErrClientNotEnrolled,
+			identity.ClientID, modelID)
+	}
+
+	return nil
+}
+
+// ModelID returns the ID of the model declared by an object.
+func ModelID(object *v1alpha1.FederatedModel) string {
+	if object.Spec.ModelID != "" {
+		return object.Spec.ModelID
+	}
+
+	return object.Name
+}
+
+func roundConfig(limits v1alpha1.UpdateLimits) fl.RoundConfig {
+	return fl.RoundConfig{
+		MinParticipants: limits.MinParticipants,
+		MaxParticipants: limits.MaxParticipants,
+		DurationSeconds: limits.RoundDurationSeconds,
+	}
+}
@@ -0,0 +1,240 @@
+package controller
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	fl "github.com/kubernetes/dashboard/src/app/backend/federatedlearning"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/apis/v1alpha1"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/clientset/versioned/fake"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/informers/externalversions"
+)
+
+func encodeWeights(weights []float32) []byte {
+	data := make([]byte, len(weights)*4)
+	for i, w := range weights {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(w))
+	}
+
+	return data
+}
+
+type testController struct {
+	*Controller
+	coordinator *fl.Coordinator
+	client      *fake.Clientset
+	indexer     cache.Indexer
+}
+
+// newTestController creates a controller whose informer holds the given objects. Objects are synced by
+// calling sync instead of running the controller.
+func newTestController(t *testing.T, objects []*v1alpha1.FederatedModel, kubeObjects ...runtime.Object) *testController {
+	var runtimeObjects []runtime.Object
+	for _, object := range objects {
+		runtimeObjects = append(runtimeObjects, object)
+	}
+
+	coordinator := fl.NewCoordinator()
+	client := fake.NewSimpleClientset(runtimeObjects...)
+	informer := externalversions.NewSharedInformerFactory(client, 0).FederatedLearning().V1alpha1().FederatedModels()
+	c := NewController(coordinator, client, kubeFake.NewSimpleClientset(kubeObjects...), informer)
+	indexer := informer.Informer().GetIndexer()
+	for _, object := range objects {
+		if err := indexer.Add(object); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &testController{Controller: c, coordinator: coordinator, client: client, indexer: indexer}
+}
+
+// status returns the status of an object as stored by the controller.
+func (c *testController) status(t *testing.T, namespace, name string) v1alpha1.FederatedModelStatus {
+	object, err := c.client.FederatedLearningV1alpha1().FederatedModels(namespace).Get(context.Background(), name,
+		metaV1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get(): unexpected error: %v", err)
+	}
+
+	return object.Status
+}
+
+func newFederatedModel(namespace, name, modelID string) *v1alpha1.FederatedModel {
+	return &v1alpha1.FederatedModel{
+		ObjectMeta: metaV1.ObjectMeta{Namespace: namespace, Name: name, Generation: 1},
+		Spec: v1alpha1.FederatedModelSpec{
+			ModelID:     modelID,
+			Description: "RRM power control",
+			InitialWeights: v1alpha1.WeightsSource{ConfigMapKeyRef: &coreV1.ConfigMapKeySelector{
+				LocalObjectReference: coreV1.LocalObjectReference{Name: "weights"},
+				Key:                  "model",
+			}},
+		},
+	}
+}
+
+func newWeightsConfigMap(namespace string) *coreV1.ConfigMap {
+	return &coreV1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{Namespace: namespace, Name: "weights"},
+		BinaryData: map[string][]byte{"model": encodeWeights([]float32{1, 2, 3})},
+	}
+}
+
+func expectReady(t *testing.T, status v1alpha1.FederatedModelStatus, ready metaV1.ConditionStatus, reason string) {
+	condition := meta.FindStatusCondition(status.Conditions, v1alpha1.ConditionReady)
+	if condition == nil || condition.Status != ready || condition.Reason != reason {
+		t.Fatalf("expected ready condition %s with reason %s, got %v", ready, reason, condition)
+	}
+}
+
+func TestSync(t *testing.T) {
+	object := newFederatedModel("ricxapp", "rrm-power-control", "")
+	object.Spec.Limits = v1alpha1.UpdateLimits{MinParticipants: 2}
+	c := newTestController(t, []*v1alpha1.FederatedModel{object}, newWeightsConfigMap("ricxapp"))
+
+	if err := c.sync("ricxapp/rrm-power-control"); err != nil {
+		t.Fatalf("sync(): unexpected error: %v", err)
+	}
+
+	model, err := c.coordinator.GetModel("rrm-power-control")
+	if err != nil {
+		t.Fatalf("expected model named after the object, got %v", err)
+	}
+	if model.Version != 1 || model.Description != "RRM power control" {
+		t.Fatalf("expected first version with the description of the object, got %+v", model)
+	}
+	if config := c.coordinator.GetModelConfig(model.ID); config.Round.MinParticipants != 2 {
+		t.Fatalf("expected round config from the update limits, got %+v", config.Round)
+	}
+
+	identity := &fl.Identity{ClientID: "ricxapp:rrm-0", Namespace: "ricxapp", PodName: "rrm-0", PodUID: "1"}
+	if _, err := c.coordinator.RegisterClient(identity, &fl.ClientRegistration{ModelID: model.ID}); err != nil {
+		t.Fatalf("RegisterClient(): unexpected error: %v", err)
+	}
+	if err := c.sync("ricxapp/rrm-power-control"); err != nil {
+		t.Fatalf("sync(): unexpected error: %v", err)
+	}
+
+	status := c.status(t, "ricxapp", "rrm-power-control")
+	expectReady(t, status, metaV1.ConditionTrue, ReasonSynced)
+	if status.ModelID != model.ID || status.CurrentVersion != 1 || status.Digest != model.Digest ||
+		status.Round != 1 || status.ObservedGeneration != 1 {
+		t.Fatalf("expected status of version 1 in round 1, got %+v", status)
+	}
+	if len(status.Clients) != 1 || status.Clients[0] != (v1alpha1.ParticipantStatus{ID: identity.ClientID,
+		Status: fl.ClientAvailable}) {
+		t.Fatalf("expected enrolled client in status, got %v", status.Clients)
+	}
+
+	updated := object.DeepCopy()
+	updated.Generation = 2
+	updated.Spec.Limits = v1alpha1.UpdateLimits{MinParticipants: 3, MaxParticipants: 5, RoundDurationSeconds: 60}
+	c.indexer.Update(updated)
+	if err := c.sync("ricxapp/rrm-power-control"); err != nil {
+		t.Fatalf("sync(): unexpected error: %v", err)
+	}
+
+	expected := fl.RoundConfig{MinParticipants: 3, MaxParticipants: 5, DurationSeconds: 60}
+	if config := c.coordinator.GetModelConfig(model.ID); config.Round != expected {
+		t.Fatalf("expected round config %+v, got %+v", expected, config.Round)
+	}
+	if status := c.status(t, "ricxapp", "rrm-power-control"); status.ObservedGeneration != 2 {
+		t.Fatalf("expected observed generation 2, got %d", status.ObservedGeneration)
+	}
+}
+
+func TestSyncConflict(t *testing.T) {
+	first := newFederatedModel("ricxapp", "rrm", "shared")
+	second := newFederatedModel("staging", "rrm", "shared")
+	c := newTestController(t, []*v1alpha1.FederatedModel{first, second}, newWeightsConfigMap("ricxapp"),
+		newWeightsConfigMap("staging"))
+
+	for _, key := range []string{"ricxapp/rrm", "staging/rrm"} {
+		if err := c.sync(key); err != nil {
+			t.Fatalf("sync(%s): unexpected error: %v", key, err)
+		}
+	}
+	expectReady(t, c.status(t, "ricxapp", "rrm"), metaV1.ConditionTrue, ReasonSynced)
+	expectReady(t, c.status(t, "staging", "rrm"), metaV1.ConditionFalse, ReasonConflict)
+
+	// Deleting the owner hands the model over to the other object.
+	c.indexer.Delete(first)
+	if err := c.sync("ricxapp/rrm"); err != nil {
+		t.Fatalf("sync(): unexpected error: %v", err)
+	}
+	if c.queue.Len() != 1 {
+		t.Fatalf("expected the other object to be queued, got %d queued objects", c.queue.Len())
+	}
+	if err := c.sync("staging/rrm"); err != nil {
+		t.Fatalf("sync(): unexpected error: %v", err)
+	}
+	expectReady(t, c.status(t, "staging", "rrm"), metaV1.ConditionTrue, ReasonSynced)
+}
+
+func TestSyncInvalid(t *testing.T) {
+	object := newFederatedModel("ricxapp", "rrm", "")
+	object.Spec.InitialWeights = v1alpha1.WeightsSource{}
+	missing := newFederatedModel("ricxapp", "missing", "")
+	c := newTestController(t, []*v1alpha1.FederatedModel{object, missing})
+
+	if err := c.sync("ricxapp/rrm"); err != nil {
+		t.Fatalf("sync(): expected invalid spec not to be retried, got %v", err)
+	}
+	expectReady(t, c.status(t, "ricxapp", "rrm"), metaV1.ConditionFalse, ReasonInvalid)
+
+	if err := c.sync("ricxapp/missing"); err == nil {
+		t.Fatal("sync(): expected missing ConfigMap to be retried")
+	}
+	expectReady(t, c.status(t, "ricxapp", "missing"), metaV1.ConditionFalse, ReasonSyncFailed)
+}
+
+func TestCheckEnrollment(t *testing.T) {
+	object := newFederatedModel("ricxapp", "rrm", "")
+	object.Spec.Selector = &metaV1.LabelSelector{MatchLabels: map[string]string{"app": "rrm"}}
+	pods := []runtime.Object{newWeightsConfigMap("ricxapp")}
+	for name, app := range map[string]string{"rrm-0": "rrm", "other-0": "other"} {
+		pods = append(pods, &coreV1.Pod{ObjectMeta: metaV1.ObjectMeta{Namespace: "ricxapp", Name: name,
+			Labels: map[string]string{"app": app}}})
+	}
+	c := newTestController(t, []*v1alpha1.FederatedModel{object}, pods...)
+	if err := c.sync("ricxapp/rrm"); err != nil {
+		t.Fatalf("sync(): unexpected error: %v", err)
+	}
+	c.coordinator.SetEnrollmentPolicy(c)
+	if _, err := c.coordinator.CreateModel(&fl.ModelSpec{ID: "undeclared",
+		Weights: encodeWeights([]float32{1})}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		namespace, pod, model string
+		enrolled              bool
+	}{
+		{"ricxapp", "rrm-0", "rrm", true},
+		{"ricxapp", "other-0", "rrm", false},
+		{"ricxapp", "rrm-1", "rrm", false},
+		{"default", "rrm-0", "rrm", false},
+		{"ricxapp", "other-0", "undeclared", true},
+	}
+	for _, test := range cases {
+		identity := &fl.Identity{ClientID: fl.PodClientID(test.namespace, test.pod), Namespace: test.namespace,
+			PodName: test.pod, PodUID: "1"}
+		_, err := c.coordinator.RegisterClient(identity, &fl.ClientRegistration{ModelID: test.model})
+		if test.enrolled && err != nil {
+			t.Errorf("expected %s to enroll in %s, got %v", identity.ClientID, test.model, err)
+		}
+		if !test.enrolled && !errors.Is(err, fl.ErrClientNotEnrolled) {
+			t.Errorf("expected %s not to enroll in %s, got %v", identity.ClientID, test.model, err)
+		}
+	}
+}
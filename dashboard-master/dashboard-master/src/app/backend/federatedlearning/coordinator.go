@@ -1,40 +1,217 @@
-
 package federatedlearning
 
 import (
 	"fmt"
+	"log"
 	"sync"
+	"time"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/secagg"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/tensor"
 )
 
+// Time interval between which round deadlines and client liveness are checked.
+const housekeepingPeriod = time.Second
+
 // Coordinator manages the federated learning process.
 type Coordinator struct {
 	mu           sync.Mutex
 	models       map[string]*GlobalModel
+	versions     map[string][]*GlobalModel
+	configs      map[string]ModelConfig
 	clients      map[string]*Client
 	modelUpdates map[string][]*ModelUpdate
+	rounds       map[string]*Round
+	privacy      map[string]*PrivacyBudget
+	sessions     map[string]*secureSession
+	optimizers   map[string]*OptimizerState
+	candidates   map[string]*Candidate
+	upstreams    map[string]*UpstreamState
+	// submissions counts the updates clients submitted based on each version, by model.
+	submissions map[string]map[submission]int
+
+	// events delivers events of the coordinator to subscribers.
+	events *eventBroker
+	// metrics are exported by the collector returned by Collector.
+	metrics *metrics
+
+	// clientTimeout is the time after which a client that was not seen is marked offline.
+	clientTimeout time.Duration
+	// defaultQuota is the quota of models that do not configure it.
+	defaultQuota QuotaConfig
+	// clientLimits limit the request rate of each client, addressLimits the request rate of each remote
+	// address before the caller is authenticated.
+	clientLimits  rateLimiters
+	addressLimits rateLimiters
+
+	// signer signs the provenance of new versions. Versions are published unsigned if it is nil.
+	signer Signer
+	// enrollment decides which clients may enroll in a model. Every client may enroll in every model if it
+	// is nil.
+	enrollment EnrollmentPolicy
+
+	// store persists the state of the coordinator. It is nil if the state is kept in memory only.
+	store Store
+
+	// now returns the current time. It can be replaced in tests.
+	now func() time.Time
 }
 
 // NewCoordinator creates a new Coordinator.
 func NewCoordinator() *Coordinator {
 	return &Coordinator{
-		models:       make(map[string]*GlobalModel),
-		clients:      make(map[string]*Client),
-		modelUpdates: make(map[string][]*ModelUpdate),
+		models:        make(map[string]*GlobalModel),
+		versions:      make(map[string][]*GlobalModel),
+		configs:       make(map[string]ModelConfig),
+		clients:       make(map[string]*Client),
+		modelUpdates:  make(map[string][]*ModelUpdate),
+		rounds:        make(map[string]*Round),
+		privacy:       make(map[string]*PrivacyBudget),
+		sessions:      make(map[string]*secureSession),
+		optimizers:    make(map[string]*OptimizerState),
+		candidates:    make(map[string]*Candidate),
+		upstreams:     make(map[string]*UpstreamState),
+		submissions:   make(map[string]map[submission]int),
+		events:        newEventBroker(),
+		metrics:       newMetrics(),
+		clientTimeout: DefaultClientTimeout,
+		now:           time.Now,
 	}
 }
 
-// RegisterClient registers a new client.
-func (c *Coordinator) RegisterClient(id string) (*Client, error) {
+// NewPersistentCoordinator creates a Coordinator that persists its state in the given store. State
+// persisted by a previous coordinator is restored, and rounds that were interrupted while aggregating are
+// aggregated again.
+func NewPersistentCoordinator(store Store) (*Coordinator, error) {
+	c := NewCoordinator()
+	c.store = store
+
+	clients, err := store.LoadClients()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load clients: %v", err)
+	}
+	for _, client := range clients {
+		c.clients[client.ID] = client
+	}
+
+	states, err := store.LoadModels()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load models: %v", err)
+	}
+
+	jobs := make([]*aggregationJob, 0)
+	for _, state := range states {
+		c.configs[state.ID] = state.Config
+		if state.Privacy != nil {
+			c.privacy[state.ID] = state.Privacy
+		}
+		if state.Optimizer != nil {
+			c.optimizers[state.ID] = state.Optimizer
+		}
+		if state.Candidate != nil {
+			c.candidates[state.ID] = state.Candidate
+		}
+		if state.Upstream != nil {
+			c.upstreams[state.ID] = state.Upstream
+		}
+		if state.Model == nil {
+			continue
+		}
+
+		c.models[state.ID] = state.Model
+		c.versions[state.ID] = state.Versions
+		if len(state.Versions) == 0 {
+			c.addVersion(state.Model)
+		}
+
+		if state.Round == nil {
+			continue
+		}
+
+		// Updates of a finished round may be left behind if the coordinator stopped before it removed them.
+		for _, update := range state.Updates {
+			// Updates of asynchronous models may be based on earlier versions.
+			sameBase := update.BaseVersion == state.Round.BaseVersion || state.Config.Async.Enabled
+			if sameBase && state.Round.hasParticipant(update.ClientID) {
+				c.modelUpdates[state.ID] = append(c.modelUpdates[state.ID], update)
+			}
+		}
+
+		c.rounds[state.ID] = state.Round
+		if state.Round.State == RoundAggregating {
+			jobs = append(jobs, c.startAggregation(state.Round))
+		}
+	}
+
+	log.Printf("Restored %d models and %d clients of federated learning coordinator", len(c.models),
+		len(c.clients))
+	for _, job := range jobs {
+		c.runAggregation(job)
+	}
+
+	return c, nil
+}
+
+// Run checks round deadlines and marks clients that stopped sending heartbeats offline until stopCh is
+// closed. It blocks, so it should be started in a separate goroutine.
+func (c *Coordinator) Run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(housekeepingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			c.checkRounds()
+			c.checkCandidates()
+			c.checkClients()
+			c.clientLimits.prune()
+			c.addressLimits.prune()
+		}
+	}
+}
+
+// RegisterClient registers an authenticated client and enrolls it in a model. Registering again enrolls the
+// client in the given model. If the pod of the client was recreated, the client is registered anew.
+func (c *Coordinator) RegisterClient(identity *Identity, registration *ClientRegistration) (*Client, error) {
+	c.mu.Lock()
+	enrollment := c.enrollment
+	c.mu.Unlock()
+
+	// The policy may look up the client elsewhere, so it is not consulted while holding the lock.
+	if enrollment != nil {
+		if err := enrollment.CheckEnrollment(identity, registration.ModelID); err != nil {
+			return nil, err
+		}
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if _, exists := c.clients[id]; exists {
-		return nil, fmt.Errorf("client already registered: %s", id)
+	if _, exists := c.models[registration.ModelID]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, registration.ModelID)
+	}
+
+	now := c.now()
+	client := &Client{ID: identity.ClientID, Namespace: identity.Namespace, PodName: identity.PodName,
+		PodUID: identity.PodUID, ModelID: registration.ModelID, Status: ClientAvailable, RegisteredAt: now,
+		LastSeen: now, NumSamples: registration.NumSamples,
+		Compression: c.configs[registration.ModelID].Compression.Negotiate(registration.Compression)}
+	if existing, exists := c.clients[identity.ClientID]; exists && existing.PodUID == identity.PodUID {
+		client.RegisteredAt = existing.RegisteredAt
+		client.LastSelected = existing.LastSelected
 	}
 
-	client := &Client{ID: id, Status: "available"}
-	c.clients[id] = client
-	return client, nil
+	if c.store != nil {
+		if err := c.store.SaveClient(client); err != nil {
+			return nil, fmt.Errorf("failed to persist client %s: %v", client.ID, err)
+		}
+	}
+
+	c.clients[client.ID] = client
+	c.emit(Event{Type: EventClientRegistered, ModelID: client.ModelID, ClientID: client.ID})
+	return client.copy(), nil
 }
 
 // GetModel returns the latest global model.
@@ -44,21 +221,538 @@ func (c *Coordinator) GetModel(id string) (*GlobalModel, error) {
 
 	model, exists := c.models[id]
 	if !exists {
-		return nil, fmt.Errorf("model not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, id)
+	}
+
+	return c.published(model), nil
+}
+
+// SetModelConfig sets the training configuration of a model. It applies to rounds opened afterwards.
+func (c *Coordinator) SetModelConfig(id string, config ModelConfig) error {
+	if err := validateConfig(config); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.configs[id] = config
+	return c.persistModel(id)
+}
+
+// GetModelConfig returns the training configuration of a model.
+func (c *Coordinator) GetModelConfig(id string) ModelConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.configs[id]
+}
+
+// OpenRound opens a new training round of the current model version. If config is nil the round
+// configuration of the model is used. Only one round of a model can be active at a time.
+func (c *Coordinator) OpenRound(modelID string, config *RoundConfig) (*Round, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.models[modelID]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, modelID)
+	}
+
+	if round, exists := c.rounds[modelID]; exists && round.Active() {
+		return nil, fmt.Errorf("%w: round %d of model %s is %s", ErrRoundActive, round.Number, modelID, round.State)
+	}
+
+	if candidate, exists := c.candidates[modelID]; exists {
+		return nil, fmt.Errorf("%w: version %d of model %s is a candidate", ErrRoundActive, candidate.Version, modelID)
+	}
+
+	if config == nil {
+		modelConfig := c.configs[modelID].Round
+		config = &modelConfig
+	}
+
+	round := c.openRound(modelID, *config)
+	if err := c.persistModel(modelID); err != nil {
+		return nil, err
+	}
+
+	return round.copy(), nil
+}
+
+// GetRound returns the current, or most recently finished, training round of a model.
+func (c *Coordinator) GetRound(modelID string) (*Round, error) {
+	c.checkRounds()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	round, exists := c.rounds[modelID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s has no rounds", ErrRoundNotOpen, modelID)
 	}
 
-	return model, nil
+	return round.copy(), nil
 }
 
-// SubmitModelUpdate submits a model update from a client.
+// SubmitModelUpdate submits a model update from a client to the open round of the model. Once the
+// round is ready, its updates are aggregated into the next model version.
 func (c *Coordinator) SubmitModelUpdate(update *ModelUpdate) error {
+	c.mu.Lock()
+
+	job, err := c.submitModelUpdate(update)
+	if err != nil {
+		c.observeRejection(update, err)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		c.emit(Event{Type: EventUpdateRejected, ModelID: update.ModelID, ClientID: update.ClientID,
+			Message: err.Error()})
+		return err
+	}
+
+	if job != nil {
+		c.runAggregation(job)
+	}
+
+	return nil
+}
+
+// submitModelUpdate adds an update to the open round of its model. It returns an aggregation job if
+// the round became ready. Must be called with the lock held.
+func (c *Coordinator) submitModelUpdate(update *ModelUpdate) (*aggregationJob, error) {
+	client, exists := c.clients[update.ClientID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotRegistered, update.ClientID)
+	}
+
+	if client.ModelID != update.ModelID {
+		return nil, fmt.Errorf("%w: client %s is enrolled in model %s, not %s", ErrClientNotEnrolled, client.ID,
+			client.ModelID, update.ModelID)
+	}
+
+	model, exists := c.models[update.ModelID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, update.ModelID)
+	}
+
+	round, exists := c.rounds[update.ModelID]
+	if !exists || !round.accepting() || round.expired(c.now()) {
+		return nil, fmt.Errorf("%w: model %s does not accept updates", ErrRoundNotOpen, update.ModelID)
+	}
+
+	if err := c.checkSelected(round, update.ClientID); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkQuota(update); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkPrivacyBudget(model.ID); err != nil {
+		return nil, err
+	}
+
+	if c.configs[model.ID].SecureAggregation.Enabled {
+		return nil, fmt.Errorf("%w: model %s only accepts updates through secure aggregation", ErrInvalidUpdate,
+			model.ID)
+	}
+
+	if c.configs[model.ID].Async.Enabled {
+		if err := c.checkStaleness(model, update); err != nil {
+			return nil, err
+		}
+	} else if update.BaseVersion != round.BaseVersion {
+		return nil, fmt.Errorf("%w: update from client %s is based on version %d, round %d of model %s trains "+
+			"version %d", ErrStaleUpdate, update.ClientID, update.BaseVersion, round.Number, model.ID,
+			round.BaseVersion)
+	}
+
+	if round.hasParticipant(update.ClientID) {
+		return nil, fmt.Errorf("%w: client %s in round %d of model %s", ErrDuplicateUpdate, update.ClientID,
+			round.Number, model.ID)
+	}
+
+	received := len(update.WeightUpdate)
+	update, err := c.decompressUpdate(client, model, update)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateUpdate(model, update); err != nil {
+		return nil, err
+	}
+
+	if c.store != nil {
+		if err := c.store.SaveUpdate(update); err != nil {
+			return nil, fmt.Errorf("failed to persist update from client %s: %v", update.ClientID, err)
+		}
+	}
+
+	// Updates are evicted once the update was persisted, so that a failed update does not evict any.
+	evicted := c.evictUpdates(model.ID, update.BaseVersion)
+	c.modelUpdates[model.ID] = append(c.modelUpdates[model.ID], update)
+	if evicted {
+		c.persistUpdates(model.ID)
+	}
+	if update.NumSamples > 0 {
+		client.NumSamples = update.NumSamples
+	}
+	c.touchClient(update.ClientID, ClientAvailable)
+	round.Participants = append(round.Participants, update.ClientID)
+	round.State = RoundCollecting
+	round.addTransfer(received, len(update.WeightUpdate))
+	c.metrics.updateSize.WithLabelValues(model.ID).Observe(float64(received))
+	c.emit(Event{Type: EventUpdateReceived, ModelID: model.ID, ClientID: update.ClientID, Round: round.Number})
+
+	var job *aggregationJob
+	if round.ready(c.now()) {
+		job = c.startAggregation(round)
+	}
+
+	if err := c.persistModel(model.ID); err != nil {
+		log.Print(err)
+	}
+
+	return job, nil
+}
+
+// aggregationJob holds everything needed to aggregate a round without holding the coordinator lock.
+type aggregationJob struct {
+	round   *Round
+	model   *GlobalModel
+	updates []*ModelUpdate
+	config  ModelConfig
+	// session is the secure aggregation session whose sum is aggregated instead of updates.
+	session *secagg.Session
+	// bases are the versions updates of asynchronous models are based on, by version number.
+	bases map[int]*GlobalModel
+	// optimizer is the state of the server optimizer. It is replaced by the state after the step of the job.
+	optimizer *OptimizerState
+}
+
+// startAggregation moves a round to the aggregating state and takes its updates. Must be called with
+// the lock held.
+func (c *Coordinator) startAggregation(round *Round) *aggregationJob {
+	round.State = RoundAggregating
+	job := &aggregationJob{
+		round:   round,
+		model:   c.models[round.ModelID],
+		updates: c.modelUpdates[round.ModelID],
+		config:  c.configs[round.ModelID],
+	}
+	c.modelUpdates[round.ModelID] = nil
+	// The optimizer state is copied because it is updated without holding the lock.
+	job.optimizer = c.optimizers[round.ModelID].copy()
+	if job.config.Async.Enabled {
+		job.bases = c.baseVersions(round.ModelID, job.updates)
+	}
+
+	return job
+}
+
+// runAggregation aggregates the updates of a round into the next model version, closes the round and
+// opens the next one. The round is failed if aggregation fails.
+func (c *Coordinator) runAggregation(job *aggregationJob) {
+	aggregation, err := c.aggregate(job)
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if _, exists := c.clients[update.ClientID]; !exists {
-		return fmt.Errorf("client not registered: %s", update.ClientID)
+	if err != nil {
+		c.failAggregation(job, err)
+		return
+	}
+
+	if job.config.Privacy.enabled() {
+		if err := c.checkPrivacyBudget(job.model.ID); err != nil {
+			c.failAggregation(job, err)
+			return
+		}
+	}
+
+	version := &GlobalModel{
+		ID:            job.model.ID,
+		Version:       c.latestVersion(job.model.ID) + 1,
+		ParentVersion: job.model.Version,
+		Weights:       aggregation.Weights,
+		Digest:        weightsDigest(aggregation.Weights),
+		Size:          len(aggregation.Weights),
+		CreatedAt:     c.now(),
+		Description:   job.model.Description,
+		Discarded:     aggregation.Discarded,
+		Contributors:  contributors(job, aggregation),
+		Schema:        job.model.Schema,
+		Training:      newMetricsSummary(job.updates),
+	}
+	if job.config.Optimizer.enabled() {
+		job.optimizer.Version = version.Version
+	}
+	if c.store != nil {
+		if err := c.store.SaveWeights(version); err != nil {
+			c.failAggregation(job, fmt.Errorf("failed to persist weights: %v", err))
+			return
+		}
+		if job.config.Optimizer.enabled() {
+			if err := c.store.SaveMoments(version.ID, job.optimizer); err != nil {
+				c.failAggregation(job, fmt.Errorf("failed to persist optimizer moments: %v", err))
+				return
+			}
+		}
+	}
+
+	if job.config.Privacy.enabled() {
+		c.privacyBudget(version.ID).spend(job.config.Privacy)
+	}
+
+	c.addVersion(version)
+	c.countUpstream(version.ID, job.updates)
+	job.round.close(RoundClosed, c.now(), "")
+	c.emit(Event{Type: EventModelVersion, ModelID: version.ID, Round: job.round.Number, Version: version.Version})
+
+	log.Printf("Aggregated %d updates of round %d of model %s into version %d", len(job.round.Participants),
+		job.round.Number, version.ID, version.Version)
+	if len(version.Discarded) > 0 {
+		log.Printf("Discarded outlying updates of clients %v in round %d of model %s", version.Discarded,
+			job.round.Number, version.ID)
+	}
+
+	// Training pauses while a candidate is judged. The step of the server optimizer is kept with the
+	// candidate until it is promoted.
+	if promotion := c.configs[version.ID].Promotion; promotion.Enabled {
+		c.startCandidate(c.findVersion(version.ID, version.Version), promotion)
+		if job.config.Optimizer.enabled() {
+			c.candidates[version.ID].Optimizer = job.optimizer
+		}
+	} else {
+		c.models[version.ID] = version
+		if job.config.Optimizer.enabled() {
+			c.setOptimizer(version.ID, job.optimizer)
+		}
+		c.openRound(version.ID, c.configs[version.ID].Round)
+	}
+	c.persistRoundEnd(version.ID)
+}
+
+// setOptimizer replaces the state of the server optimizer of a model, a nil state resets the optimizer.
+// Moments of the previous state are no longer needed and are removed from the store. Must be called with
+// the lock held.
+func (c *Coordinator) setOptimizer(modelID string, state *OptimizerState) {
+	previous := c.optimizers[modelID]
+	if state != nil {
+		c.optimizers[modelID] = state
+	} else {
+		delete(c.optimizers, modelID)
+	}
+
+	if c.store == nil || previous == nil || state != nil && state.Version == previous.Version {
+		return
+	}
+	if err := c.store.DeleteMoments(modelID, previous.Version); err != nil {
+		log.Printf("Failed to remove optimizer moments of model %s version %d: %v", modelID, previous.Version, err)
+	}
+}
+
+// failAggregation fails a round whose updates could not be aggregated and opens the next one. Must be called
+// with the lock held.
+func (c *Coordinator) failAggregation(job *aggregationJob, err error) {
+	c.failRound(job.round, err.Error())
+}
+
+// failRound fails a round and opens the next one. Must be called with the lock held.
+func (c *Coordinator) failRound(round *Round, reason string) {
+	log.Printf("Round %d of model %s failed: %s", round.Number, round.ModelID, reason)
+	round.close(RoundFailed, c.now(), reason)
+	c.openRound(round.ModelID, c.configs[round.ModelID].Round)
+	c.persistRoundEnd(round.ModelID)
+}
+
+func (c *Coordinator) aggregate(job *aggregationJob) (*Aggregation, error) {
+	aggregator, err := NewAggregator(job.config.Aggregation)
+	if err != nil {
+		return nil, err
+	}
+
+	var aggregation *Aggregation
+	if job.round.SecurePhase != "" {
+		aggregation, err = secureAggregate(job)
+	} else if job.config.Async.Enabled {
+		aggregation, err = asyncAggregate(job)
+	} else if job.config.Privacy.enabled() {
+		aggregation, err = privatize(job)
+	} else {
+		aggregation, err = aggregator.Aggregate(job.model, job.updates)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate model %s: %v", job.model.ID, err)
+	}
+
+	if job.config.Optimizer.enabled() {
+		if aggregation.Weights, err = optimize(job, aggregation.Weights); err != nil {
+			return nil, fmt.Errorf("failed to optimize model %s: %v", job.model.ID, err)
+		}
+	}
+
+	if aggregation.Weights, err = encodeLike(job.model.Schema, aggregation.Weights); err != nil {
+		return nil, fmt.Errorf("failed to encode model %s: %v", job.model.ID, err)
+	}
+
+	return aggregation, nil
+}
+
+// checkRounds aggregates or fails rounds whose deadline passed, and advances secure aggregation sessions
+// whose phase timed out.
+func (c *Coordinator) checkRounds() {
+	c.mu.Lock()
+	jobs := c.checkSessions()
+	now := c.now()
+	for modelID, round := range c.rounds {
+		if !round.accepting() || !round.expired(now) || c.configs[modelID].SecureAggregation.Enabled {
+			continue
+		}
+
+		// Partially filled buffers of asynchronous models are folded at the deadline.
+		if round.quorum() || c.configs[modelID].Async.Enabled && len(round.Participants) > 0 {
+			jobs = append(jobs, c.startAggregation(round))
+			continue
+		}
+
+		c.failRound(round, fmt.Sprintf("quorum not reached: %d of %d participants", len(round.Participants),
+			round.MinParticipants))
+	}
+	c.mu.Unlock()
+
+	for _, job := range jobs {
+		c.runAggregation(job)
+	}
+}
+
+// openRound opens the next round of the current model version. Rounds of asynchronous models collect a
+// buffer of updates. Must be called with the lock held.
+func (c *Coordinator) openRound(modelID string, config RoundConfig) *Round {
+	if async := c.configs[modelID].Async; async.Enabled {
+		config.MinParticipants = async.bufferSize()
+		config.MaxParticipants = async.bufferSize()
+	}
+
+	number := 1
+	if previous, exists := c.rounds[modelID]; exists {
+		number = previous.Number + 1
+	}
+
+	round := newRound(number, c.models[modelID], config, c.now())
+	c.rounds[modelID] = round
+	c.modelUpdates[modelID] = nil
+	c.pruneSubmissions(round)
+	delete(c.sessions, modelID)
+	c.selectClients(round)
+	return round
+}
+
+// persistModel persists the current state of a model. Must be called with the lock held.
+func (c *Coordinator) persistModel(modelID string) error {
+	if c.store == nil {
+		return nil
+	}
+
+	state := &ModelState{
+		ID:        modelID,
+		Model:     c.models[modelID],
+		Versions:  c.versions[modelID],
+		Config:    c.configs[modelID],
+		Round:     c.rounds[modelID],
+		Privacy:   c.privacy[modelID],
+		Optimizer: c.optimizers[modelID],
+		Candidate: c.candidates[modelID],
+		Upstream:  c.upstreams[modelID],
+	}
+	if err := c.store.SaveModel(state); err != nil {
+		return fmt.Errorf("failed to persist model %s: %v", modelID, err)
 	}
 
-	c.modelUpdates[update.ModelID] = append(c.modelUpdates[update.ModelID], update)
 	return nil
 }
+
+// persistRoundEnd persists the state of a model after its round finished and drops the persisted updates
+// of the finished round. Must be called with the lock held.
+func (c *Coordinator) persistRoundEnd(modelID string) {
+	if c.store == nil {
+		return
+	}
+
+	if err := c.persistModel(modelID); err != nil {
+		log.Print(err)
+	}
+
+	if err := c.store.DeleteUpdates(modelID); err != nil {
+		log.Printf("Failed to delete persisted updates of model %s: %v", modelID, err)
+	}
+}
+
+// validateUpdate checks that the weights of an update can be combined with the weights of the model. Updates
+// of models with a tensor layout have to be tensor containers of the same layout.
+func validateUpdate(model *GlobalModel, update *ModelUpdate) error {
+	if err := validateMetrics(update.Metrics); err != nil {
+		return fmt.Errorf("%w from client %s: %v", ErrInvalidUpdate, update.ClientID, err)
+	}
+
+	weights, err := decodeWeights(update.WeightUpdate)
+	if err != nil {
+		return fmt.Errorf("%w from client %s: %v", ErrInvalidUpdate, update.ClientID, err)
+	}
+	if !finite(weights) {
+		return fmt.Errorf("%w from client %s: weights must not be NaN or infinite", ErrInvalidUpdate,
+			update.ClientID)
+	}
+
+	if model.Schema != nil {
+		schema, _ := weightsSchema(update.WeightUpdate)
+		if schema == nil {
+			return fmt.Errorf("%w from client %s: model %s expects tensors %s, got a flat vector", ErrInvalidUpdate,
+				update.ClientID, model.ID, tensor.String(model.Schema))
+		}
+		if err := tensor.CheckSchema(model.Schema, schema); err != nil {
+			return fmt.Errorf("%w from client %s: layout does not match model %s: %v", ErrInvalidUpdate,
+				update.ClientID, model.ID, err)
+		}
+	}
+
+	if len(model.Weights) > 0 && len(weights) != numWeights(model) {
+		return fmt.Errorf("%w from client %s: got %d weights, model %s has %d", ErrInvalidUpdate,
+			update.ClientID, len(weights), model.ID, numWeights(model))
+	}
+
+	return nil
+}
+
+// validateConfig checks the training configuration of a model.
+func validateConfig(config ModelConfig) error {
+	if _, err := NewAggregator(config.Aggregation); err != nil {
+		return err
+	}
+	if err := config.Selection.validate(config); err != nil {
+		return err
+	}
+	if err := config.SecureAggregation.validate(config); err != nil {
+		return err
+	}
+	if err := config.Async.validate(config); err != nil {
+		return err
+	}
+	if err := config.Optimizer.validate(); err != nil {
+		return err
+	}
+	if err := config.Compression.Validate(); err != nil {
+		return err
+	}
+	if err := config.Promotion.validate(); err != nil {
+		return err
+	}
+	if err := config.Quota.validate(); err != nil {
+		return err
+	}
+	if config.ProximalMu < 0 {
+		return fmt.Errorf("proximal coefficient must not be negative")
+	}
+
+	return config.Privacy.validate(config.Aggregation)
+}
@@ -0,0 +1,79 @@
+package federatedlearning
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestCoordinator(t *testing.T, minParticipants int, clients ...string) *Coordinator {
+	return newTestCoordinatorWithConfig(t, RoundConfig{MinParticipants: minParticipants}, clients...)
+}
+
+func newTestCoordinatorWithConfig(t *testing.T, config RoundConfig, clients ...string) *Coordinator {
+	c := NewCoordinator()
+	spec := &ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}), Config: ModelConfig{Round: config}}
+	if _, err := c.CreateModel(spec); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+
+	for _, id := range clients {
+		if _, err := c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"}); err != nil {
+			t.Fatalf("RegisterClient(%s): unexpected error: %v", id, err)
+		}
+	}
+
+	return c
+}
+
+func TestSubmitModelUpdateAggregates(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a", "b")
+
+	submit := func(client string, weights []float32) {
+		err := c.SubmitModelUpdate(&ModelUpdate{ClientID: client, ModelID: "test", BaseVersion: 1,
+			WeightUpdate: encodeWeights(weights), NumSamples: 1})
+		if err != nil {
+			t.Fatalf("SubmitModelUpdate(%s): unexpected error: %v", client, err)
+		}
+	}
+
+	submit("a", []float32{2, 4})
+	model, _ := c.GetModel("test")
+	if model.Version != 1 {
+		t.Fatalf("expected version 1 before quorum, got %d", model.Version)
+	}
+
+	submit("b", []float32{4, 8})
+	model, _ = c.GetModel("test")
+	if model.Version != 2 {
+		t.Fatalf("expected version 2 after aggregation, got %d", model.Version)
+	}
+
+	weights, _ := decodeWeights(model.Weights)
+	if expected := []float32{3, 6}; !reflect.DeepEqual(weights, expected) {
+		t.Errorf("expected weights %v, got %v", expected, weights)
+	}
+
+	if pending := len(c.modelUpdates["test"]); pending != 0 {
+		t.Errorf("expected aggregated updates to be consumed, got %d pending", pending)
+	}
+}
+
+func TestSubmitModelUpdateInvalid(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a")
+
+	cases := []struct {
+		info   string
+		update *ModelUpdate
+	}{
+		{"unregistered client", &ModelUpdate{ClientID: "x", ModelID: "test", WeightUpdate: encodeWeights([]float32{1, 1})}},
+		{"unknown model", &ModelUpdate{ClientID: "a", ModelID: "x", WeightUpdate: encodeWeights([]float32{1, 1})}},
+		{"wrong weight count", &ModelUpdate{ClientID: "a", ModelID: "test", WeightUpdate: encodeWeights([]float32{1})}},
+		{"malformed weights", &ModelUpdate{ClientID: "a", ModelID: "test", WeightUpdate: []byte{1, 2, 3}}},
+	}
+
+	for _, tc := range cases {
+		if err := c.SubmitModelUpdate(tc.update); err == nil {
+			t.Errorf("%s: expected error", tc.info)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package federatedlearning
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Errors returned by the coordinator. They are wrapped with details about the failing request, use
+// errors.Is to check for them.
+var (
+	ErrModelNotFound          = errors.New("model not found")
+	ErrVersionNotFound        = errors.New("model version not found")
+	ErrModelExists            = errors.New("model already exists")
+	ErrInvalidModel           = errors.New("invalid model")
+	ErrClientNotRegistered    = errors.New("client not registered")
+	ErrClientNotEnrolled      = errors.New("client not enrolled in model")
+	ErrClientMismatch         = errors.New("client ID does not match caller")
+	ErrUnauthenticated        = errors.New("client not authenticated")
+	ErrInvalidUpdate          = errors.New("invalid update")
+	ErrStaleUpdate            = errors.New("stale update")
+	ErrDuplicateUpdate        = errors.New("update already submitted")
+	ErrRoundNotOpen           = errors.New("no open round")
+	ErrRoundActive            = errors.New("round already active")
+	ErrPrivacyBudgetExhausted = errors.New("privacy budget exhausted")
+	ErrUpdateTooLarge         = errors.New("update too large")
+	ErrQuotaExceeded          = errors.New("quota exceeded")
+	ErrRateLimited            = errors.New("request rate exceeded")
+)
+
+// httpStatus maps errors returned by the coordinator to HTTP status codes.
+func httpStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrModelNotFound), errors.Is(err, ErrVersionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrClientNotRegistered), errors.Is(err, ErrClientNotEnrolled), errors.Is(err, ErrClientMismatch):
+		return http.StatusForbidden
+	case errors.Is(err, ErrInvalidUpdate), errors.Is(err, ErrInvalidModel):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUpdateTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrQuotaExceeded), errors.Is(err, ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrNotSelected):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrModelExists), errors.Is(err, ErrStaleUpdate), errors.Is(err, ErrDuplicateUpdate),
+		errors.Is(err, ErrRoundNotOpen), errors.Is(err, ErrRoundActive), errors.Is(err, ErrPrivacyBudgetExhausted):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,209 @@
+package federatedlearning
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// Names of metrics with a conventional meaning. Clients may report any other metric as well.
+const (
+	// MetricLoss is the loss of the model on the data of the client.
+	MetricLoss = "loss"
+	// MetricAccuracy is the accuracy of the model on the data of the client.
+	MetricAccuracy = "accuracy"
+)
+
+const (
+	// maxMetrics is the number of metrics a client may report with a single update or evaluation.
+	maxMetrics = 32
+	// maxMetricNameLength is the length of the longest metric name.
+	maxMetricNameLength = 64
+)
+
+// Evaluation is sent by a client that scored a model version on its held-out data. Evaluations do not
+// contribute to training.
+type Evaluation struct {
+	ClientID   string `json:"clientId"`
+	ModelID    string `json:"modelId"`
+	Version    int    `json:"version"`
+	NumSamples int    `json:"numSamples"` // The number of held-out samples the version was scored on
+	// Metrics are named scores of the version, e.g. MetricLoss and MetricAccuracy.
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// MetricSummary aggregates a metric reported by several clients.
+type MetricSummary struct {
+	// Mean is the mean of the reported values, weighted by the number of samples of the clients. Clients
+	// that did not report their number of samples count as a single sample.
+	Mean    float64 `json:"mean"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Clients int     `json:"clients"`
+	// Weight is the sum of the weights of the reported values.
+	Weight float64 `json:"weight"`
+}
+
+// MetricsSummary aggregates the metrics reported for a model version.
+type MetricsSummary struct {
+	// Clients are the clients that reported metrics.
+	Clients    []string                  `json:"clients"`
+	NumSamples int                       `json:"numSamples"`
+	Metrics    map[string]*MetricSummary `json:"metrics"`
+}
+
+// newMetricsSummary summarizes the training metrics reported with updates.
+func newMetricsSummary(updates []*ModelUpdate) *MetricsSummary {
+	var summary *MetricsSummary
+	for _, update := range updates {
+		if len(update.Metrics) > 0 {
+			summary = summary.add(update.ClientID, update.NumSamples, update.Metrics)
+		}
+	}
+
+	return summary
+}
+
+// add returns a copy of the summary that includes metrics reported by a client. The summary itself is not
+// modified, so that it can be shared with callers of the coordinator. A nil summary is empty.
+func (s *MetricsSummary) add(clientID string, numSamples int, metrics map[string]float64) *MetricsSummary {
+	result := &MetricsSummary{Clients: make([]string, 0), Metrics: make(map[string]*MetricSummary)}
+	if s != nil {
+		result.Clients = append(result.Clients, s.Clients...)
+		result.NumSamples = s.NumSamples
+		for name, metric := range s.Metrics {
+			copied := *metric
+			result.Metrics[name] = &copied
+		}
+	}
+
+	weight := math.Max(float64(numSamples), 1)
+	result.Clients = append(result.Clients, clientID)
+	result.NumSamples += numSamples
+	for name, value := range metrics {
+		metric, exists := result.Metrics[name]
+		if !exists {
+			metric = &MetricSummary{Min: value, Max: value}
+			result.Metrics[name] = metric
+		}
+
+		metric.Mean += (value - metric.Mean) * weight / (metric.Weight + weight)
+		metric.Weight += weight
+		metric.Min = math.Min(metric.Min, value)
+		metric.Max = math.Max(metric.Max, value)
+		metric.Clients++
+	}
+
+	return result
+}
+
+// hasClient returns true if the client already reported metrics. A nil summary has no clients.
+func (s *MetricsSummary) hasClient(clientID string) bool {
+	if s == nil {
+		return false
+	}
+
+	for _, id := range s.Clients {
+		if id == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateMetrics checks metrics reported by a client.
+func validateMetrics(metrics map[string]float64) error {
+	if len(metrics) > maxMetrics {
+		return fmt.Errorf("at most %d metrics may be reported, got %d", maxMetrics, len(metrics))
+	}
+
+	for name, value := range metrics {
+		if name == "" || len(name) > maxMetricNameLength {
+			return fmt.Errorf("metric names must be between 1 and %d characters: %q", maxMetricNameLength, name)
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return fmt.Errorf("metric %s is not finite", name)
+		}
+	}
+
+	return nil
+}
+
+// SubmitEvaluation records the scores a client measured for a model version. Every client can evaluate a
+// version once. The evaluations of a version are summarized in its Evaluation.
+func (c *Coordinator) SubmitEvaluation(evaluation *Evaluation) error {
+	if err := validateMetrics(evaluation.Metrics); err != nil {
+		return fmt.Errorf("%w from client %s: %v", ErrInvalidUpdate, evaluation.ClientID, err)
+	}
+	if len(evaluation.Metrics) == 0 {
+		return fmt.Errorf("%w from client %s: evaluation without metrics", ErrInvalidUpdate, evaluation.ClientID)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	client, exists := c.clients[evaluation.ClientID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrClientNotRegistered, evaluation.ClientID)
+	}
+
+	if client.ModelID != evaluation.ModelID {
+		return fmt.Errorf("%w: client %s is enrolled in model %s, not %s", ErrClientNotEnrolled, client.ID,
+			client.ModelID, evaluation.ModelID)
+	}
+
+	version := c.findVersion(evaluation.ModelID, evaluation.Version)
+	if version == nil {
+		return fmt.Errorf("%w: %s version %d", ErrVersionNotFound, evaluation.ModelID, evaluation.Version)
+	}
+
+	if version.Evaluation.hasClient(client.ID) {
+		return fmt.Errorf("%w: client %s already evaluated version %d of model %s", ErrDuplicateUpdate, client.ID,
+			version.Version, version.ID)
+	}
+
+	// Persistent coordinators keep the current version apart from its metadata in the version history.
+	version.Evaluation = version.Evaluation.add(client.ID, evaluation.NumSamples, evaluation.Metrics)
+	if current := c.models[version.ID]; current.Version == version.Version {
+		current.Evaluation = version.Evaluation
+	}
+	c.touchClient(client.ID, client.Status)
+	if c.judgeCandidate(version.ID) {
+		return nil
+	}
+
+	return c.persistModel(version.ID)
+}
+
+// submitEvaluation accepts the scores a client measured for a model version.
+func (a *API) submitEvaluation(req *restful.Request, resp *restful.Response) {
+	evaluation := new(Evaluation)
+	if err := req.ReadEntity(evaluation); err != nil {
+		resp.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	identity, err := a.authorizeClient(req, evaluation.ClientID)
+	if err != nil {
+		writeClientError(resp, err)
+		return
+	}
+
+	evaluation.ClientID = identity.ClientID
+	evaluation.ModelID = req.PathParameter("modelId")
+	if evaluation.Version, err = strconv.Atoi(req.PathParameter("version")); err != nil {
+		resp.WriteError(http.StatusBadRequest, fmt.Errorf("invalid version: %s", req.PathParameter("version")))
+		return
+	}
+
+	if err := a.coordinator.SubmitEvaluation(evaluation); err != nil {
+		resp.WriteError(httpStatus(err), err)
+		return
+	}
+
+	resp.WriteHeader(http.StatusAccepted)
+}
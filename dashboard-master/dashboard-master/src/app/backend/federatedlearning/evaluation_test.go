@@ -0,0 +1,108 @@
+package federatedlearning
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestTrainingMetrics(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a", "b")
+	updates := []*ModelUpdate{
+		{ClientID: "a", NumSamples: 30, Metrics: map[string]float64{MetricLoss: 0.5, MetricAccuracy: 0.8}},
+		{ClientID: "b", NumSamples: 10, Metrics: map[string]float64{MetricLoss: 0.9, "latency": 3}},
+	}
+
+	invalid := &ModelUpdate{ClientID: "a", ModelID: "test", BaseVersion: 1, WeightUpdate: encodeWeights([]float32{1, 1}),
+		Metrics: map[string]float64{MetricLoss: math.Inf(1)}}
+	if err := c.SubmitModelUpdate(invalid); !errors.Is(err, ErrInvalidUpdate) {
+		t.Errorf("expected update with infinite loss to be rejected, got %v", err)
+	}
+
+	for _, update := range updates {
+		update.ModelID, update.BaseVersion, update.WeightUpdate = "test", 1, encodeWeights([]float32{1, 1})
+		if err := c.SubmitModelUpdate(update); err != nil {
+			t.Fatalf("SubmitModelUpdate(%s): unexpected error: %v", update.ClientID, err)
+		}
+	}
+
+	model, _ := c.GetModel("test")
+	if model.Training == nil || model.Training.NumSamples != 40 || len(model.Training.Clients) != 2 {
+		t.Fatalf("expected training metrics of 2 clients with 40 samples, got %+v", model.Training)
+	}
+
+	cases := []struct {
+		name     string
+		expected MetricSummary
+	}{
+		{MetricLoss, MetricSummary{Mean: 0.6, Min: 0.5, Max: 0.9, Clients: 2, Weight: 40}},
+		{MetricAccuracy, MetricSummary{Mean: 0.8, Min: 0.8, Max: 0.8, Clients: 1, Weight: 30}},
+		{"latency", MetricSummary{Mean: 3, Min: 3, Max: 3, Clients: 1, Weight: 10}},
+	}
+	for _, tc := range cases {
+		actual := model.Training.Metrics[tc.name]
+		if actual == nil || math.Abs(actual.Mean-tc.expected.Mean) > 1e-9 || actual.Min != tc.expected.Min ||
+			actual.Max != tc.expected.Max || actual.Clients != tc.expected.Clients || actual.Weight != tc.expected.Weight {
+			t.Errorf("expected metric %s %+v, got %+v", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestEvaluation(t *testing.T) {
+	store, _ := NewFileStore(t.TempDir())
+	c := newTestPersistentCoordinator(t, store)
+	if _, err := c.CreateModel(&ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}),
+		Config: ModelConfig{Round: RoundConfig{MinParticipants: 2}}}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		c.RegisterClient(&Identity{ClientID: id, PodUID: id}, &ClientRegistration{ModelID: "test"})
+	}
+	trainTestRound(t, c, []float32{1, 1})
+
+	server := newTestAPIServer(c)
+	defer server.Close()
+	url := func(version string) string {
+		return server.URL + "/api/v1/fl/model/test/versions/" + version + "/evaluation"
+	}
+
+	cases := []struct {
+		info       string
+		caller     string
+		version    string
+		evaluation *Evaluation
+		expected   int
+	}{
+		{"should score previous version", "a", "1",
+			&Evaluation{NumSamples: 10, Metrics: map[string]float64{MetricAccuracy: 0.7}}, http.StatusAccepted},
+		{"should score current version", "a", "2",
+			&Evaluation{NumSamples: 10, Metrics: map[string]float64{MetricAccuracy: 0.9}}, http.StatusAccepted},
+		{"should average scores of clients", "b", "2",
+			&Evaluation{NumSamples: 30, Metrics: map[string]float64{MetricAccuracy: 0.5}}, http.StatusAccepted},
+		{"should reject second evaluation of client", "a", "2",
+			&Evaluation{Metrics: map[string]float64{MetricAccuracy: 1}}, http.StatusConflict},
+		{"should reject evaluation without metrics", "b", "1", &Evaluation{}, http.StatusBadRequest},
+		{"should reject evaluation of missing version", "b", "3",
+			&Evaluation{Metrics: map[string]float64{MetricAccuracy: 1}}, http.StatusNotFound},
+		{"should reject evaluation on behalf of another client", "a", "1",
+			&Evaluation{ClientID: "b", Metrics: map[string]float64{MetricAccuracy: 1}}, http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		if status := doTestClientRequest(t, tc.caller, http.MethodPost, url(tc.version), tc.evaluation, nil); status != tc.expected {
+			t.Errorf("%s: expected status %d, got %d", tc.info, tc.expected, status)
+		}
+	}
+
+	// Evaluations survive a restart, the current version keeps the evaluation of its history entry.
+	restored := newTestPersistentCoordinator(t, store)
+	model, _ := restored.GetModel("test")
+	versions, _ := restored.ListVersions("test")
+	if model.Evaluation == nil || math.Abs(model.Evaluation.Metrics[MetricAccuracy].Mean-0.6) > 1e-9 {
+		t.Errorf("expected mean accuracy 0.6 of version 2, got %+v", model.Evaluation)
+	}
+	if versions[0].Evaluation == nil || versions[0].Evaluation.Metrics[MetricAccuracy].Mean != 0.7 {
+		t.Errorf("expected mean accuracy 0.7 of version 1, got %+v", versions[0].Evaluation)
+	}
+}
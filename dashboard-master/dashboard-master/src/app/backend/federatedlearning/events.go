@@ -0,0 +1,154 @@
+package federatedlearning
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// EventType is the type of an event emitted by the coordinator.
+type EventType string
+
+const (
+	// EventClientRegistered is emitted when a client registers.
+	EventClientRegistered EventType = "clientRegistered"
+	// EventClientOffline is emitted when a client missed its heartbeats and is marked offline.
+	EventClientOffline EventType = "clientOffline"
+	// EventUpdateReceived is emitted when an update was accepted into a round.
+	EventUpdateReceived EventType = "updateReceived"
+	// EventUpdateRejected is emitted when an update was rejected. The message holds the reason.
+	EventUpdateRejected EventType = "updateRejected"
+	// EventModelVersion is emitted when a round was aggregated into a new model version.
+	EventModelVersion EventType = "modelVersion"
+	// EventVersionPromoted is emitted when a candidate version was promoted. The message holds the reason.
+	EventVersionPromoted EventType = "versionPromoted"
+	// EventVersionRejected is emitted when a candidate version was rejected. The message holds the reason.
+	EventVersionRejected EventType = "versionRejected"
+)
+
+const (
+	// eventBufferSize is the number of events buffered for a subscriber. Events are dropped for subscribers
+	// that fall further behind.
+	eventBufferSize = 64
+	// eventKeepAlive is the interval of comments sent on idle event streams, so that proxies do not close
+	// them.
+	eventKeepAlive = 15 * time.Second
+)
+
+// Event describes activity of the coordinator.
+type Event struct {
+	Type     EventType `json:"type"`
+	ModelID  string    `json:"modelId,omitempty"`
+	ClientID string    `json:"clientId,omitempty"`
+	Round    int       `json:"round,omitempty"`
+	Version  int       `json:"version,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// eventBroker fans out events to subscribers. Publishing never blocks.
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]string
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[chan Event]string)}
+}
+
+// subscribe returns a channel receiving events of the given model, or of all models if modelID is empty.
+func (b *eventBroker) subscribe(modelID string) chan Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make(chan Event, eventBufferSize)
+	b.subscribers[events] = modelID
+	return events
+}
+
+// unsubscribe stops delivering events to a channel returned by subscribe and closes it.
+func (b *eventBroker) unsubscribe(events chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.subscribers[events]; exists {
+		delete(b.subscribers, events)
+		close(events)
+	}
+}
+
+// publish delivers an event to all subscribers interested in its model.
+func (b *eventBroker) publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for events, modelID := range b.subscribers {
+		if modelID != "" && modelID != event.ModelID {
+			continue
+		}
+
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving events of the given model, or of all models if modelID is empty,
+// and a function that cancels the subscription. Events are dropped if the channel is not drained.
+func (c *Coordinator) Subscribe(modelID string) (<-chan Event, func()) {
+	events := c.events.subscribe(modelID)
+	return events, func() { c.events.unsubscribe(events) }
+}
+
+// emit publishes an event stamped with the current time.
+func (c *Coordinator) emit(event Event) {
+	event.Time = c.now()
+	c.events.publish(event)
+}
+
+// streamEvents streams events of the coordinator as server-sent events. The modelId query parameter
+// restricts the stream to a single model.
+func (a *API) streamEvents(req *restful.Request, resp *restful.Response) {
+	flusher, ok := resp.ResponseWriter.(http.Flusher)
+	if !ok {
+		resp.WriteErrorString(http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	events, cancel := a.coordinator.Subscribe(req.QueryParameter("modelId"))
+	defer cancel()
+
+	header := resp.ResponseWriter.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	resp.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-req.Request.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(resp, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(resp, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,88 @@
+package federatedlearning
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIEventStream(t *testing.T) {
+	c := newTestCoordinator(t, 1)
+	if _, err := c.CreateModel(&ModelSpec{ID: "other", Weights: encodeWeights([]float32{0, 0})}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	server := newTestAPIServer(c)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/fl/events?modelId=test", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /fl/events: unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("expected event stream, got %s", contentType)
+	}
+
+	c.RegisterClient(&Identity{ClientID: "b"}, &ClientRegistration{ModelID: "other"})
+	c.RegisterClient(&Identity{ClientID: "a"}, &ClientRegistration{ModelID: "test"})
+	c.SubmitModelUpdate(&ModelUpdate{ClientID: "a", ModelID: "test", BaseVersion: 2,
+		WeightUpdate: encodeWeights([]float32{1, 1})})
+	c.SubmitModelUpdate(&ModelUpdate{ClientID: "a", ModelID: "test", BaseVersion: 1,
+		WeightUpdate: encodeWeights([]float32{1, 1})})
+
+	expected := []Event{
+		{Type: EventClientRegistered, ModelID: "test", ClientID: "a"},
+		{Type: EventUpdateRejected, ModelID: "test", ClientID: "a"},
+		{Type: EventUpdateReceived, ModelID: "test", ClientID: "a", Round: 1},
+		{Type: EventModelVersion, ModelID: "test", Round: 1, Version: 2},
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for _, e := range expected {
+		var name string
+		for scanner.Scan() {
+			line := scanner.Text()
+			if value, found := strings.CutPrefix(line, "event: "); found {
+				name = value
+			}
+			if value, found := strings.CutPrefix(line, "data: "); found {
+				event := new(Event)
+				if err := json.Unmarshal([]byte(value), event); err != nil {
+					t.Fatalf("failed to decode event %s: %v", value, err)
+				}
+				if name != string(e.Type) || event.Type != e.Type || event.ModelID != e.ModelID ||
+					event.ClientID != e.ClientID || event.Round != e.Round || event.Version != e.Version {
+					t.Errorf("expected event %+v, got %s %+v", e, name, event)
+				}
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Errorf("failed to read event stream: %v", err)
+	}
+}
+
+func TestEventBrokerDropsForSlowSubscribers(t *testing.T) {
+	c := NewCoordinator()
+	events, cancel := c.Subscribe("")
+
+	for i := 0; i < eventBufferSize+1; i++ {
+		c.emit(Event{Type: EventUpdateReceived, Round: i})
+	}
+	if len(events) != eventBufferSize {
+		t.Errorf("expected %d buffered events, got %d", eventBufferSize, len(events))
+	}
+
+	cancel()
+	for range events {
+	}
+	c.emit(Event{Type: EventUpdateReceived})
+}
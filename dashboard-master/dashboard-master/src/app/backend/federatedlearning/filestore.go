@@ -0,0 +1,124 @@
+package federatedlearning
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileBlobStore implements BlobStore on the local file system. Every key is stored as a file relative to
+// the root directory.
+type fileBlobStore struct {
+	root string
+}
+
+// NewFileBlobStore creates a BlobStore that keeps objects as files under the given directory.
+func NewFileBlobStore(root string) (BlobStore, error) {
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, err
+	}
+
+	return &fileBlobStore{root: root}, nil
+}
+
+// NewFileStore creates a Store that keeps the coordinator state under the given directory.
+func NewFileStore(root string) (Store, error) {
+	metadata, err := NewFileBlobStore(filepath.Join(root, "metadata"))
+	if err != nil {
+		return nil, err
+	}
+
+	blobs, err := NewFileBlobStore(filepath.Join(root, "blobs"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStore(metadata, blobs), nil
+}
+
+// Put implements BlobStore interface. See BlobStore for more information.
+func (s *fileBlobStore) Put(key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash never leaves a partially written object behind.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// Get implements BlobStore interface. See BlobStore for more information.
+func (s *fileBlobStore) Get(key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, key)
+	}
+
+	return data, err
+}
+
+// Delete implements BlobStore interface. See BlobStore for more information.
+func (s *fileBlobStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// List implements BlobStore interface. See BlobStore for more information.
+func (s *fileBlobStore) List(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	err := filepath.WalkDir(s.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+
+	return keys, err
+}
+
+// path returns the file an object is stored in. Keys must not escape the root directory.
+func (s *fileBlobStore) path(key string) (string, error) {
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("invalid blob key: %q", key)
+		}
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,1138 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: federatedlearning.proto
+
+package flgrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Compression of model updates.
+type Compression struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Delta         bool                   `protobuf:"varint,1,opt,name=delta,proto3" json:"delta,omitempty"`
+	TopK          float64                `protobuf:"fixed64,2,opt,name=top_k,json=topK,proto3" json:"top_k,omitempty"`
+	Quantize      bool                   `protobuf:"varint,3,opt,name=quantize,proto3" json:"quantize,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Compression) Reset() {
+	*x = Compression{}
+	mi := &file_federatedlearning_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Compression) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Compression) ProtoMessage() {}
+
+func (x *Compression) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Compression.ProtoReflect.Descriptor instead.
+func (*Compression) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Compression) GetDelta() bool {
+	if x != nil {
+		return x.Delta
+	}
+	return false
+}
+
+func (x *Compression) GetTopK() float64 {
+	if x != nil {
+		return x.TopK
+	}
+	return 0
+}
+
+func (x *Compression) GetQuantize() bool {
+	if x != nil {
+		return x.Quantize
+	}
+	return false
+}
+
+type RegisterRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	ModelId string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	// Size of the local data set of the client, used by selection strategies.
+	NumSamples int64 `protobuf:"varint,2,opt,name=num_samples,json=numSamples,proto3" json:"num_samples,omitempty"`
+	// Compression of model updates the client supports.
+	Compression   *Compression `protobuf:"bytes,3,opt,name=compression,proto3" json:"compression,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	mi := &file_federatedlearning_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RegisterRequest) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetNumSamples() int64 {
+	if x != nil {
+		return x.NumSamples
+	}
+	return 0
+}
+
+func (x *RegisterRequest) GetCompression() *Compression {
+	if x != nil {
+		return x.Compression
+	}
+	return nil
+}
+
+type Client struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Id           string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ModelId      string                 `protobuf:"bytes,2,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	Status       string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	RegisteredAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=registered_at,json=registeredAt,proto3" json:"registered_at,omitempty"`
+	LastSeen     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	NumSamples   int64                  `protobuf:"varint,6,opt,name=num_samples,json=numSamples,proto3" json:"num_samples,omitempty"`
+	// Compression negotiated at registration.
+	Compression   *Compression `protobuf:"bytes,7,opt,name=compression,proto3" json:"compression,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Client) Reset() {
+	*x = Client{}
+	mi := &file_federatedlearning_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Client) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Client) ProtoMessage() {}
+
+func (x *Client) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Client.ProtoReflect.Descriptor instead.
+func (*Client) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Client) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Client) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *Client) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Client) GetRegisteredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RegisteredAt
+	}
+	return nil
+}
+
+func (x *Client) GetLastSeen() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeen
+	}
+	return nil
+}
+
+func (x *Client) GetNumSamples() int64 {
+	if x != nil {
+		return x.NumSamples
+	}
+	return 0
+}
+
+func (x *Client) GetCompression() *Compression {
+	if x != nil {
+		return x.Compression
+	}
+	return nil
+}
+
+type HeartbeatRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientId      string                 `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatRequest) Reset() {
+	*x = HeartbeatRequest{}
+	mi := &file_federatedlearning_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatRequest) ProtoMessage() {}
+
+func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *HeartbeatRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+type GetModelRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	ModelId string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	// Client that is going to train the model. If set, the client is marked as training, and the call
+	// fails with UNAVAILABLE and a RetryInfo detail if the client was not selected for the current round.
+	ClientId string `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// Version to get. The current version is returned if it is zero. Ignored if client_id is set.
+	Version       int64 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetModelRequest) Reset() {
+	*x = GetModelRequest{}
+	mi := &file_federatedlearning_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetModelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetModelRequest) ProtoMessage() {}
+
+func (x *GetModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetModelRequest.ProtoReflect.Descriptor instead.
+func (*GetModelRequest) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetModelRequest) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *GetModelRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *GetModelRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type Model struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	ParentVersion int64                  `protobuf:"varint,3,opt,name=parent_version,json=parentVersion,proto3" json:"parent_version,omitempty"`
+	// SHA-256 digest of the weights, e.g. "sha256:<hex>".
+	Digest      string                 `protobuf:"bytes,4,opt,name=digest,proto3" json:"digest,omitempty"`
+	Size        int64                  `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Description string                 `protobuf:"bytes,7,opt,name=description,proto3" json:"description,omitempty"`
+	// FedProx coefficient of the proximal term clients add to their local objective.
+	ProximalMu float64 `protobuf:"fixed64,8,opt,name=proximal_mu,json=proximalMu,proto3" json:"proximal_mu,omitempty"`
+	// Promotion state of the version, "candidate" or "baseline" if the client was picked to evaluate it.
+	Promotion string `protobuf:"bytes,9,opt,name=promotion,proto3" json:"promotion,omitempty"`
+	// IDs of the clients whose updates were aggregated into the version.
+	Contributors []string `protobuf:"bytes,10,rep,name=contributors,proto3" json:"contributors,omitempty"`
+	// Signature of the provenance of the version. Unset if the coordinator does not sign versions.
+	Signature     *Signature `protobuf:"bytes,11,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Model) Reset() {
+	*x = Model{}
+	mi := &file_federatedlearning_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Model) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Model) ProtoMessage() {}
+
+func (x *Model) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Model.ProtoReflect.Descriptor instead.
+func (*Model) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Model) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Model) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Model) GetParentVersion() int64 {
+	if x != nil {
+		return x.ParentVersion
+	}
+	return 0
+}
+
+func (x *Model) GetDigest() string {
+	if x != nil {
+		return x.Digest
+	}
+	return ""
+}
+
+func (x *Model) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Model) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Model) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Model) GetProximalMu() float64 {
+	if x != nil {
+		return x.ProximalMu
+	}
+	return 0
+}
+
+func (x *Model) GetPromotion() string {
+	if x != nil {
+		return x.Promotion
+	}
+	return ""
+}
+
+func (x *Model) GetContributors() []string {
+	if x != nil {
+		return x.Contributors
+	}
+	return nil
+}
+
+func (x *Model) GetSignature() *Signature {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+// Signature of the provenance of a version, see the Go type federatedlearning.Signature.
+type Signature struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the public key the signature is verified with.
+	KeyId string `protobuf:"bytes,1,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	// Signature algorithm, "Ed25519".
+	Algorithm string `protobuf:"bytes,2,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	// The signed provenance, a JSON object holding modelId, version, parentVersion, digest and contributors.
+	Payload       []byte `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	Value         []byte `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Signature) Reset() {
+	*x = Signature{}
+	mi := &file_federatedlearning_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Signature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Signature) ProtoMessage() {}
+
+func (x *Signature) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Signature.ProtoReflect.Descriptor instead.
+func (*Signature) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Signature) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *Signature) GetAlgorithm() string {
+	if x != nil {
+		return x.Algorithm
+	}
+	return ""
+}
+
+func (x *Signature) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Signature) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type ModelChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Chunk:
+	//
+	//	*ModelChunk_Model
+	//	*ModelChunk_Weights
+	Chunk         isModelChunk_Chunk `protobuf_oneof:"chunk"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelChunk) Reset() {
+	*x = ModelChunk{}
+	mi := &file_federatedlearning_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelChunk) ProtoMessage() {}
+
+func (x *ModelChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelChunk.ProtoReflect.Descriptor instead.
+func (*ModelChunk) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ModelChunk) GetChunk() isModelChunk_Chunk {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+func (x *ModelChunk) GetModel() *Model {
+	if x != nil {
+		if x, ok := x.Chunk.(*ModelChunk_Model); ok {
+			return x.Model
+		}
+	}
+	return nil
+}
+
+func (x *ModelChunk) GetWeights() []byte {
+	if x != nil {
+		if x, ok := x.Chunk.(*ModelChunk_Weights); ok {
+			return x.Weights
+		}
+	}
+	return nil
+}
+
+type isModelChunk_Chunk interface {
+	isModelChunk_Chunk()
+}
+
+type ModelChunk_Model struct {
+	Model *Model `protobuf:"bytes,1,opt,name=model,proto3,oneof"`
+}
+
+type ModelChunk_Weights struct {
+	Weights []byte `protobuf:"bytes,2,opt,name=weights,proto3,oneof"`
+}
+
+func (*ModelChunk_Model) isModelChunk_Chunk() {}
+
+func (*ModelChunk_Weights) isModelChunk_Chunk() {}
+
+type UpdateMetadata struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	ClientId string                 `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	ModelId  string                 `protobuf:"bytes,2,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	// Version of the model the update is based on.
+	BaseVersion int64 `protobuf:"varint,3,opt,name=base_version,json=baseVersion,proto3" json:"base_version,omitempty"`
+	NumSamples  int64 `protobuf:"varint,4,opt,name=num_samples,json=numSamples,proto3" json:"num_samples,omitempty"`
+	// Metrics of local training, e.g. "loss" and "accuracy".
+	Metrics       map[string]float64 `protobuf:"bytes,5,rep,name=metrics,proto3" json:"metrics,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateMetadata) Reset() {
+	*x = UpdateMetadata{}
+	mi := &file_federatedlearning_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateMetadata) ProtoMessage() {}
+
+func (x *UpdateMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateMetadata.ProtoReflect.Descriptor instead.
+func (*UpdateMetadata) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateMetadata) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *UpdateMetadata) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *UpdateMetadata) GetBaseVersion() int64 {
+	if x != nil {
+		return x.BaseVersion
+	}
+	return 0
+}
+
+func (x *UpdateMetadata) GetNumSamples() int64 {
+	if x != nil {
+		return x.NumSamples
+	}
+	return 0
+}
+
+func (x *UpdateMetadata) GetMetrics() map[string]float64 {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+type UpdateChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Chunk:
+	//
+	//	*UpdateChunk_Metadata
+	//	*UpdateChunk_Weights
+	Chunk         isUpdateChunk_Chunk `protobuf_oneof:"chunk"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateChunk) Reset() {
+	*x = UpdateChunk{}
+	mi := &file_federatedlearning_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateChunk) ProtoMessage() {}
+
+func (x *UpdateChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateChunk.ProtoReflect.Descriptor instead.
+func (*UpdateChunk) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateChunk) GetChunk() isUpdateChunk_Chunk {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+func (x *UpdateChunk) GetMetadata() *UpdateMetadata {
+	if x != nil {
+		if x, ok := x.Chunk.(*UpdateChunk_Metadata); ok {
+			return x.Metadata
+		}
+	}
+	return nil
+}
+
+func (x *UpdateChunk) GetWeights() []byte {
+	if x != nil {
+		if x, ok := x.Chunk.(*UpdateChunk_Weights); ok {
+			return x.Weights
+		}
+	}
+	return nil
+}
+
+type isUpdateChunk_Chunk interface {
+	isUpdateChunk_Chunk()
+}
+
+type UpdateChunk_Metadata struct {
+	Metadata *UpdateMetadata `protobuf:"bytes,1,opt,name=metadata,proto3,oneof"`
+}
+
+type UpdateChunk_Weights struct {
+	Weights []byte `protobuf:"bytes,2,opt,name=weights,proto3,oneof"`
+}
+
+func (*UpdateChunk_Metadata) isUpdateChunk_Chunk() {}
+
+func (*UpdateChunk_Weights) isUpdateChunk_Chunk() {}
+
+type SubmitUpdateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitUpdateResponse) Reset() {
+	*x = SubmitUpdateResponse{}
+	mi := &file_federatedlearning_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitUpdateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitUpdateResponse) ProtoMessage() {}
+
+func (x *SubmitUpdateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitUpdateResponse.ProtoReflect.Descriptor instead.
+func (*SubmitUpdateResponse) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{10}
+}
+
+type WatchEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Model whose events are streamed, all models if empty.
+	ModelId       string `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	mi := &file_federatedlearning_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *WatchEventsRequest) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	ModelId       string                 `protobuf:"bytes,2,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	ClientId      string                 `protobuf:"bytes,3,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Round         int64                  `protobuf:"varint,4,opt,name=round,proto3" json:"round,omitempty"`
+	Version       int64                  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	Message       string                 `protobuf:"bytes,6,opt,name=message,proto3" json:"message,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_federatedlearning_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_federatedlearning_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_federatedlearning_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *Event) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *Event) GetRound() int64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *Event) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Event) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+var File_federatedlearning_proto protoreflect.FileDescriptor
+
+const file_federatedlearning_proto_rawDesc = "" +
+	"\n" +
+	"\x17federatedlearning.proto\x12\x14federatedlearning.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"T\n" +
+	"\vCompression\x12\x14\n" +
+	"\x05delta\x18\x01 \x01(\bR\x05delta\x12\x13\n" +
+	"\x05top_k\x18\x02 \x01(\x01R\x04topK\x12\x1a\n" +
+	"\bquantize\x18\x03 \x01(\bR\bquantize\"\x92\x01\n" +
+	"\x0fRegisterRequest\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\x12\x1f\n" +
+	"\vnum_samples\x18\x02 \x01(\x03R\n" +
+	"numSamples\x12C\n" +
+	"\vcompression\x18\x03 \x01(\v2!.federatedlearning.v1.CompressionR\vcompression\"\xab\x02\n" +
+	"\x06Client\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\bmodel_id\x18\x02 \x01(\tR\amodelId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12?\n" +
+	"\rregistered_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\fregisteredAt\x127\n" +
+	"\tlast_seen\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\blastSeen\x12\x1f\n" +
+	"\vnum_samples\x18\x06 \x01(\x03R\n" +
+	"numSamples\x12C\n" +
+	"\vcompression\x18\a \x01(\v2!.federatedlearning.v1.CompressionR\vcompression\"/\n" +
+	"\x10HeartbeatRequest\x12\x1b\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\"c\n" +
+	"\x0fGetModelRequest\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\x12\x1b\n" +
+	"\tclient_id\x18\x02 \x01(\tR\bclientId\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\"\x83\x03\n" +
+	"\x05Model\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12%\n" +
+	"\x0eparent_version\x18\x03 \x01(\x03R\rparentVersion\x12\x16\n" +
+	"\x06digest\x18\x04 \x01(\tR\x06digest\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x03R\x04size\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12 \n" +
+	"\vdescription\x18\a \x01(\tR\vdescription\x12\x1f\n" +
+	"\vproximal_mu\x18\b \x01(\x01R\n" +
+	"proximalMu\x12\x1c\n" +
+	"\tpromotion\x18\t \x01(\tR\tpromotion\x12\"\n" +
+	"\fcontributors\x18\n" +
+	" \x03(\tR\fcontributors\x12=\n" +
+	"\tsignature\x18\v \x01(\v2\x1f.federatedlearning.v1.SignatureR\tsignature\"p\n" +
+	"\tSignature\x12\x15\n" +
+	"\x06key_id\x18\x01 \x01(\tR\x05keyId\x12\x1c\n" +
+	"\talgorithm\x18\x02 \x01(\tR\talgorithm\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\fR\x05value\"f\n" +
+	"\n" +
+	"ModelChunk\x123\n" +
+	"\x05model\x18\x01 \x01(\v2\x1b.federatedlearning.v1.ModelH\x00R\x05model\x12\x1a\n" +
+	"\aweights\x18\x02 \x01(\fH\x00R\aweightsB\a\n" +
+	"\x05chunk\"\x95\x02\n" +
+	"\x0eUpdateMetadata\x12\x1b\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12\x19\n" +
+	"\bmodel_id\x18\x02 \x01(\tR\amodelId\x12!\n" +
+	"\fbase_version\x18\x03 \x01(\x03R\vbaseVersion\x12\x1f\n" +
+	"\vnum_samples\x18\x04 \x01(\x03R\n" +
+	"numSamples\x12K\n" +
+	"\ametrics\x18\x05 \x03(\v21.federatedlearning.v1.UpdateMetadata.MetricsEntryR\ametrics\x1a:\n" +
+	"\fMetricsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"v\n" +
+	"\vUpdateChunk\x12B\n" +
+	"\bmetadata\x18\x01 \x01(\v2$.federatedlearning.v1.UpdateMetadataH\x00R\bmetadata\x12\x1a\n" +
+	"\aweights\x18\x02 \x01(\fH\x00R\aweightsB\a\n" +
+	"\x05chunk\"\x16\n" +
+	"\x14SubmitUpdateResponse\"/\n" +
+	"\x12WatchEventsRequest\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\"\xcd\x01\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x19\n" +
+	"\bmodel_id\x18\x02 \x01(\tR\amodelId\x12\x1b\n" +
+	"\tclient_id\x18\x03 \x01(\tR\bclientId\x12\x14\n" +
+	"\x05round\x18\x04 \x01(\x03R\x05round\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x03R\aversion\x12\x18\n" +
+	"\amessage\x18\x06 \x01(\tR\amessage\x12.\n" +
+	"\x04time\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x04time2\xc1\x03\n" +
+	"\vCoordinator\x12O\n" +
+	"\bRegister\x12%.federatedlearning.v1.RegisterRequest\x1a\x1c.federatedlearning.v1.Client\x12Q\n" +
+	"\tHeartbeat\x12&.federatedlearning.v1.HeartbeatRequest\x1a\x1c.federatedlearning.v1.Client\x12U\n" +
+	"\bGetModel\x12%.federatedlearning.v1.GetModelRequest\x1a .federatedlearning.v1.ModelChunk0\x01\x12_\n" +
+	"\fSubmitUpdate\x12!.federatedlearning.v1.UpdateChunk\x1a*.federatedlearning.v1.SubmitUpdateResponse(\x01\x12V\n" +
+	"\vWatchEvents\x12(.federatedlearning.v1.WatchEventsRequest\x1a\x1b.federatedlearning.v1.Event0\x01BJZHgithub.com/kubernetes/dashboard/src/app/backend/federatedlearning/flgrpcb\x06proto3"
+
+var (
+	file_federatedlearning_proto_rawDescOnce sync.Once
+	file_federatedlearning_proto_rawDescData []byte
+)
+
+func file_federatedlearning_proto_rawDescGZIP() []byte {
+	file_federatedlearning_proto_rawDescOnce.Do(func() {
+		file_federatedlearning_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_federatedlearning_proto_rawDesc), len(file_federatedlearning_proto_rawDesc)))
+	})
+	return file_federatedlearning_proto_rawDescData
+}
+
+var file_federatedlearning_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_federatedlearning_proto_goTypes = []any{
+	(*Compression)(nil),           // 0: federatedlearning.v1.Compression
+	(*RegisterRequest)(nil),       // 1: federatedlearning.v1.RegisterRequest
+	(*Client)(nil),                // 2: federatedlearning.v1.Client
+	(*HeartbeatRequest)(nil),      // 3: federatedlearning.v1.HeartbeatRequest
+	(*GetModelRequest)(nil),       // 4: federatedlearning.v1.GetModelRequest
+	(*Model)(nil),                 // 5: federatedlearning.v1.Model
+	(*Signature)(nil),             // 6: federatedlearning.v1.Signature
+	(*ModelChunk)(nil),            // 7: federatedlearning.v1.ModelChunk
+	(*UpdateMetadata)(nil),        // 8: federatedlearning.v1.UpdateMetadata
+	(*UpdateChunk)(nil),           // 9: federatedlearning.v1.UpdateChunk
+	(*SubmitUpdateResponse)(nil),  // 10: federatedlearning.v1.SubmitUpdateResponse
+	(*WatchEventsRequest)(nil),    // 11: federatedlearning.v1.WatchEventsRequest
+	(*Event)(nil),                 // 12: federatedlearning.v1.Event
+	nil,                           // 13: federatedlearning.v1.UpdateMetadata.MetricsEntry
+	(*timestamppb.Timestamp)(nil), // 14: google.protobuf.Timestamp
+}
+var file_federatedlearning_proto_depIdxs = []int32{
+	0,  // 0: federatedlearning.v1.RegisterRequest.compression:type_name -> federatedlearning.v1.Compression
+	14, // 1: federatedlearning.v1.Client.registered_at:type_name -> google.protobuf.Timestamp
+	14, // 2: federatedlearning.v1.Client.last_seen:type_name -> google.protobuf.Timestamp
+	0,  // 3: federatedlearning.v1.Client.compression:type_name -> federatedlearning.v1.Compression
+	14, // 4: federatedlearning.v1.Model.created_at:type_name -> google.protobuf.Timestamp
+	6,  // 5: federatedlearning.v1.Model.signature:type_name -> federatedlearning.v1.Signature
+	5,  // 6: federatedlearning.v1.ModelChunk.model:type_name -> federatedlearning.v1.Model
+	13, // 7: federatedlearning.v1.UpdateMetadata.metrics:type_name -> federatedlearning.v1.UpdateMetadata.MetricsEntry
+	8,  // 8: federatedlearning.v1.UpdateChunk.metadata:type_name -> federatedlearning.v1.UpdateMetadata
+	14, // 9: federatedlearning.v1.Event.time:type_name -> google.protobuf.Timestamp
+	1,  // 10: federatedlearning.v1.Coordinator.Register:input_type -> federatedlearning.v1.RegisterRequest
+	3,  // 11: federatedlearning.v1.Coordinator.Heartbeat:input_type -> federatedlearning.v1.HeartbeatRequest
+	4,  // 12: federatedlearning.v1.Coordinator.GetModel:input_type -> federatedlearning.v1.GetModelRequest
+	9,  // 13: federatedlearning.v1.Coordinator.SubmitUpdate:input_type -> federatedlearning.v1.UpdateChunk
+	11, // 14: federatedlearning.v1.Coordinator.WatchEvents:input_type -> federatedlearning.v1.WatchEventsRequest
+	2,  // 15: federatedlearning.v1.Coordinator.Register:output_type -> federatedlearning.v1.Client
+	2,  // 16: federatedlearning.v1.Coordinator.Heartbeat:output_type -> federatedlearning.v1.Client
+	7,  // 17: federatedlearning.v1.Coordinator.GetModel:output_type -> federatedlearning.v1.ModelChunk
+	10, // 18: federatedlearning.v1.Coordinator.SubmitUpdate:output_type -> federatedlearning.v1.SubmitUpdateResponse
+	12, // 19: federatedlearning.v1.Coordinator.WatchEvents:output_type -> federatedlearning.v1.Event
+	15, // [15:20] is the sub-list for method output_type
+	10, // [10:15] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_federatedlearning_proto_init() }
+func file_federatedlearning_proto_init() {
+	if File_federatedlearning_proto != nil {
+		return
+	}
+	file_federatedlearning_proto_msgTypes[7].OneofWrappers = []any{
+		(*ModelChunk_Model)(nil),
+		(*ModelChunk_Weights)(nil),
+	}
+	file_federatedlearning_proto_msgTypes[9].OneofWrappers = []any{
+		(*UpdateChunk_Metadata)(nil),
+		(*UpdateChunk_Weights)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_federatedlearning_proto_rawDesc), len(file_federatedlearning_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_federatedlearning_proto_goTypes,
+		DependencyIndexes: file_federatedlearning_proto_depIdxs,
+		MessageInfos:      file_federatedlearning_proto_msgTypes,
+	}.Build()
+	File_federatedlearning_proto = out.File
+	file_federatedlearning_proto_goTypes = nil
+	file_federatedlearning_proto_depIdxs = nil
+}
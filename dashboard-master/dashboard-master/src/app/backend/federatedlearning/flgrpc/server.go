@@ -0,0 +1,371 @@
+// Package flgrpc serves the client API of the federated learning coordinator over gRPC. Weights are
+// streamed in binary chunks instead of being embedded in JSON documents.
+package flgrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative federatedlearning.proto
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	fl "github.com/kubernetes/dashboard/src/app/backend/federatedlearning"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/compress"
+)
+
+const (
+	// chunkSize is the size of the weight chunks streamed to clients.
+	chunkSize = 1 << 20
+	// maxUpdateSize is the maximum size of the weights of a streamed update.
+	maxUpdateSize = 1 << 30
+)
+
+// server implements CoordinatorServer on top of a coordinator.
+type server struct {
+	UnimplementedCoordinatorServer
+
+	coordinator   *fl.Coordinator
+	authenticator fl.Authenticator
+}
+
+// NewServer creates a gRPC server serving the coordinator. Clients are authenticated with the given
+// authenticator from the bearer token in the "authorization" metadata of their calls.
+func NewServer(coordinator *fl.Coordinator, authenticator fl.Authenticator, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	RegisterCoordinatorServer(s, &server{coordinator: coordinator, authenticator: authenticator})
+	return s
+}
+
+// ServerCredentials returns TLS credentials serving the given certificate. If clientCAFile is not empty,
+// clients have to present a certificate signed by one of the CAs in the file (mutual TLS).
+func ServerCredentials(certFile, keyFile, clientCAFile string) (credentials.TransportCredentials, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %v", err)
+	}
+
+	config := &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %v", err)
+		}
+
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", clientCAFile)
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(config), nil
+}
+
+// Register implements CoordinatorServer interface. See CoordinatorServer for more information.
+func (s *server) Register(ctx context.Context, req *RegisterRequest) (*Client, error) {
+	identity, err := s.authorizeClient(ctx, "")
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	registration := &fl.ClientRegistration{ModelID: req.ModelId, NumSamples: int(req.NumSamples),
+		Compression: compressionFromProto(req.Compression)}
+	client, err := s.coordinator.RegisterClient(identity, registration)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return clientToProto(client), nil
+}
+
+// Heartbeat implements CoordinatorServer interface. See CoordinatorServer for more information.
+func (s *server) Heartbeat(ctx context.Context, req *HeartbeatRequest) (*Client, error) {
+	if _, err := s.authorizeClient(ctx, req.ClientId); err != nil {
+		return nil, statusError(err)
+	}
+
+	client, err := s.coordinator.Heartbeat(req.ClientId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return clientToProto(client), nil
+}
+
+// GetModel implements CoordinatorServer interface. See CoordinatorServer for more information.
+func (s *server) GetModel(req *GetModelRequest, stream grpc.ServerStreamingServer[ModelChunk]) error {
+	var model *fl.GlobalModel
+	var err error
+	switch {
+	case req.ClientId != "":
+		if _, err = s.authorizeClient(stream.Context(), req.ClientId); err == nil {
+			model, err = s.coordinator.FetchModel(req.ModelId, req.ClientId)
+		}
+	case req.Version != 0:
+		model, err = s.coordinator.GetModelVersion(req.ModelId, int(req.Version))
+	default:
+		model, err = s.coordinator.GetModel(req.ModelId)
+	}
+	if err != nil {
+		return statusError(err)
+	}
+
+	if err := stream.Send(&ModelChunk{Chunk: &ModelChunk_Model{Model: modelToProto(model)}}); err != nil {
+		return err
+	}
+
+	for offset := 0; offset < len(model.Weights); offset += chunkSize {
+		chunk := model.Weights[offset:min(offset+chunkSize, len(model.Weights))]
+		if err := stream.Send(&ModelChunk{Chunk: &ModelChunk_Weights{Weights: chunk}}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SubmitUpdate implements CoordinatorServer interface. See CoordinatorServer for more information.
+func (s *server) SubmitUpdate(stream grpc.ClientStreamingServer[UpdateChunk, SubmitUpdateResponse]) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	header := first.GetMetadata()
+	if header == nil {
+		return status.Error(codes.InvalidArgument, "the first message has to carry the update metadata")
+	}
+
+	if _, err := s.authorizeClient(stream.Context(), header.ClientId); err != nil {
+		return statusError(err)
+	}
+
+	update := &fl.ModelUpdate{ClientID: header.ClientId, ModelID: header.ModelId,
+		BaseVersion: int(header.BaseVersion), NumSamples: int(header.NumSamples), Metrics: header.Metrics}
+	maxSize := maxUpdateSize
+	if quota := s.coordinator.Quota(header.ModelId).MaxUpdateSize; quota > 0 {
+		maxSize = min(maxSize, quota)
+	}
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		weights := chunk.GetWeights()
+		if chunk.GetMetadata() != nil {
+			return status.Error(codes.InvalidArgument, "update metadata can only be sent once")
+		}
+		if len(update.WeightUpdate)+len(weights) > maxSize {
+			return status.Errorf(codes.ResourceExhausted, "update exceeds %d bytes", maxSize)
+		}
+		update.WeightUpdate = append(update.WeightUpdate, weights...)
+	}
+
+	if err := s.coordinator.SubmitModelUpdate(update); err != nil {
+		return statusError(err)
+	}
+
+	return stream.SendAndClose(&SubmitUpdateResponse{})
+}
+
+// WatchEvents implements CoordinatorServer interface. See CoordinatorServer for more information.
+func (s *server) WatchEvents(req *WatchEventsRequest, stream grpc.ServerStreamingServer[Event]) error {
+	if _, err := s.authorizeClient(stream.Context(), ""); err != nil {
+		return statusError(err)
+	}
+
+	events, cancel := s.coordinator.Subscribe(req.ModelId)
+	defer cancel()
+
+	// Headers tell the client that the subscription is in place.
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(eventToProto(&event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// authorizeClient authenticates the caller and checks that it is the given client. An empty client ID is
+// accepted, the returned identity tells the ID of the caller. The bearer token in the metadata of the call
+// is handed to the authenticator as the Authorization header of a request. Calls are rate limited by the
+// address of the peer before the caller is authenticated, and by client afterwards.
+func (s *server) authorizeClient(ctx context.Context, clientID string) (*fl.Identity, error) {
+	if p, ok := peer.FromContext(ctx); ok {
+		if err := s.coordinator.CheckAddressRateLimit(fl.RemoteHost(p.Addr.String())); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", nil)
+	if err != nil {
+		return nil, err
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, value := range md.Get("authorization") {
+			req.Header.Add("Authorization", value)
+		}
+	}
+
+	identity, err := s.authenticator.Authenticate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.coordinator.CheckRateLimit(identity.ClientID); err != nil {
+		return nil, err
+	}
+
+	if clientID != "" && clientID != identity.ClientID {
+		return nil, fmt.Errorf("%w: caller %s is not client %s", fl.ErrClientMismatch, identity.ClientID, clientID)
+	}
+
+	return identity, nil
+}
+
+// statusError maps errors returned by the coordinator to gRPC status errors. Clients that were not
+// selected for a round or exceeded their request rate get a RetryInfo detail telling them when to try
+// again.
+func statusError(err error) error {
+	var retryAfter time.Duration
+	var wait *fl.WaitError
+	var rateLimit *fl.RateLimitError
+	switch {
+	case errors.As(err, &wait):
+		retryAfter = wait.RetryAfter
+	case errors.As(err, &rateLimit):
+		retryAfter = rateLimit.RetryAfter
+	default:
+		return status.Error(statusCode(err), err.Error())
+	}
+
+	st, detailErr := status.New(statusCode(err), err.Error()).WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if detailErr != nil {
+		return status.Error(statusCode(err), err.Error())
+	}
+	return st.Err()
+}
+
+// statusCode maps errors returned by the coordinator to gRPC status codes.
+func statusCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, fl.ErrModelNotFound), errors.Is(err, fl.ErrVersionNotFound):
+		return codes.NotFound
+	case errors.Is(err, fl.ErrUnauthenticated):
+		return codes.Unauthenticated
+	case errors.Is(err, fl.ErrClientNotRegistered), errors.Is(err, fl.ErrClientNotEnrolled),
+		errors.Is(err, fl.ErrClientMismatch):
+		return codes.PermissionDenied
+	case errors.Is(err, fl.ErrInvalidUpdate), errors.Is(err, fl.ErrInvalidModel):
+		return codes.InvalidArgument
+	case errors.Is(err, fl.ErrNotSelected):
+		return codes.Unavailable
+	case errors.Is(err, fl.ErrModelExists), errors.Is(err, fl.ErrDuplicateUpdate):
+		return codes.AlreadyExists
+	case errors.Is(err, fl.ErrStaleUpdate), errors.Is(err, fl.ErrRoundNotOpen), errors.Is(err, fl.ErrRoundActive):
+		return codes.FailedPrecondition
+	case errors.Is(err, fl.ErrPrivacyBudgetExhausted), errors.Is(err, fl.ErrUpdateTooLarge),
+		errors.Is(err, fl.ErrQuotaExceeded), errors.Is(err, fl.ErrRateLimited):
+		return codes.ResourceExhausted
+	default:
+		return codes.Internal
+	}
+}
+
+func compressionFromProto(c *Compression) compress.Options {
+	return compress.Options{Delta: c.GetDelta(), TopK: c.GetTopK(), Quantize: c.GetQuantize()}
+}
+
+func compressionToProto(o compress.Options) *Compression {
+	return &Compression{Delta: o.Delta, TopK: o.TopK, Quantize: o.Quantize}
+}
+
+func clientToProto(client *fl.Client) *Client {
+	return &Client{
+		Id:           client.ID,
+		ModelId:      client.ModelID,
+		Status:       client.Status,
+		RegisteredAt: timestamp(client.RegisteredAt),
+		LastSeen:     timestamp(client.LastSeen),
+		NumSamples:   int64(client.NumSamples),
+		Compression:  compressionToProto(client.Compression),
+	}
+}
+
+func modelToProto(model *fl.GlobalModel) *Model {
+	return &Model{
+		Id:            model.ID,
+		Version:       int64(model.Version),
+		ParentVersion: int64(model.ParentVersion),
+		Digest:        model.Digest,
+		Size:          int64(model.Size),
+		CreatedAt:     timestamp(model.CreatedAt),
+		Description:   model.Description,
+		ProximalMu:    model.ProximalMu,
+		Promotion:     string(model.Promotion),
+		Contributors:  model.Contributors,
+		Signature:     signatureToProto(model.Signature),
+	}
+}
+
+func signatureToProto(signature *fl.Signature) *Signature {
+	if signature == nil {
+		return nil
+	}
+
+	return &Signature{KeyId: signature.KeyID, Algorithm: signature.Algorithm, Payload: signature.Payload,
+		Value: signature.Value}
+}
+
+func eventToProto(event *fl.Event) *Event {
+	return &Event{
+		Type:     string(event.Type),
+		ModelId:  event.ModelID,
+		ClientId: event.ClientID,
+		Round:    int64(event.Round),
+		Version:  int64(event.Version),
+		Message:  event.Message,
+		Time:     timestamp(event.Time),
+	}
+}
+
+// timestamp converts a time to a protobuf timestamp, the zero time to nil.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
@@ -0,0 +1,343 @@
+package flgrpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	fl "github.com/kubernetes/dashboard/src/app/backend/federatedlearning"
+)
+
+// testAuthenticator authenticates clients whose bearer token is their client ID.
+type testAuthenticator struct{}
+
+func (testAuthenticator) Authenticate(req *http.Request) (*fl.Identity, error) {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return nil, fmt.Errorf("%w: bearer token is required", fl.ErrUnauthenticated)
+	}
+
+	return &fl.Identity{ClientID: token, PodUID: token}, nil
+}
+
+func encodeWeights(weights []float32) []byte {
+	data := make([]byte, len(weights)*4)
+	for i, w := range weights {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(w))
+	}
+
+	return data
+}
+
+// newTestServer serves the coordinator over an in-memory connection and returns a client connection to it.
+func newTestServer(t *testing.T, c *fl.Coordinator, serverOpts []grpc.ServerOption,
+	dialOpts ...grpc.DialOption) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(c, testAuthenticator{}, serverOpts...)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	if len(dialOpts) == 0 {
+		dialOpts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	dialOpts = append(dialOpts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialOpts...)
+	if err != nil {
+		t.Fatalf("NewClient(): unexpected error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func newTestCoordinator(t *testing.T, config fl.ModelConfig) *fl.Coordinator {
+	c := fl.NewCoordinator()
+	spec := &fl.ModelSpec{ID: "test", Weights: encodeWeights([]float32{1, 2, 3}), Config: config}
+	if _, err := c.CreateModel(spec); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+
+	return c
+}
+
+// as returns a context authenticating calls as the given client.
+func as(clientID string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+clientID)
+}
+
+// getModel receives a streamed model and its weights.
+func getModel(t *testing.T, client CoordinatorClient, req *GetModelRequest) (*Model, []byte, error) {
+	stream, err := client.GetModel(as(req.ClientId), req)
+	if err != nil {
+		t.Fatalf("GetModel(): unexpected error: %v", err)
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var weights []byte
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return first.GetModel(), weights, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		weights = append(weights, chunk.GetWeights()...)
+	}
+}
+
+// testSigner signs every payload with the same signature.
+type testSigner struct{}
+
+// Sign implements Signer interface.
+func (testSigner) Sign(payload []byte) (string, []byte, error) {
+	return "test", []byte("signature"), nil
+}
+
+func TestServer(t *testing.T) {
+	c := newTestCoordinator(t, fl.ModelConfig{Round: fl.RoundConfig{MinParticipants: 1, MaxParticipants: 1}})
+	if err := c.SetSigner(testSigner{}); err != nil {
+		t.Fatalf("SetSigner(): unexpected error: %v", err)
+	}
+	client := NewCoordinatorClient(newTestServer(t, c, nil))
+
+	ctx, cancel := context.WithTimeout(as("a"), 10*time.Second)
+	defer cancel()
+	events, err := client.WatchEvents(ctx, &WatchEventsRequest{ModelId: "test"})
+	if err != nil {
+		t.Fatalf("WatchEvents(): unexpected error: %v", err)
+	}
+	// The stream is established once the server sent its headers.
+	if _, err := events.Header(); err != nil {
+		t.Fatalf("Header(): unexpected error: %v", err)
+	}
+
+	registered, err := client.Register(as("a"), &RegisterRequest{ModelId: "test", NumSamples: 10,
+		Compression: &Compression{Quantize: true}})
+	if err != nil {
+		t.Fatalf("Register(): unexpected error: %v", err)
+	}
+	if registered.Id != "a" || registered.Status != fl.ClientAvailable || registered.Compression.Quantize {
+		t.Fatalf("expected available client a without compression offered by the model, got %v", registered)
+	}
+
+	if _, err := client.Heartbeat(as("a"), &HeartbeatRequest{ClientId: "a"}); err != nil {
+		t.Fatalf("Heartbeat(): unexpected error: %v", err)
+	}
+
+	model, weights, err := getModel(t, client, &GetModelRequest{ModelId: "test", ClientId: "a"})
+	if err != nil {
+		t.Fatalf("GetModel(): unexpected error: %v", err)
+	}
+	if model.Version != 1 || !bytes.Equal(weights, encodeWeights([]float32{1, 2, 3})) {
+		t.Fatalf("expected weights of version 1, got version %d with %v", model.Version, weights)
+	}
+
+	stream, err := client.SubmitUpdate(as("a"))
+	if err != nil {
+		t.Fatalf("SubmitUpdate(): unexpected error: %v", err)
+	}
+	stream.Send(&UpdateChunk{Chunk: &UpdateChunk_Metadata{Metadata: &UpdateMetadata{ClientId: "a", ModelId: "test",
+		BaseVersion: 1, NumSamples: 10, Metrics: map[string]float64{fl.MetricLoss: 0.5}}}})
+	update := encodeWeights([]float32{3, 2, 1})
+	for offset := 0; offset < len(update); offset += 5 {
+		stream.Send(&UpdateChunk{Chunk: &UpdateChunk_Weights{Weights: update[offset:min(offset+5, len(update))]}})
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		t.Fatalf("SubmitUpdate(): unexpected error: %v", err)
+	}
+
+	model, weights, err = getModel(t, client, &GetModelRequest{ModelId: "test", Version: 2})
+	if err != nil {
+		t.Fatalf("GetModel(): unexpected error: %v", err)
+	}
+	if model.Version != 2 || !bytes.Equal(weights, update) {
+		t.Fatalf("expected aggregated weights of version 2, got version %d with %v", model.Version, weights)
+	}
+	if len(model.Contributors) != 1 || model.Contributors[0] != "a" || model.Signature.GetKeyId() != "test" ||
+		!bytes.Equal(model.Signature.GetValue(), []byte("signature")) {
+		t.Fatalf("expected signed version 2 contributed by a, got %v", model)
+	}
+
+	expected := []string{string(fl.EventClientRegistered), string(fl.EventUpdateReceived),
+		string(fl.EventModelVersion)}
+	for _, eventType := range expected {
+		event, err := events.Recv()
+		if err != nil {
+			t.Fatalf("Recv(): unexpected error: %v", err)
+		}
+		if event.Type != eventType || event.ModelId != "test" {
+			t.Fatalf("expected %s event of model test, got %v", eventType, event)
+		}
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	c := newTestCoordinator(t, fl.ModelConfig{Round: fl.RoundConfig{MinParticipants: 1},
+		Selection: fl.SelectionConfig{ClientsPerRound: 1, RetryAfterSeconds: 30}})
+	client := NewCoordinatorClient(newTestServer(t, c, nil))
+
+	_, err := client.Register(context.Background(), &RegisterRequest{ModelId: "test"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without a token, got %v", err)
+	}
+
+	if _, err := client.Register(as("a"), &RegisterRequest{ModelId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for a missing model, got %v", err)
+	}
+
+	for _, id := range []string{"a", "b"} {
+		if _, err := client.Register(as(id), &RegisterRequest{ModelId: "test"}); err != nil {
+			t.Fatalf("Register(): unexpected error: %v", err)
+		}
+		if id == "a" {
+			if _, _, err := getModel(t, client, &GetModelRequest{ModelId: "test", ClientId: "a"}); err != nil {
+				t.Fatalf("GetModel(): unexpected error: %v", err)
+			}
+		}
+	}
+
+	_, err = client.Heartbeat(as("a"), &HeartbeatRequest{ClientId: "b"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for a heartbeat of another client, got %v", err)
+	}
+
+	// The round invites a single client, which is a.
+	_, _, err = getModel(t, client, &GetModelRequest{ModelId: "test", ClientId: "b"})
+	st := status.Convert(err)
+	if st.Code() != codes.Unavailable || len(st.Details()) != 1 {
+		t.Fatalf("expected Unavailable with retry info for a client that was not selected, got %v", err)
+	}
+	if info, ok := st.Details()[0].(*errdetails.RetryInfo); !ok || info.RetryDelay.AsDuration() != 30*time.Second {
+		t.Fatalf("expected retry after 30s, got %v", st.Details()[0])
+	}
+
+	stream, _ := client.SubmitUpdate(as("a"))
+	stream.Send(&UpdateChunk{Chunk: &UpdateChunk_Weights{Weights: []byte{1}}})
+	if _, err := stream.CloseAndRecv(); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for an update without metadata, got %v", err)
+	}
+
+	stream, _ = client.SubmitUpdate(as("a"))
+	stream.Send(&UpdateChunk{Chunk: &UpdateChunk_Metadata{Metadata: &UpdateMetadata{ClientId: "a", ModelId: "test",
+		BaseVersion: 7}}})
+	stream.Send(&UpdateChunk{Chunk: &UpdateChunk_Weights{Weights: encodeWeights([]float32{1, 1, 1})}})
+	if _, err := stream.CloseAndRecv(); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition for a stale update, got %v", err)
+	}
+}
+
+// writeTestCertificate writes a certificate signed by parent, or a self-signed CA certificate if parent is
+// nil, and its key to dir.
+func writeTestCertificate(t *testing.T, dir, name string, parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		0600)
+	certificate, _ := x509.ParseCertificate(der)
+	return certificate, key
+}
+
+func TestServerMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeTestCertificate(t, dir, "ca", nil, nil)
+	writeTestCertificate(t, dir, "coordinator", ca, caKey)
+	writeTestCertificate(t, dir, "xapp", ca, caKey)
+
+	creds, err := ServerCredentials(filepath.Join(dir, "coordinator.crt"), filepath.Join(dir, "coordinator.key"),
+		filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		t.Fatalf("ServerCredentials(): unexpected error: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	clientCert, err := tls.LoadX509KeyPair(filepath.Join(dir, "xapp.crt"), filepath.Join(dir, "xapp.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestCoordinator(t, fl.ModelConfig{})
+	tests := []struct {
+		name         string
+		certificates []tls.Certificate
+		code         codes.Code
+	}{
+		{"with client certificate", []tls.Certificate{clientCert}, codes.OK},
+		{"without client certificate", nil, codes.Unavailable},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &tls.Config{RootCAs: roots, ServerName: "coordinator", Certificates: test.certificates}
+			conn := newTestServer(t, c, []grpc.ServerOption{grpc.Creds(creds)},
+				grpc.WithTransportCredentials(credentials.NewTLS(config)))
+
+			_, err := NewCoordinatorClient(conn).Register(as("a"), &RegisterRequest{ModelId: "test"})
+			if status.Code(err) != test.code {
+				t.Fatalf("expected %v, got %v", test.code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// Package kubeauth authenticates federated learning clients with their Kubernetes ServiceAccount tokens.
+package kubeauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationV1 "k8s.io/api/authentication/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning"
+)
+
+const (
+	// Extra fields set by TokenReview for projected ServiceAccount tokens that are bound to a pod.
+	podNameExtra = "authentication.kubernetes.io/pod-name"
+	podUIDExtra  = "authentication.kubernetes.io/pod-uid"
+	// serviceAccountPrefix is the prefix of usernames of ServiceAccounts, followed by "<namespace>:<name>".
+	serviceAccountPrefix = "system:serviceaccount:"
+)
+
+const (
+	// CacheTTL is the time for which the result of a token review is reused, so that clients do not cause a
+	// TokenReview call to the API server for every request. Rejected tokens are remembered as well.
+	CacheTTL = 10 * time.Second
+	// maxCacheEntries bounds the number of remembered token reviews. Tokens are not cached while the cache is
+	// full of reviews that did not expire yet.
+	maxCacheEntries = 4096
+)
+
+// cachedReview is the remembered result of a token review.
+type cachedReview struct {
+	identity *federatedlearning.Identity
+	err      error
+	expires  time.Time
+}
+
+// tokenReviewAuthenticator authenticates clients by reviewing the bearer token of a request with the API
+// server.
+type tokenReviewAuthenticator struct {
+	client    kubernetes.Interface
+	audiences []string
+	now       func() time.Time
+
+	// reviews are the results of token reviews by SHA-256 hash of the token.
+	mu      sync.Mutex
+	reviews map[[sha256.Size]byte]*cachedReview
+}
+
+// NewTokenReviewAuthenticator creates an authenticator that accepts projected ServiceAccount tokens bound
+// to a pod. Tokens have to be issued for one of the given audiences, or for the API server if none is given.
+// Results of token reviews are reused for CacheTTL.
+func NewTokenReviewAuthenticator(client kubernetes.Interface, audiences ...string) federatedlearning.Authenticator {
+	return &tokenReviewAuthenticator{client: client, audiences: audiences, now: time.Now,
+		reviews: make(map[[sha256.Size]byte]*cachedReview)}
+}
+
+// Authenticate implements Authenticator interface. See Authenticator for more information.
+func (a *tokenReviewAuthenticator) Authenticate(req *http.Request) (*federatedlearning.Identity, error) {
+	authHeader := req.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil, fmt.Errorf("%w: bearer token is required", federatedlearning.ErrUnauthenticated)
+	}
+
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+	key := sha256.Sum256([]byte(token))
+	if cached := a.cached(key); cached != nil {
+		if cached.err != nil {
+			return nil, cached.err
+		}
+		identity := *cached.identity
+		return &identity, nil
+	}
+
+	identity, err := a.review(req.Context(), token)
+	if err != nil && !errors.Is(err, federatedlearning.ErrUnauthenticated) {
+		return nil, err
+	}
+
+	result := &cachedReview{err: err, expires: a.now().Add(CacheTTL)}
+	if identity != nil {
+		cached := *identity
+		result.identity = &cached
+	}
+	a.remember(key, result)
+	return identity, err
+}
+
+// cached returns the remembered review of a token, or nil if it was not reviewed recently.
+func (a *tokenReviewAuthenticator) cached(key [sha256.Size]byte) *cachedReview {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	cached, exists := a.reviews[key]
+	if !exists || !a.now().Before(cached.expires) {
+		return nil
+	}
+
+	return cached
+}
+
+// remember remembers the review of a token, unless the cache is full.
+func (a *tokenReviewAuthenticator) remember(key [sha256.Size]byte, result *cachedReview) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.reviews) >= maxCacheEntries {
+		now := a.now()
+		for k, cached := range a.reviews {
+			if !now.Before(cached.expires) {
+				delete(a.reviews, k)
+			}
+		}
+	}
+	if len(a.reviews) < maxCacheEntries {
+		a.reviews[key] = result
+	}
+}
+
+// review reviews a token with the API server. Errors of the API server do not wrap ErrUnauthenticated.
+func (a *tokenReviewAuthenticator) review(ctx context.Context, token string) (*federatedlearning.Identity,
+	error) {
+	review, err := a.client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationV1.TokenReview{
+		Spec: authenticationV1.TokenReviewSpec{Token: token, Audiences: a.audiences},
+	}, metaV1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to review token: %v", err)
+	}
+
+	status := review.Status
+	if !status.Authenticated {
+		return nil, fmt.Errorf("%w: %s", federatedlearning.ErrUnauthenticated, status.Error)
+	}
+
+	// API servers that are not aware of audiences authenticate tokens of any audience and return none.
+	if len(a.audiences) > 0 && !intersects(a.audiences, status.Audiences) {
+		return nil, fmt.Errorf("%w: token is not issued for audiences %v", federatedlearning.ErrUnauthenticated,
+			a.audiences)
+	}
+
+	username := status.User.Username
+	namespace, serviceAccount, found := strings.Cut(strings.TrimPrefix(username, serviceAccountPrefix), ":")
+	if !strings.HasPrefix(username, serviceAccountPrefix) || !found {
+		return nil, fmt.Errorf("%w: %s is not a ServiceAccount", federatedlearning.ErrUnauthenticated, username)
+	}
+
+	podName, podUID := extra(status.User, podNameExtra), extra(status.User, podUIDExtra)
+	if podName == "" || podUID == "" {
+		return nil, fmt.Errorf("%w: token of %s is not bound to a pod", federatedlearning.ErrUnauthenticated,
+			username)
+	}
+
+	return &federatedlearning.Identity{
+		ClientID:       federatedlearning.PodClientID(namespace, podName),
+		Namespace:      namespace,
+		PodName:        podName,
+		PodUID:         podUID,
+		ServiceAccount: serviceAccount,
+	}, nil
+}
+
+// intersects returns true if both lists have a common value.
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// extra returns the first value of an extra field of a user.
+func extra(user authenticationV1.UserInfo, key string) string {
+	if values := user.Extra[key]; len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,175 @@
+package kubeauth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	authenticationV1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clientTesting "k8s.io/client-go/testing"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning"
+)
+
+func newTestAuthenticator(users map[string]authenticationV1.UserInfo) federatedlearning.Authenticator {
+	return newCountingTestAuthenticator(users, new(int))
+}
+
+// newCountingTestAuthenticator creates an authenticator that counts the token reviews it makes in reviews.
+func newCountingTestAuthenticator(users map[string]authenticationV1.UserInfo,
+	reviews *int) federatedlearning.Authenticator {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews",
+		func(action clientTesting.Action) (bool, runtime.Object, error) {
+			*reviews++
+			review := action.(clientTesting.CreateAction).GetObject().(*authenticationV1.TokenReview)
+			user, exists := users[review.Spec.Token]
+			review.Status = authenticationV1.TokenReviewStatus{Authenticated: exists, User: user,
+				Audiences: review.Spec.Audiences}
+			return true, review, nil
+		})
+
+	return NewTokenReviewAuthenticator(client, "federated-learning")
+}
+
+func TestTokenReviewAuthenticator(t *testing.T) {
+	authenticator := newTestAuthenticator(map[string]authenticationV1.UserInfo{
+		"pod-token": {
+			Username: "system:serviceaccount:ricxapp:rrm",
+			Extra: map[string]authenticationV1.ExtraValue{
+				podNameExtra: {"rrm-0"},
+				podUIDExtra:  {"1234"},
+			},
+		},
+		"legacy-token": {Username: "system:serviceaccount:ricxapp:rrm"},
+		"user-token":   {Username: "admin"},
+	})
+
+	cases := []struct {
+		info     string
+		header   string
+		expected *federatedlearning.Identity
+	}{
+		{"should authenticate pod token", "Bearer pod-token", &federatedlearning.Identity{ClientID: "ricxapp:rrm-0",
+			Namespace: "ricxapp", PodName: "rrm-0", PodUID: "1234", ServiceAccount: "rrm"}},
+		{"should reject token not bound to a pod", "Bearer legacy-token", nil},
+		{"should reject user token", "Bearer user-token", nil},
+		{"should reject invalid token", "Bearer invalid", nil},
+		{"should reject missing token", "", nil},
+	}
+
+	for _, tc := range cases {
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/fl/register", nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+
+		identity, err := authenticator.Authenticate(req)
+		if tc.expected == nil {
+			if !errors.Is(err, federatedlearning.ErrUnauthenticated) {
+				t.Errorf("%s: expected unauthenticated error, got %v", tc.info, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.info, err)
+		} else if *identity != *tc.expected {
+			t.Errorf("%s: expected %+v, got %+v", tc.info, tc.expected, identity)
+		}
+	}
+}
+
+func TestTokenReviewAudiences(t *testing.T) {
+	user := authenticationV1.UserInfo{
+		Username: "system:serviceaccount:ricxapp:rrm",
+		Extra: map[string]authenticationV1.ExtraValue{
+			podNameExtra: {"rrm-0"},
+			podUIDExtra:  {"1234"},
+		},
+	}
+
+	cases := []struct {
+		info      string
+		audiences []string
+		valid     bool
+	}{
+		{"should reject review of API server not aware of audiences", nil, false},
+		{"should reject token issued for other audiences", []string{"https://kubernetes.default.svc"}, false},
+		{"should accept token issued for one of the audiences", []string{"other", "federated-learning"}, true},
+	}
+
+	for _, tc := range cases {
+		client := fake.NewSimpleClientset()
+		client.PrependReactor("create", "tokenreviews",
+			func(action clientTesting.Action) (bool, runtime.Object, error) {
+				review := action.(clientTesting.CreateAction).GetObject().(*authenticationV1.TokenReview)
+				review.Status = authenticationV1.TokenReviewStatus{Authenticated: true, User: user,
+					Audiences: tc.audiences}
+				return true, review, nil
+			})
+
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/fl/register", nil)
+		req.Header.Set("Authorization", "Bearer pod-token")
+		_, err := NewTokenReviewAuthenticator(client, "federated-learning").Authenticate(req)
+		if tc.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.info, err)
+		}
+		if !tc.valid && !errors.Is(err, federatedlearning.ErrUnauthenticated) {
+			t.Errorf("%s: expected unauthenticated error, got %v", tc.info, err)
+		}
+	}
+}
+
+func TestTokenReviewCache(t *testing.T) {
+	reviews := 0
+	authenticator := newCountingTestAuthenticator(map[string]authenticationV1.UserInfo{
+		"pod-token": {
+			Username: "system:serviceaccount:ricxapp:rrm",
+			Extra: map[string]authenticationV1.ExtraValue{
+				podNameExtra: {"rrm-0"},
+				podUIDExtra:  {"1234"},
+			},
+		},
+	}, &reviews)
+	now := time.Now()
+	authenticator.(*tokenReviewAuthenticator).now = func() time.Time { return now }
+
+	authenticate := func(token string) error {
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/fl/register", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		identity, err := authenticator.Authenticate(req)
+		if err != nil {
+			return err
+		}
+		if identity.ClientID != "ricxapp:rrm-0" {
+			t.Fatalf("expected client ricxapp:rrm-0, got %q", identity.ClientID)
+		}
+		// Callers must not be able to change the cached identity.
+		identity.ClientID = "changed"
+		return nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := authenticate("pod-token"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := authenticate("invalid"); !errors.Is(err, federatedlearning.ErrUnauthenticated) {
+			t.Fatalf("expected unauthenticated error, got %v", err)
+		}
+	}
+	if reviews != 2 {
+		t.Fatalf("expected tokens to be reviewed once, got %d reviews", reviews)
+	}
+
+	now = now.Add(CacheTTL)
+	if err := authenticate("pod-token"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reviews != 3 {
+		t.Fatalf("expected token to be reviewed again once the review expired, got %d reviews", reviews)
+	}
+}
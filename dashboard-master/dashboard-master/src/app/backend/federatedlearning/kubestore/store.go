@@ -0,0 +1,146 @@
+// Package kubestore persists the state of the federated learning coordinator in Kubernetes objects.
+//
+// The state is kept in ConfigMaps only. It holds models, client registrations and updates, but no credentials:
+// clients authenticate with their service account tokens, which are reviewed and never stored, the signing key
+// is kept in a Secret of its own by the signing package, and secure aggregation shares live only in memory for
+// the duration of a round. Should the state ever carry keys, they belong in a Secret backed BlobStore.
+package kubestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning"
+)
+
+const (
+	// storeLabel marks objects created by the store so that they can be listed.
+	storeLabel = "dashboard.kubernetes.io/federated-learning-store"
+	// keyAnnotation holds the blob store key of an object. Keys can not be used as object names directly
+	// because they contain characters that are not allowed in names.
+	keyAnnotation = "dashboard.kubernetes.io/federated-learning-key"
+	// dataKey is the key under which the object data is stored.
+	dataKey = "data"
+	// namePrefix is the prefix of names of all objects created by the store.
+	namePrefix = "fl-"
+)
+
+// blobStore implements federatedlearning.BlobStore interface on top of ConfigMaps. Objects are limited to
+// 1MiB, so it is meant for metadata. Weights should be kept in a blob store backed by a volume.
+type blobStore struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+// NewConfigMapStore creates a blob store that keeps every object in a ConfigMap in the given namespace.
+func NewConfigMapStore(client kubernetes.Interface, namespace string) federatedlearning.BlobStore {
+	return &blobStore{client: client, namespace: namespace}
+}
+
+// Put implements BlobStore interface. See BlobStore for more information.
+func (s *blobStore) Put(key string, data []byte) error {
+	meta := metaV1.ObjectMeta{
+		Name:        objectName(key),
+		Namespace:   s.namespace,
+		Labels:      map[string]string{storeLabel: "true"},
+		Annotations: map[string]string{keyAnnotation: key},
+	}
+
+	err := s.update(context.TODO(), meta, data)
+	if errors.IsNotFound(err) {
+		err = s.create(context.TODO(), meta, data)
+	}
+
+	return err
+}
+
+// Get implements BlobStore interface. See BlobStore for more information.
+func (s *blobStore) Get(key string) ([]byte, error) {
+	data, err := s.get(context.TODO(), objectName(key))
+	if errors.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: %s", federatedlearning.ErrBlobNotFound, key)
+	}
+
+	return data, err
+}
+
+// Delete implements BlobStore interface. See BlobStore for more information.
+func (s *blobStore) Delete(key string) error {
+	err := s.delete(context.TODO(), objectName(key))
+	if errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// List implements BlobStore interface. See BlobStore for more information.
+func (s *blobStore) List(prefix string) ([]string, error) {
+	objects, err := s.list(context.TODO(), storeLabel+"=true")
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(objects))
+	for _, object := range objects {
+		if key := object.GetAnnotations()[keyAnnotation]; strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// objectName returns a valid object name derived from a blob store key.
+func objectName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return namePrefix + hex.EncodeToString(sum[:20])
+}
+
+func (s *blobStore) get(ctx context.Context, name string) ([]byte, error) {
+	configMap, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, name, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return configMap.BinaryData[dataKey], nil
+}
+
+func (s *blobStore) create(ctx context.Context, meta metaV1.ObjectMeta, data []byte) error {
+	configMap := &v1.ConfigMap{ObjectMeta: meta, BinaryData: map[string][]byte{dataKey: data}}
+	_, err := s.client.CoreV1().ConfigMaps(s.namespace).Create(ctx, configMap, metaV1.CreateOptions{})
+	return err
+}
+
+func (s *blobStore) update(ctx context.Context, meta metaV1.ObjectMeta, data []byte) error {
+	configMap := &v1.ConfigMap{ObjectMeta: meta, BinaryData: map[string][]byte{dataKey: data}}
+	_, err := s.client.CoreV1().ConfigMaps(s.namespace).Update(ctx, configMap, metaV1.UpdateOptions{})
+	return err
+}
+
+func (s *blobStore) delete(ctx context.Context, name string) error {
+	return s.client.CoreV1().ConfigMaps(s.namespace).Delete(ctx, name, metaV1.DeleteOptions{})
+}
+
+func (s *blobStore) list(ctx context.Context, selector string) ([]v1.ConfigMap, error) {
+	list, err := s.client.CoreV1().ConfigMaps(s.namespace).List(ctx, metaV1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+// NewStore creates a coordinator store that keeps metadata of models and clients in ConfigMaps of the
+// given namespace and weights in the given blob store, usually one backed by a persistent volume.
+func NewStore(client kubernetes.Interface, namespace string, weights federatedlearning.BlobStore) federatedlearning.Store {
+	return federatedlearning.NewStore(NewConfigMapStore(client, namespace), weights)
+}
@@ -0,0 +1,44 @@
+package kubestore
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning"
+)
+
+func TestBlobStore(t *testing.T) {
+	store := NewConfigMapStore(fake.NewSimpleClientset(), "default")
+	if err := store.Put("models/rrm-power-control", []byte("v1")); err != nil {
+		t.Fatalf("Put(): unexpected error: %v", err)
+	}
+	if err := store.Put("models/rrm-power-control", []byte("v2")); err != nil {
+		t.Fatalf("Put(): unexpected error on overwrite: %v", err)
+	}
+	if err := store.Put("clients/10.0.0.1:5000", []byte("client")); err != nil {
+		t.Fatalf("Put(): unexpected error: %v", err)
+	}
+
+	data, err := store.Get("models/rrm-power-control")
+	if err != nil || string(data) != "v2" {
+		t.Errorf("expected v2, got %q, %v", data, err)
+	}
+
+	keys, err := store.List("models/")
+	if err != nil || !reflect.DeepEqual(keys, []string{"models/rrm-power-control"}) {
+		t.Errorf("expected single model key, got %v, %v", keys, err)
+	}
+
+	if err := store.Delete("models/rrm-power-control"); err != nil {
+		t.Errorf("Delete(): unexpected error: %v", err)
+	}
+	if err := store.Delete("models/rrm-power-control"); err != nil {
+		t.Errorf("Delete(): expected deleting missing key to succeed, got %v", err)
+	}
+	if _, err := store.Get("models/rrm-power-control"); !errors.Is(err, federatedlearning.ErrBlobNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
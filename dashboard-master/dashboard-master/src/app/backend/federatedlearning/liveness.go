@@ -0,0 +1,171 @@
+package federatedlearning
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// DefaultClientTimeout is the time after which a client that did not send a heartbeat, fetch a model or
+// submit an update is marked offline.
+const DefaultClientTimeout = 2 * time.Minute
+
+// SetClientTimeout sets the time after which clients that were not seen are marked offline.
+func (c *Coordinator) SetClientTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clientTimeout = timeout
+}
+
+// Heartbeat records that a client is alive. Offline clients become available again.
+func (c *Coordinator) Heartbeat(clientID string) (*Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	client, exists := c.clients[clientID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotRegistered, clientID)
+	}
+
+	status := client.Status
+	if status == ClientOffline {
+		status = ClientAvailable
+	}
+
+	c.touchClient(clientID, status)
+	return client.copy(), nil
+}
+
+// FetchModel returns the latest global model to a client that is going to train it, and marks the client
+// as training.
+func (c *Coordinator) FetchModel(modelID, clientID string) (*GlobalModel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	client, exists := c.clients[clientID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotRegistered, clientID)
+	}
+
+	model, exists := c.models[modelID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, modelID)
+	}
+
+	if client.ModelID != modelID {
+		return nil, fmt.Errorf("%w: client %s is enrolled in model %s, not %s", ErrClientNotEnrolled, clientID,
+			client.ModelID, modelID)
+	}
+
+	// Canaries serve the candidate of the model, training pauses until the candidate was judged. The other
+	// clients evaluate the current version until the candidate has a baseline.
+	if candidate, exists := c.candidates[modelID]; exists {
+		c.touchClient(clientID, client.Status)
+		if !candidate.isCanary(clientID) {
+			served := c.published(model)
+			if c.needsBaseline(modelID, clientID) {
+				served.Promotion = PromotionBaseline
+			}
+			return served, nil
+		}
+
+		version, err := c.getVersion(modelID, candidate.Version)
+		if err != nil {
+			return nil, err
+		}
+		return c.published(version), nil
+	}
+
+	if round, exists := c.rounds[modelID]; exists {
+		if err := c.checkSelected(round, clientID); err != nil {
+			return nil, err
+		}
+	}
+
+	c.touchClient(clientID, ClientTraining)
+	return c.published(model), nil
+}
+
+// GetClient returns a registered client.
+func (c *Coordinator) GetClient(clientID string) (*Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	client, exists := c.clients[clientID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotRegistered, clientID)
+	}
+
+	return client.copy(), nil
+}
+
+// ListClients returns all registered clients, sorted by ID.
+func (c *Coordinator) ListClients() []*Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clients := make([]*Client, 0, len(c.clients))
+	for _, client := range c.clients {
+		clients = append(clients, client.copy())
+	}
+
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+	return clients
+}
+
+// checkClients marks clients that were not seen for longer than the client timeout offline.
+func (c *Coordinator) checkClients() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.clientTimeout <= 0 {
+		return
+	}
+
+	now := c.now()
+	for id, client := range c.clients {
+		if client.Status != ClientOffline && now.Sub(client.LastSeen) > c.clientTimeout {
+			log.Printf("Federated learning client %s was not seen since %s, marking it offline", id,
+				client.LastSeen.Format(time.RFC3339))
+			c.setClientStatus(client, ClientOffline)
+			c.emit(Event{Type: EventClientOffline, ModelID: client.ModelID, ClientID: id})
+		}
+	}
+}
+
+// touchClient records that a client was seen and sets its status. Must be called with the lock held.
+func (c *Coordinator) touchClient(clientID string, status string) {
+	client := c.clients[clientID]
+	client.LastSeen = c.now()
+	c.setClientStatus(client, status)
+}
+
+// setClientStatus sets the status of a client. Status changes are persisted, while LastSeen alone is not to
+// avoid writing to the store on every heartbeat. Must be called with the lock held.
+func (c *Coordinator) setClientStatus(client *Client, status string) {
+	if client.Status == status {
+		return
+	}
+
+	client.Status = status
+	c.persistClient(client)
+}
+
+// persistClient persists a client, failures are logged. Must be called with the lock held.
+func (c *Coordinator) persistClient(client *Client) {
+	if c.store == nil {
+		return
+	}
+
+	if err := c.store.SaveClient(client); err != nil {
+		log.Printf("Failed to persist federated learning client %s: %v", client.ID, err)
+	}
+}
+
+// copy returns a copy of the client that can be handed out without holding the coordinator lock.
+func (cl *Client) copy() *Client {
+	result := *cl
+	return &result
+}
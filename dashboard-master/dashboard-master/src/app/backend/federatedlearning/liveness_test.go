@@ -0,0 +1,121 @@
+package federatedlearning
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClientLiveness(t *testing.T) {
+	now := time.Now()
+	c := newTestCoordinator(t, 2)
+	c.now = func() time.Time { return now }
+	c.SetClientTimeout(time.Minute)
+	for _, id := range []string{"a", "b"} {
+		if _, err := c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"}); err != nil {
+			t.Fatalf("RegisterClient(%s): unexpected error: %v", id, err)
+		}
+	}
+
+	expectStatus := func(info, id, expected string) {
+		client, err := c.GetClient(id)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", info, err)
+		}
+		if client.Status != expected {
+			t.Errorf("%s: expected client %s to be %s, got %s", info, id, expected, client.Status)
+		}
+	}
+
+	if _, err := c.FetchModel("test", "a"); err != nil {
+		t.Fatalf("FetchModel(): unexpected error: %v", err)
+	}
+	expectStatus("after fetching model", "a", ClientTraining)
+
+	now = now.Add(45 * time.Second)
+	if err := submitTestUpdate(c, "a", 1); err != nil {
+		t.Fatalf("SubmitModelUpdate(): unexpected error: %v", err)
+	}
+	expectStatus("after submitting update", "a", ClientAvailable)
+
+	now = now.Add(30 * time.Second)
+	c.checkClients()
+	expectStatus("within timeout", "a", ClientAvailable)
+	expectStatus("after timeout", "b", ClientOffline)
+
+	client, err := c.Heartbeat("b")
+	if err != nil {
+		t.Fatalf("Heartbeat(): unexpected error: %v", err)
+	}
+	if client.Status != ClientAvailable || !client.LastSeen.Equal(now) {
+		t.Errorf("expected heartbeat to make client available at %s, got %+v", now, client)
+	}
+
+	if _, err := c.Heartbeat("x"); !errors.Is(err, ErrClientNotRegistered) {
+		t.Errorf("expected heartbeat of unregistered client to fail, got %v", err)
+	}
+}
+
+func TestClientLivenessPersisted(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore(): unexpected error: %v", err)
+	}
+
+	c := newTestPersistentCoordinator(t, store)
+	if _, err := c.CreateModel(&ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0})}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	if _, err := c.RegisterClient(&Identity{ClientID: "a"}, &ClientRegistration{ModelID: "test"}); err != nil {
+		t.Fatalf("RegisterClient(): unexpected error: %v", err)
+	}
+
+	c.SetClientTimeout(time.Minute)
+	now := time.Now().Add(time.Hour)
+	c.now = func() time.Time { return now }
+	c.checkClients()
+
+	restored, err := NewPersistentCoordinator(store)
+	if err != nil {
+		t.Fatalf("NewPersistentCoordinator(): unexpected error: %v", err)
+	}
+	if client, _ := restored.GetClient("a"); client == nil || client.Status != ClientOffline {
+		t.Errorf("expected offline status to be persisted, got %+v", client)
+	}
+}
+
+func TestClientsAPI(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a", "b")
+	server := newTestAPIServer(c)
+	defer server.Close()
+
+	model := new(GlobalModel)
+	url := fmt.Sprintf("%s/api/v1/fl/model/test?clientId=a", server.URL)
+	if status := doTestClientRequest(t, "a", http.MethodGet, url, nil, model); status != http.StatusOK {
+		t.Fatalf("expected model to be returned, got %d", status)
+	}
+
+	var clients []*Client
+	if status := doTestRequest(t, http.MethodGet, server.URL+"/api/v1/fl/clients", nil, &clients); status != http.StatusOK {
+		t.Fatalf("expected clients to be listed, got %d", status)
+	}
+	if len(clients) != 2 || clients[0].Status != ClientTraining || clients[1].Status != ClientAvailable {
+		t.Errorf("expected training client a and available client b, got %+v, %+v", clients[0], clients[1])
+	}
+
+	client := new(Client)
+	url = server.URL + "/api/v1/fl/clients/b/heartbeat"
+	if status := doTestClientRequest(t, "b", http.MethodPost, url, nil, client); status != http.StatusOK || client.ID != "b" {
+		t.Errorf("expected heartbeat to return client b, got %d %+v", status, client)
+	}
+	if status := doTestClientRequest(t, "a", http.MethodPost, url, nil, nil); status != http.StatusForbidden {
+		t.Errorf("expected heartbeat on behalf of another client to be forbidden, got %d", status)
+	}
+
+	url = server.URL + "/api/v1/fl/clients/x/heartbeat"
+	if status := doTestClientRequest(t, "x", http.MethodPost, url, nil, nil); status != http.StatusForbidden {
+		t.Errorf("expected heartbeat of unregistered client to be forbidden, got %d", status)
+	}
+}
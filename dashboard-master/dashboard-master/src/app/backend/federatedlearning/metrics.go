@@ -0,0 +1,178 @@
+package federatedlearning
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons of rejected updates reported by the rejected updates metric.
+var rejectionReasons = []struct {
+	err    error
+	reason string
+}{
+	{ErrModelNotFound, "model_not_found"},
+	{ErrClientNotRegistered, "client_not_registered"},
+	{ErrClientNotEnrolled, "client_not_enrolled"},
+	{ErrNotSelected, "not_selected"},
+	{ErrRoundNotOpen, "round_not_open"},
+	{ErrStaleUpdate, "stale"},
+	{ErrDuplicateUpdate, "duplicate"},
+	{ErrInvalidUpdate, "invalid"},
+	{ErrPrivacyBudgetExhausted, "privacy_budget_exhausted"},
+	{ErrUpdateTooLarge, "too_large"},
+	{ErrQuotaExceeded, "quota_exceeded"},
+}
+
+var (
+	clientsDesc = prometheus.NewDesc("federated_learning_clients",
+		"Number of clients registered with a model.", []string{"model"}, nil)
+	activeClientsDesc = prometheus.NewDesc("federated_learning_active_clients",
+		"Number of clients registered with a model that are not offline.", []string{"model"}, nil)
+	pendingUpdatesDesc = prometheus.NewDesc("federated_learning_pending_updates",
+		"Number of updates waiting to be aggregated into the next version of a model.", []string{"model"}, nil)
+	modelVersionDesc = prometheus.NewDesc("federated_learning_model_version",
+		"Current version of a model.", []string{"model"}, nil)
+	lastVersionAgeDesc = prometheus.NewDesc("federated_learning_seconds_since_last_version",
+		"Time in seconds since the latest version of a model was created.", []string{"model"}, nil)
+)
+
+// metrics holds the metrics the coordinator updates as requests arrive. Metrics derived from the state of
+// the coordinator are computed when they are collected.
+type metrics struct {
+	updateSize      *prometheus.HistogramVec
+	rejectedUpdates *prometheus.CounterVec
+	evictedUpdates  *prometheus.CounterVec
+	rateLimited     prometheus.Counter
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		updateSize: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "federated_learning_update_size_bytes",
+				Help: "Size of accepted model updates as they were received, in bytes.",
+				// Use buckets ranging from 1 KiB to 256 MiB.
+				Buckets: prometheus.ExponentialBuckets(1024, 4, 10),
+			},
+			[]string{"model"},
+		),
+		rejectedUpdates: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "federated_learning_rejected_updates_total",
+				Help: "Counter of rejected model updates broken out for each model and reason.",
+			},
+			[]string{"model", "reason"},
+		),
+		evictedUpdates: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "federated_learning_evicted_updates_total",
+				Help: "Counter of pending model updates evicted to make room for updates based on newer versions.",
+			},
+			[]string{"model"},
+		),
+		rateLimited: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "federated_learning_rate_limited_requests_total",
+				Help: "Counter of client requests rejected because the client exceeded its request rate.",
+			},
+		),
+	}
+}
+
+// Collector returns a Prometheus collector exporting metrics of the coordinator.
+func (c *Coordinator) Collector() prometheus.Collector {
+	return coordinatorCollector{c}
+}
+
+// coordinatorCollector implements prometheus.Collector for a coordinator.
+type coordinatorCollector struct {
+	c *Coordinator
+}
+
+// Describe implements prometheus.Collector interface.
+func (cc coordinatorCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{clientsDesc, activeClientsDesc, pendingUpdatesDesc, modelVersionDesc,
+		lastVersionAgeDesc} {
+		ch <- desc
+	}
+	cc.c.metrics.updateSize.Describe(ch)
+	cc.c.metrics.rejectedUpdates.Describe(ch)
+	cc.c.metrics.evictedUpdates.Describe(ch)
+	cc.c.metrics.rateLimited.Describe(ch)
+}
+
+// Collect implements prometheus.Collector interface.
+func (cc coordinatorCollector) Collect(ch chan<- prometheus.Metric) {
+	c := cc.c
+	c.mu.Lock()
+	now := c.now()
+	clients := make(map[string]int, len(c.models))
+	active := make(map[string]int, len(c.models))
+	for _, client := range c.clients {
+		clients[client.ModelID]++
+		if client.Status != ClientOffline {
+			active[client.ModelID]++
+		}
+	}
+	for id, model := range c.models {
+		ch <- prometheus.MustNewConstMetric(clientsDesc, prometheus.GaugeValue, float64(clients[id]), id)
+		ch <- prometheus.MustNewConstMetric(activeClientsDesc, prometheus.GaugeValue, float64(active[id]), id)
+		ch <- prometheus.MustNewConstMetric(pendingUpdatesDesc, prometheus.GaugeValue,
+			float64(len(c.modelUpdates[id])), id)
+		ch <- prometheus.MustNewConstMetric(modelVersionDesc, prometheus.GaugeValue, float64(model.Version), id)
+		ch <- prometheus.MustNewConstMetric(lastVersionAgeDesc, prometheus.GaugeValue,
+			now.Sub(c.lastVersionTime(model)).Seconds(), id)
+	}
+	c.mu.Unlock()
+
+	c.metrics.updateSize.Collect(ch)
+	c.metrics.rejectedUpdates.Collect(ch)
+	c.metrics.evictedUpdates.Collect(ch)
+	c.metrics.rateLimited.Collect(ch)
+}
+
+// observeRejection counts a rejected update. Updates of unknown models are counted without a model, so that
+// callers cannot create arbitrary series. Must be called with the lock held.
+func (c *Coordinator) observeRejection(update *ModelUpdate, err error) {
+	model := update.ModelID
+	if _, exists := c.models[model]; !exists {
+		model = ""
+	}
+
+	c.metrics.rejectedUpdates.WithLabelValues(model, rejectionReason(err)).Inc()
+}
+
+// observeRejectedRequest counts an update whose request was rejected before it reached the coordinator,
+// e.g. because it exceeded the update size quota of the model.
+func (c *Coordinator) observeRejectedRequest(modelID string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.observeRejection(&ModelUpdate{ModelID: modelID}, err)
+}
+
+// lastVersionTime returns the time the latest version of a model was created. The current version is older
+// after a rollback. Must be called with the lock held.
+func (c *Coordinator) lastVersionTime(model *GlobalModel) time.Time {
+	latest := model.CreatedAt
+	for _, v := range c.versions[model.ID] {
+		if v.CreatedAt.After(latest) {
+			latest = v.CreatedAt
+		}
+	}
+
+	return latest
+}
+
+// rejectionReason returns the reason reported for an update rejected with err.
+func rejectionReason(err error) string {
+	for _, r := range rejectionReasons {
+		if errors.Is(err, r.err) {
+			return r.reason
+		}
+	}
+
+	return "error"
+}
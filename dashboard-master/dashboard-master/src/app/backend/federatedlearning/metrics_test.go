@@ -0,0 +1,91 @@
+package federatedlearning
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCoordinatorMetrics(t *testing.T) {
+	c := NewCoordinator()
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	spec := &ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}), Config: ModelConfig{Round: RoundConfig{MinParticipants: 2}}}
+	if _, err := c.CreateModel(spec); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"})
+	}
+
+	now = now.Add(90 * time.Second)
+	c.SetClientTimeout(time.Minute)
+	c.checkClients()
+	c.RegisterClient(&Identity{ClientID: "b"}, &ClientRegistration{ModelID: "test"})
+
+	c.SubmitModelUpdate(&ModelUpdate{ClientID: "b", ModelID: "test", BaseVersion: 1,
+		WeightUpdate: encodeWeights([]float32{1, 1})})
+	c.SubmitModelUpdate(&ModelUpdate{ClientID: "b", ModelID: "test", BaseVersion: 1,
+		WeightUpdate: encodeWeights([]float32{1, 1})})
+	c.SubmitModelUpdate(&ModelUpdate{ClientID: "a", ModelID: "test", BaseVersion: 2,
+		WeightUpdate: encodeWeights([]float32{1, 1})})
+	c.SubmitModelUpdate(&ModelUpdate{ClientID: "a", ModelID: "unknown"})
+
+	expected := `
+# HELP federated_learning_active_clients Number of clients registered with a model that are not offline.
+# TYPE federated_learning_active_clients gauge
+federated_learning_active_clients{model="test"} 1
+# HELP federated_learning_clients Number of clients registered with a model.
+# TYPE federated_learning_clients gauge
+federated_learning_clients{model="test"} 3
+# HELP federated_learning_model_version Current version of a model.
+# TYPE federated_learning_model_version gauge
+federated_learning_model_version{model="test"} 1
+# HELP federated_learning_pending_updates Number of updates waiting to be aggregated into the next version of a model.
+# TYPE federated_learning_pending_updates gauge
+federated_learning_pending_updates{model="test"} 1
+# HELP federated_learning_rejected_updates_total Counter of rejected model updates broken out for each model and reason.
+# TYPE federated_learning_rejected_updates_total counter
+federated_learning_rejected_updates_total{model="",reason="client_not_enrolled"} 1
+federated_learning_rejected_updates_total{model="test",reason="duplicate"} 1
+federated_learning_rejected_updates_total{model="test",reason="stale"} 1
+# HELP federated_learning_seconds_since_last_version Time in seconds since the latest version of a model was created.
+# TYPE federated_learning_seconds_since_last_version gauge
+federated_learning_seconds_since_last_version{model="test"} 90
+`
+	err := testutil.CollectAndCompare(c.Collector(), strings.NewReader(expected), "federated_learning_active_clients",
+		"federated_learning_clients", "federated_learning_model_version", "federated_learning_pending_updates",
+		"federated_learning_rejected_updates_total", "federated_learning_seconds_since_last_version")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count := testutil.CollectAndCount(c.Collector(), "federated_learning_update_size_bytes"); count != 1 {
+		t.Errorf("expected update sizes of a single model, got %d series", count)
+	}
+}
+
+func TestLastVersionMetricAfterRollback(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a", "b")
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	trainTestRound(t, c, []float32{1, 1})
+
+	now = now.Add(30 * time.Second)
+	if _, err := c.RollbackModel("test", 1); err != nil {
+		t.Fatalf("RollbackModel(): unexpected error: %v", err)
+	}
+
+	expected := `
+# HELP federated_learning_seconds_since_last_version Time in seconds since the latest version of a model was created.
+# TYPE federated_learning_seconds_since_last_version gauge
+federated_learning_seconds_since_last_version{model="test"} 30
+`
+	err := testutil.CollectAndCompare(c.Collector(), strings.NewReader(expected),
+		"federated_learning_seconds_since_last_version")
+	if err != nil {
+		t.Error(err)
+	}
+}
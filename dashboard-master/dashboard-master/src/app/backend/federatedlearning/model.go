@@ -1,23 +1,90 @@
-
 package federatedlearning
 
-import "time"
+import (
+	"time"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/compress"
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/tensor"
+)
 
 // GlobalModel represents the master model managed by the coordinator.
 // The weights are stored as a byte slice to remain agnostic to the specific
 // ML framework (e.g., TensorFlow, PyTorch) used by the xApps.
 type GlobalModel struct {
-    ID          string    `json:"id"`           // Unique identifier for the model, e.g., "rrm-power-control"
-    Version     int       `json:"version"`      // Monotonically increasing version number
-    Weights     []byte    `json:"-"`            // The actual model weights (omitted from standard JSON responses)
-    CreatedAt   time.Time `json:"createdAt"`
-    Description string    `json:"description"`
+	ID            string    `json:"id"`                      // Unique identifier for the model, e.g., "rrm-power-control"
+	Version       int       `json:"version"`                 // Monotonically increasing version number
+	ParentVersion int       `json:"parentVersion,omitempty"` // The version this version was trained from
+	Weights       []byte    `json:"-"`                       // The actual model weights (omitted from standard JSON responses)
+	Digest        string    `json:"digest"`                  // SHA-256 digest of the weights, e.g., "sha256:<hex>"
+	Size          int       `json:"size"`                    // Size of the weights in bytes
+	CreatedAt     time.Time `json:"createdAt"`
+	Description   string    `json:"description"`
+	Discarded     []string  `json:"discarded,omitempty"` // Clients whose updates were discarded as outliers when aggregating this version
+	// Contributors are the sorted IDs of the clients whose updates were aggregated into this version.
+	Contributors []string `json:"contributors,omitempty"`
+	// Signature signs the provenance of this version. It is nil if the coordinator does not sign versions.
+	Signature *Signature `json:"signature,omitempty"`
+	// Privacy is the privacy budget spent by the model. It is only set on the current version of models
+	// trained with differential privacy.
+	Privacy *PrivacyBudget `json:"privacy,omitempty"`
+	// ProximalMu is the coefficient of the FedProx proximal term clients add to their local objective,
+	// mu/2 times the squared distance of their weights to this version. Zero means plain local training.
+	ProximalMu float64 `json:"proximalMu,omitempty"`
+	// Schema is the tensor layout of the weights. It is empty for weights encoded as a flat float32 vector.
+	// Updates have to use the same tensors in the same order with the same shapes, data types may differ.
+	Schema []tensor.Spec `json:"schema,omitempty"`
+	// Training summarizes the metrics clients reported with the updates aggregated into this version.
+	Training *MetricsSummary `json:"training,omitempty"`
+	// Evaluation summarizes the scores clients measured for this version on their held-out data.
+	Evaluation *MetricsSummary `json:"evaluation,omitempty"`
+	// Promotion is the state of versions trained under a promotion policy.
+	Promotion PromotionState `json:"promotion,omitempty"`
+	// UpstreamVersion is the version of the upstream model whose weights this version adopted.
+	UpstreamVersion int `json:"upstreamVersion,omitempty"`
+}
+
+// ModelSpec describes a model to be created by the coordinator.
+type ModelSpec struct {
+	ID          string      `json:"id"`
+	Description string      `json:"description"`
+	Weights     []byte      `json:"weights"` // Initial weights of the first version
+	Config      ModelConfig `json:"config"`
 }
 
 // ModelUpdate is sent by an xApp client to the coordinator after a local training round.
 type ModelUpdate struct {
-    ClientID     string `json:"clientId"`     // The unique ID of the xApp client (e.g., pod name)
-    ModelID      string `json:"modelId"`      // The ID of the model being updated
-    BaseVersion  int    `json:"baseVersion"`  // The version of the global model the update is based on
-    WeightUpdate []byte `json:"weightUpdate"` // The new weights or gradients from the client
+	ClientID     string `json:"clientId"`     // The unique ID of the xApp client (e.g., pod name)
+	ModelID      string `json:"modelId"`      // The ID of the model being updated
+	BaseVersion  int    `json:"baseVersion"`  // The version of the global model the update is based on
+	WeightUpdate []byte `json:"weightUpdate"` // The new weights or gradients from the client
+	NumSamples   int    `json:"numSamples"`   // The number of local samples the update was trained on
+	// Metrics describe how local training went, e.g. MetricLoss and MetricAccuracy on the local data.
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+}
+
+// ModelConfig holds the training configuration of a single model.
+type ModelConfig struct {
+	Aggregation AggregationConfig `json:"aggregation"`
+	Round       RoundConfig       `json:"round"`
+	Selection   SelectionConfig   `json:"selection"`
+	Privacy     PrivacyConfig     `json:"privacy"`
+	// SecureAggregation configures secure aggregation of client updates.
+	SecureAggregation SecureAggregationConfig `json:"secureAggregation"`
+	// Async configures asynchronous training. Rounds wait for all their clients if it is not enabled.
+	Async AsyncConfig `json:"async"`
+	// Optimizer configures the server optimizer applied to aggregated weights.
+	Optimizer OptimizerConfig `json:"optimizer"`
+	// ProximalMu is the FedProx proximal coefficient handed to clients with the model. It must not be
+	// negative.
+	ProximalMu float64 `json:"proximalMu,omitempty"`
+	// Compression is the compression of model updates offered to clients. Clients negotiate the
+	// compression they use at registration.
+	Compression compress.Options `json:"compression"`
+	// Promotion configures the promotion policy of new versions. Without it, new versions are served as
+	// soon as they are aggregated.
+	Promotion PromotionConfig `json:"promotion"`
+	// Upstream links the model to a model of an upstream coordinator for hierarchical training.
+	Upstream UpstreamConfig `json:"upstream"`
+	// Quota limits the updates clients submit to the model.
+	Quota QuotaConfig `json:"quota"`
 }
@@ -0,0 +1,339 @@
+package federatedlearning
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Names of the built-in server optimizers (Reddi et al., "Adaptive Federated Optimization"). They treat the
+// difference between the aggregated weights and the current version as a pseudo-gradient and apply it with
+// momentum or with per-weight adaptive learning rates.
+const (
+	// FedAvgM applies the pseudo-gradient with heavy-ball momentum.
+	FedAvgM = "fedavgm"
+	// FedAdam scales the pseudo-gradient with Adam's first and second moment estimates.
+	FedAdam = "fedadam"
+	// FedYogi is FedAdam with Yogi's additive second moment update, which grows more slowly.
+	FedYogi = "fedyogi"
+)
+
+// Defaults of the server optimizer configuration.
+const (
+	// DefaultMomentumLearningRate is the learning rate of FedAvgM.
+	DefaultMomentumLearningRate = 1.0
+	// DefaultAdaptiveLearningRate is the learning rate of FedAdam, FedYogi and other optimizers.
+	DefaultAdaptiveLearningRate = 0.1
+	// DefaultServerMomentum is the decay of the first moment.
+	DefaultServerMomentum = 0.9
+	// DefaultSecondMomentDecay is the decay of the second moment of adaptive optimizers.
+	DefaultSecondMomentDecay = 0.99
+	// DefaultAdaptivity is the constant added to the root of the second moment of adaptive optimizers.
+	DefaultAdaptivity = 1e-3
+)
+
+// OptimizerConfig configures the server optimizer of a model, which is applied to the aggregated weights of
+// every round. The state of the optimizer is persisted with the model and reset on rollbacks.
+type OptimizerConfig struct {
+	// Algorithm is the name of a registered server optimizer. Empty makes the aggregated weights the next
+	// version.
+	Algorithm string `json:"algorithm,omitempty"`
+	// LearningRate scales the pseudo-gradient. Defaults to DefaultMomentumLearningRate for FedAvgM and to
+	// DefaultAdaptiveLearningRate otherwise.
+	LearningRate float64 `json:"learningRate,omitempty"`
+	// Momentum is the decay of the first moment. Defaults to DefaultServerMomentum.
+	Momentum float64 `json:"momentum,omitempty"`
+	// SecondMomentDecay is the decay of the second moment. Defaults to DefaultSecondMomentDecay.
+	SecondMomentDecay float64 `json:"secondMomentDecay,omitempty"`
+	// Adaptivity controls how adaptive the learning rates are, smaller values adapt more. Defaults to
+	// DefaultAdaptivity.
+	Adaptivity float64 `json:"adaptivity,omitempty"`
+}
+
+// enabled returns true if a server optimizer is configured.
+func (c OptimizerConfig) enabled() bool {
+	return c.Algorithm != ""
+}
+
+// learningRate returns the configured learning rate.
+func (c OptimizerConfig) learningRate() float64 {
+	if c.LearningRate > 0 {
+		return c.LearningRate
+	}
+	if c.Algorithm == FedAvgM {
+		return DefaultMomentumLearningRate
+	}
+
+	return DefaultAdaptiveLearningRate
+}
+
+// momentum returns the configured decay of the first moment.
+func (c OptimizerConfig) momentum() float64 {
+	if c.Momentum == 0 {
+		return DefaultServerMomentum
+	}
+
+	return c.Momentum
+}
+
+// secondMomentDecay returns the configured decay of the second moment.
+func (c OptimizerConfig) secondMomentDecay() float64 {
+	if c.SecondMomentDecay == 0 {
+		return DefaultSecondMomentDecay
+	}
+
+	return c.SecondMomentDecay
+}
+
+// adaptivity returns the configured adaptivity.
+func (c OptimizerConfig) adaptivity() float64 {
+	if c.Adaptivity == 0 {
+		return DefaultAdaptivity
+	}
+
+	return c.Adaptivity
+}
+
+// validate checks the optimizer configuration of a model.
+func (c OptimizerConfig) validate() error {
+	if !c.enabled() {
+		return nil
+	}
+
+	if c.LearningRate < 0 || c.Adaptivity < 0 {
+		return fmt.Errorf("learning rate and adaptivity must not be negative")
+	}
+	if c.Momentum < 0 || c.Momentum >= 1 || c.SecondMomentDecay < 0 || c.SecondMomentDecay >= 1 {
+		return fmt.Errorf("momentum and second moment decay must be at least 0 and less than 1")
+	}
+
+	_, err := NewOptimizer(c)
+	return err
+}
+
+// Moments are the moment estimates of a server optimizer, one value per weight.
+type Moments struct {
+	First  []float64
+	Second []float64
+}
+
+// ServerOptimizer turns the aggregated weights of a round into the next version of a model.
+type ServerOptimizer interface {
+	// Step applies a pseudo-gradient, the aggregated weights minus the current weights, to the current
+	// weights and returns the weights of the next version. Moments hold the estimates of earlier steps, they
+	// are zero in the first step, and are updated in place.
+	Step(weights, gradient []float64, moments *Moments) []float64
+}
+
+// OptimizerFactory creates a server optimizer from the optimizer configuration of a model.
+type OptimizerFactory func(config OptimizerConfig) (ServerOptimizer, error)
+
+var (
+	optimizersMu sync.RWMutex
+	optimizers   = map[string]OptimizerFactory{
+		FedAvgM: newMomentumOptimizer,
+		FedAdam: func(config OptimizerConfig) (ServerOptimizer, error) { return newAdaptiveOptimizer(config, false), nil },
+		FedYogi: func(config OptimizerConfig) (ServerOptimizer, error) { return newAdaptiveOptimizer(config, true), nil },
+	}
+)
+
+// RegisterOptimizer makes a server optimizer available under the given name. Registering the same name
+// twice replaces the previous factory.
+func RegisterOptimizer(name string, factory OptimizerFactory) {
+	optimizersMu.Lock()
+	defer optimizersMu.Unlock()
+	optimizers[name] = factory
+}
+
+// Optimizers returns names of all registered server optimizers.
+func Optimizers() []string {
+	optimizersMu.RLock()
+	defer optimizersMu.RUnlock()
+
+	names := make([]string, 0, len(optimizers))
+	for name := range optimizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewOptimizer creates the server optimizer described by config.
+func NewOptimizer(config OptimizerConfig) (ServerOptimizer, error) {
+	optimizersMu.RLock()
+	factory, exists := optimizers[config.Algorithm]
+	optimizersMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown server optimizer: %s", config.Algorithm)
+	}
+
+	return factory(config)
+}
+
+// momentumOptimizer implements FedAvgM.
+type momentumOptimizer struct {
+	learningRate float64
+	momentum     float64
+}
+
+func newMomentumOptimizer(config OptimizerConfig) (ServerOptimizer, error) {
+	return &momentumOptimizer{learningRate: config.learningRate(), momentum: config.momentum()}, nil
+}
+
+// Step implements ServerOptimizer interface. See ServerOptimizer for more information.
+func (o *momentumOptimizer) Step(weights, gradient []float64, moments *Moments) []float64 {
+	result := make([]float64, len(weights))
+	for i, g := range gradient {
+		moments.First[i] = o.momentum*moments.First[i] + g
+		result[i] = weights[i] + o.learningRate*moments.First[i]
+	}
+
+	return result
+}
+
+// adaptiveOptimizer implements FedAdam and FedYogi.
+type adaptiveOptimizer struct {
+	learningRate float64
+	beta1        float64
+	beta2        float64
+	tau          float64
+	yogi         bool
+}
+
+func newAdaptiveOptimizer(config OptimizerConfig, yogi bool) *adaptiveOptimizer {
+	return &adaptiveOptimizer{learningRate: config.learningRate(), beta1: config.momentum(),
+		beta2: config.secondMomentDecay(), tau: config.adaptivity(), yogi: yogi}
+}
+
+// Step implements ServerOptimizer interface. See ServerOptimizer for more information.
+func (o *adaptiveOptimizer) Step(weights, gradient []float64, moments *Moments) []float64 {
+	result := make([]float64, len(weights))
+	for i, g := range gradient {
+		squared := g * g
+		moments.First[i] = o.beta1*moments.First[i] + (1-o.beta1)*g
+		if o.yogi {
+			moments.Second[i] -= (1 - o.beta2) * squared * sign(moments.Second[i]-squared)
+		} else {
+			moments.Second[i] = o.beta2*moments.Second[i] + (1-o.beta2)*squared
+		}
+		result[i] = weights[i] + o.learningRate*moments.First[i]/(math.Sqrt(moments.Second[i])+o.tau)
+	}
+
+	return result
+}
+
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// OptimizerState is the persisted state of the server optimizer of a model. The moment estimates are as large
+// as the weights, so stores persist them apart from the state of the model, like weights.
+type OptimizerState struct {
+	// Algorithm is the optimizer the state belongs to. The state is reset when the optimizer changes.
+	Algorithm string `json:"algorithm"`
+	// LearningRate is the learning rate of the last step.
+	LearningRate float64 `json:"learningRate"`
+	// Steps is the number of versions the optimizer created.
+	Steps int `json:"steps"`
+	// Version is the version created by the last step. Stores persist the moments under it.
+	Version int `json:"version"`
+	// FirstMoment and SecondMoment hold the moment estimates in the encoding of weights. They are persisted
+	// with SaveMoments.
+	FirstMoment  []byte `json:"-"`
+	SecondMoment []byte `json:"-"`
+}
+
+// copy returns a copy of the state that can be used without holding the coordinator lock. It is nil-safe.
+func (s *OptimizerState) copy() *OptimizerState {
+	if s == nil {
+		return nil
+	}
+
+	result := *s
+	return &result
+}
+
+// moments decodes the moment estimates of a state. They are zero if the state belongs to another
+// optimizer or to weights of another size.
+func (s *OptimizerState) moments(algorithm string, size int) *Moments {
+	moments := &Moments{First: make([]float64, size), Second: make([]float64, size)}
+	if s == nil || s.Algorithm != algorithm {
+		return moments
+	}
+
+	for _, m := range []struct {
+		data   []byte
+		target []float64
+	}{{s.FirstMoment, moments.First}, {s.SecondMoment, moments.Second}} {
+		values, err := decodeWeights(m.data)
+		if err != nil || len(values) != size {
+			return &Moments{First: make([]float64, size), Second: make([]float64, size)}
+		}
+		for i, v := range values {
+			m.target[i] = float64(v)
+		}
+	}
+
+	return moments
+}
+
+// optimize applies the server optimizer of a job to the aggregated weights and returns the weights of the
+// next version. The optimizer state of the job is replaced by the state after the step.
+func optimize(job *aggregationJob, aggregated []byte) ([]byte, error) {
+	config := job.config.Optimizer
+	optimizer, err := NewOptimizer(config)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := decodeWeights(job.model.Weights)
+	if err != nil {
+		return nil, err
+	}
+	next, err := decodeWeights(aggregated)
+	if err != nil {
+		return nil, err
+	}
+	if len(current) != len(next) {
+		return nil, fmt.Errorf("aggregated weights have length %d, model %s has %d", len(next), job.model.ID,
+			len(current))
+	}
+
+	weights := make([]float64, len(current))
+	gradient := make([]float64, len(current))
+	for i := range current {
+		weights[i] = float64(current[i])
+		gradient[i] = float64(next[i]) - float64(current[i])
+	}
+
+	moments := job.optimizer.moments(config.Algorithm, len(current))
+	result := optimizer.Step(weights, gradient, moments)
+
+	state := &OptimizerState{Algorithm: config.Algorithm, LearningRate: config.learningRate(),
+		FirstMoment: encodeVector(moments.First), SecondMoment: encodeVector(moments.Second)}
+	if job.optimizer != nil && job.optimizer.Algorithm == config.Algorithm {
+		state.Steps = job.optimizer.Steps
+	}
+	state.Steps++
+	job.optimizer = state
+
+	return encodeVector(result), nil
+}
+
+// encodeVector encodes float64 values in the encoding of weights.
+func encodeVector(values []float64) []byte {
+	weights := make([]float32, len(values))
+	for i, v := range values {
+		weights[i] = float32(v)
+	}
+
+	return encodeWeights(weights)
+}
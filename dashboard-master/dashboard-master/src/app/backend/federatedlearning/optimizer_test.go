@@ -0,0 +1,92 @@
+package federatedlearning
+
+import (
+	"math"
+	"testing"
+)
+
+func TestServerOptimizers(t *testing.T) {
+	// Two rounds whose aggregated weights move every weight by 1.
+	cases := []struct {
+		info     string
+		config   OptimizerConfig
+		expected []float64
+	}{
+		{"fedavgm should accumulate momentum", OptimizerConfig{Algorithm: FedAvgM, Momentum: 0.5},
+			[]float64{1, 2.5}},
+		{"fedadam should normalize steps", OptimizerConfig{Algorithm: FedAdam},
+			[]float64{0.0990099, 0.2327493}},
+		{"fedyogi should grow second moment additively", OptimizerConfig{Algorithm: FedYogi},
+			[]float64{0.0990099, 0.2324169}},
+	}
+
+	for _, tc := range cases {
+		optimizer, err := NewOptimizer(tc.config)
+		if err != nil {
+			t.Fatalf("%s: NewOptimizer(): unexpected error: %v", tc.info, err)
+		}
+
+		weights := []float64{0}
+		moments := &Moments{First: []float64{0}, Second: []float64{0}}
+		for step, expected := range tc.expected {
+			weights = optimizer.Step(weights, []float64{1}, moments)
+			if math.Abs(weights[0]-expected) > 1e-6 {
+				t.Errorf("%s: expected weight %g after step %d, got %g", tc.info, expected, step+1, weights[0])
+			}
+		}
+	}
+}
+
+func TestServerOptimizerState(t *testing.T) {
+	store, _ := NewFileStore(t.TempDir())
+	c := newTestPersistentCoordinator(t, store)
+	config := ModelConfig{
+		Round:      RoundConfig{MinParticipants: 2},
+		Optimizer:  OptimizerConfig{Algorithm: FedAvgM, Momentum: 0.5},
+		ProximalMu: 0.01,
+	}
+	if _, err := c.CreateModel(&ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}), Config: config}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"})
+	}
+
+	model := trainTestRound(t, c, []float32{1, 1})
+	if model.ProximalMu != 0.01 {
+		t.Errorf("expected proximal coefficient 0.01 with model, got %g", model.ProximalMu)
+	}
+
+	// The restored coordinator continues with the persisted momentum.
+	restored := newTestPersistentCoordinator(t, store)
+	model = trainTestRound(t, restored, []float32{2, 2})
+	weights, _ := decodeWeights(model.Weights)
+	if weights[0] != 2.5 {
+		t.Errorf("expected momentum to be restored and weight 2.5, got %v", weights)
+	}
+	if state := restored.optimizers["test"]; state == nil || state.Steps != 2 || state.LearningRate != 1 {
+		t.Errorf("expected optimizer state after 2 steps, got %+v", state)
+	}
+
+	if _, err := restored.RollbackModel("test", 2); err != nil {
+		t.Fatalf("RollbackModel(): unexpected error: %v", err)
+	}
+	if state := restored.optimizers["test"]; state != nil {
+		t.Errorf("expected optimizer state to be reset by rollback, got %+v", state)
+	}
+}
+
+func TestServerOptimizerConfigValidation(t *testing.T) {
+	invalid := []ModelConfig{
+		{Optimizer: OptimizerConfig{Algorithm: "unknown"}},
+		{Optimizer: OptimizerConfig{Algorithm: FedAdam, Momentum: 1}},
+		{Optimizer: OptimizerConfig{Algorithm: FedAvgM, LearningRate: -1}},
+		{ProximalMu: -0.1},
+	}
+
+	for _, config := range invalid {
+		if err := validateConfig(config); err == nil {
+			t.Errorf("expected error for config %+v", config)
+		}
+	}
+}
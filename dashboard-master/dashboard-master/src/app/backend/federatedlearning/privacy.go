@@ -0,0 +1,239 @@
+package federatedlearning
+
+import (
+	crand "crypto/rand"
+	"fmt"
+	"math"
+	"math/rand/v2"
+)
+
+// DefaultPrivacyDelta is the delta of the (epsilon, delta) privacy budget when a model does not configure it.
+const DefaultPrivacyDelta = 1e-5
+
+// PrivacyConfig configures differential privacy of a model. Every update is clipped to a maximum L2 norm
+// of its difference to the base version, Gaussian noise calibrated to that norm is added to the sum of the
+// clipped differences, and the sum is divided by the quorum of the round (McMahan et al., DP-FedAvg). The
+// divisor does not depend on how many updates arrived, so adding or removing the update of a single client
+// changes the sum by at most ClipNorm. The privacy loss of every released version is accounted with
+// zero-concentrated differential privacy (Bun and Steinke) with respect to adding or removing the update
+// of a single client, and reported as (epsilon, delta).
+type PrivacyConfig struct {
+	// ClipNorm is the maximum L2 norm of the difference of an update to its base version. Zero disables
+	// differential privacy.
+	ClipNorm float64 `json:"clipNorm"`
+	// NoiseMultiplier is the ratio of the standard deviation of the noise to the sensitivity of the sum,
+	// ClipNorm.
+	NoiseMultiplier float64 `json:"noiseMultiplier"`
+	// Epsilon is the privacy budget of the model. No new versions are created once releasing another one
+	// would exceed it. Zero means the privacy loss is accounted but not limited.
+	Epsilon float64 `json:"epsilon"`
+	// Delta of the privacy budget. Defaults to DefaultPrivacyDelta.
+	Delta float64 `json:"delta"`
+}
+
+// enabled returns true if updates of the model are privatized.
+func (c PrivacyConfig) enabled() bool {
+	return c.ClipNorm > 0
+}
+
+// delta returns the configured delta.
+func (c PrivacyConfig) delta() float64 {
+	if c.Delta == 0 {
+		return DefaultPrivacyDelta
+	}
+
+	return c.Delta
+}
+
+// validate checks the privacy configuration of a model.
+func (c PrivacyConfig) validate(aggregation AggregationConfig) error {
+	if !c.enabled() {
+		if c.ClipNorm < 0 {
+			return fmt.Errorf("clip norm must not be negative")
+		}
+		return nil
+	}
+
+	if c.NoiseMultiplier <= 0 {
+		return fmt.Errorf("noise multiplier must be positive when clip norm is set")
+	}
+	if c.Epsilon < 0 || c.Delta < 0 || c.Delta >= 1 {
+		return fmt.Errorf("privacy budget must not be negative and delta must be less than 1")
+	}
+
+	// Noise is calibrated to the sensitivity of the sum of the clipped updates, other combiners have a
+	// different sensitivity.
+	if aggregation.Algorithm != "" && aggregation.Algorithm != FedAvg {
+		return fmt.Errorf("differential privacy requires the %s aggregator, got %s", FedAvg, aggregation.Algorithm)
+	}
+
+	return nil
+}
+
+// PrivacyBudget is the privacy loss a model accumulated over all versions released with noise.
+type PrivacyBudget struct {
+	// Rho is the accumulated zero-concentrated differential privacy loss.
+	Rho float64 `json:"rho"`
+	// Epsilon is the spent budget at the configured delta.
+	Epsilon float64 `json:"epsilon"`
+	Delta   float64 `json:"delta"`
+	// Limit is the epsilon budget of the model, zero if it is not limited.
+	Limit float64 `json:"limit,omitempty"`
+	// Versions is the number of versions released with noise.
+	Versions int `json:"versions"`
+	// Exhausted is true if releasing another version would exceed the budget.
+	Exhausted bool `json:"exhausted"`
+}
+
+// roundRho returns the zCDP loss of releasing a single version with the given configuration.
+func (c PrivacyConfig) roundRho() float64 {
+	return 1 / (2 * c.NoiseMultiplier * c.NoiseMultiplier)
+}
+
+// zcdpEpsilon converts a zCDP loss to epsilon of (epsilon, delta) differential privacy.
+func zcdpEpsilon(rho, delta float64) float64 {
+	return rho + 2*math.Sqrt(rho*math.Log(1/delta))
+}
+
+// update recomputes the budget for the given configuration, which may have changed since the budget was
+// last spent.
+func (b *PrivacyBudget) update(config PrivacyConfig) {
+	b.Delta = config.delta()
+	b.Epsilon = zcdpEpsilon(b.Rho, b.Delta)
+	b.Limit = config.Epsilon
+	b.Exhausted = config.Epsilon > 0 && zcdpEpsilon(b.Rho+config.roundRho(), b.Delta) > config.Epsilon
+}
+
+// spend accounts the release of a version.
+func (b *PrivacyBudget) spend(config PrivacyConfig) {
+	b.Rho += config.roundRho()
+	b.Versions++
+	b.update(config)
+}
+
+// copy returns a copy of the budget that can be handed out without holding the coordinator lock.
+func (b *PrivacyBudget) copy() *PrivacyBudget {
+	result := *b
+	return &result
+}
+
+// clipUpdates returns copies of the updates whose difference to the weights of the base model is scaled
+// down to at most clipNorm. Sample counts are dropped, so that every client contributes equally to the
+// average and its weight does not reveal the size of its data set.
+func clipUpdates(model *GlobalModel, updates []*ModelUpdate, clipNorm float64) ([]*ModelUpdate, error) {
+	base, err := decodeWeights(model.Weights)
+	if err != nil {
+		return nil, fmt.Errorf("weights of model %s: %v", model.ID, err)
+	}
+
+	clipped := make([]*ModelUpdate, len(updates))
+	for i, update := range updates {
+		weights, err := decodeWeights(update.WeightUpdate)
+		if err != nil {
+			return nil, fmt.Errorf("update from client %s: %v", update.ClientID, err)
+		}
+		if len(weights) != len(base) {
+			return nil, fmt.Errorf("update from client %s has %d weights, expected %d", update.ClientID,
+				len(weights), len(base))
+		}
+
+		norm := math.Sqrt(squaredDistance(weights, base))
+		if norm > clipNorm {
+			scale := clipNorm / norm
+			for j := range weights {
+				weights[j] = base[j] + float32(float64(weights[j]-base[j])*scale)
+			}
+		}
+
+		clipped[i] = &ModelUpdate{ClientID: update.ClientID, ModelID: update.ModelID,
+			BaseVersion: update.BaseVersion, WeightUpdate: encodeWeights(weights)}
+	}
+
+	return clipped, nil
+}
+
+// newNoiseSource returns a random number generator seeded from the cryptographic random source, so that
+// the noise can not be predicted.
+func newNoiseSource() (*rand.Rand, error) {
+	var seed [32]byte
+	if _, err := crand.Read(seed[:]); err != nil {
+		return nil, fmt.Errorf("failed to seed noise: %v", err)
+	}
+
+	return rand.New(rand.NewChaCha8(seed)), nil
+}
+
+// privatize clips the updates of a job, adds noise to the sum of their differences to the base version and
+// applies the sum divided by the quorum of the round. Rounds with more participants than their quorum move
+// the model further than the average of their updates.
+func privatize(job *aggregationJob) (*Aggregation, error) {
+	config := job.config.Privacy
+	clipped, err := clipUpdates(job.model, job.updates, config.ClipNorm)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := decodeWeights(job.model.Weights)
+	if err != nil {
+		return nil, fmt.Errorf("weights of model %s: %v", job.model.ID, err)
+	}
+
+	sum := make([]float64, len(base))
+	for _, update := range clipped {
+		weights, err := decodeWeights(update.WeightUpdate)
+		if err != nil {
+			return nil, err
+		}
+		for i := range weights {
+			sum[i] += float64(weights[i] - base[i])
+		}
+	}
+
+	random, err := newNoiseSource()
+	if err != nil {
+		return nil, err
+	}
+
+	cohort := float64(max(job.round.MinParticipants, 1))
+	weights := make([]float32, len(base))
+	for i := range base {
+		noise := random.NormFloat64() * config.NoiseMultiplier * config.ClipNorm
+		weights[i] = base[i] + float32((sum[i]+noise)/cohort)
+	}
+
+	return &Aggregation{Weights: encodeWeights(weights)}, nil
+}
+
+// privacyBudget returns the privacy budget of a model, or nil if the model never used differential
+// privacy. Must be called with the lock held.
+func (c *Coordinator) privacyBudget(modelID string) *PrivacyBudget {
+	budget, exists := c.privacy[modelID]
+	config := c.configs[modelID].Privacy
+	if !exists {
+		if !config.enabled() {
+			return nil
+		}
+		budget = &PrivacyBudget{}
+		c.privacy[modelID] = budget
+	}
+
+	if config.enabled() {
+		budget.update(config)
+	}
+	return budget
+}
+
+// checkPrivacyBudget returns an error if the privacy budget of a model does not allow another version.
+// Must be called with the lock held.
+func (c *Coordinator) checkPrivacyBudget(modelID string) error {
+	if !c.configs[modelID].Privacy.enabled() {
+		return nil
+	}
+
+	if budget := c.privacyBudget(modelID); budget.Exhausted {
+		return fmt.Errorf("%w: model %s spent epsilon %.4g of %.4g", ErrPrivacyBudgetExhausted, modelID,
+			budget.Epsilon, budget.Limit)
+	}
+
+	return nil
+}
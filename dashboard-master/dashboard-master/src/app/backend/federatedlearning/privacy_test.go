@@ -0,0 +1,112 @@
+package federatedlearning
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestClipUpdates(t *testing.T) {
+	model := &GlobalModel{ID: "test", Weights: encodeWeights([]float32{1, 1})}
+	updates := []*ModelUpdate{
+		{ClientID: "a", WeightUpdate: encodeWeights([]float32{4, 5}), NumSamples: 100},
+		{ClientID: "b", WeightUpdate: encodeWeights([]float32{1.5, 1}), NumSamples: 1},
+	}
+
+	clipped, err := clipUpdates(model, updates, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := [][]float32{{1.6, 1.8}, {1.5, 1}}
+	for i, update := range clipped {
+		weights, _ := decodeWeights(update.WeightUpdate)
+		for j := range weights {
+			if math.Abs(float64(weights[j]-expected[i][j])) > 1e-6 {
+				t.Errorf("update %s: expected %v, got %v", update.ClientID, expected[i], weights)
+				break
+			}
+		}
+		if update.NumSamples != 0 {
+			t.Errorf("update %s: expected sample count to be dropped, got %d", update.ClientID, update.NumSamples)
+		}
+	}
+
+	if weights, _ := decodeWeights(updates[0].WeightUpdate); weights[0] != 4 {
+		t.Errorf("expected original update to be left unchanged, got %v", weights)
+	}
+}
+
+func TestPrivatize(t *testing.T) {
+	job := &aggregationJob{
+		round: &Round{MinParticipants: 4},
+		model: &GlobalModel{ID: "test", Weights: encodeWeights([]float32{1, 1})},
+		updates: []*ModelUpdate{
+			{ClientID: "a", WeightUpdate: encodeWeights([]float32{2, 2})},
+			{ClientID: "b", WeightUpdate: encodeWeights([]float32{3, 3})},
+		},
+		config: ModelConfig{Privacy: PrivacyConfig{ClipNorm: 10, NoiseMultiplier: 1e-9}},
+	}
+
+	// The sum of the changes is divided by the quorum, not by the number of updates that arrived.
+	aggregation, err := privatize(job)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	weights, _ := decodeWeights(aggregation.Weights)
+	for _, w := range weights {
+		if math.Abs(float64(w)-1.75) > 1e-6 {
+			t.Fatalf("expected weights [1.75 1.75], got %v", weights)
+		}
+	}
+}
+
+func TestPrivacyBudget(t *testing.T) {
+	store, _ := NewFileStore(t.TempDir())
+	c := newTestPersistentCoordinator(t, store)
+	// With a noise multiplier of 1 every version costs rho 0.5, two versions spend epsilon 7.79 at the
+	// default delta, and a third one would spend 9.81.
+	config := ModelConfig{
+		Round:   RoundConfig{MinParticipants: 2},
+		Privacy: PrivacyConfig{ClipNorm: 1, NoiseMultiplier: 1, Epsilon: 8},
+	}
+	if _, err := c.CreateModel(&ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}), Config: config}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"})
+	}
+
+	trainTestRound(t, c, []float32{1, 1})
+	model := trainTestRound(t, c, []float32{1, 1})
+	if model.Version != 3 || model.Privacy == nil || model.Privacy.Versions != 2 || !model.Privacy.Exhausted {
+		t.Fatalf("expected budget of 2 versions to be exhausted, got version %d with %+v", model.Version,
+			model.Privacy)
+	}
+	if math.Abs(model.Privacy.Epsilon-7.786) > 0.001 {
+		t.Errorf("expected spent epsilon 7.786, got %g", model.Privacy.Epsilon)
+	}
+
+	if err := submitTestUpdate(c, "a", 3); !errors.Is(err, ErrPrivacyBudgetExhausted) {
+		t.Errorf("expected update to be refused once budget is exhausted, got %v", err)
+	}
+
+	restored := newTestPersistentCoordinator(t, store)
+	if model, _ := restored.GetModel("test"); model.Privacy == nil || model.Privacy.Versions != 2 {
+		t.Errorf("expected restored privacy budget, got %+v", model.Privacy)
+	}
+}
+
+func TestPrivacyConfigValidation(t *testing.T) {
+	invalid := []ModelConfig{
+		{Privacy: PrivacyConfig{ClipNorm: 1}},
+		{Privacy: PrivacyConfig{ClipNorm: 1, NoiseMultiplier: 1, Delta: 1}},
+		{Privacy: PrivacyConfig{ClipNorm: 1, NoiseMultiplier: 1}, Aggregation: AggregationConfig{Algorithm: Median}},
+	}
+
+	for _, config := range invalid {
+		if err := validateConfig(config); err == nil {
+			t.Errorf("expected %+v to be rejected", config.Privacy)
+		}
+	}
+}
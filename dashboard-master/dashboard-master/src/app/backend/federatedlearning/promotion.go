@@ -0,0 +1,278 @@
+package federatedlearning
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// PromotionState is the state of a model version trained under a promotion policy.
+type PromotionState string
+
+const (
+	// PromotionCandidate is the state of a version that is served to canary clients and waits for their
+	// evaluations.
+	PromotionCandidate PromotionState = "candidate"
+	// PromotionPromoted is the state of a version that beat the version it was trained from.
+	PromotionPromoted PromotionState = "promoted"
+	// PromotionBaseline is the state of the current version as served to the clients that are not canaries
+	// while a candidate waits, asking them to evaluate it. Their evaluations are the baseline the candidate
+	// is compared with.
+	PromotionBaseline PromotionState = "baseline"
+	// PromotionRejected is the state of a version that did not beat the version it was trained from, or was
+	// not evaluated in time.
+	PromotionRejected PromotionState = "rejected"
+)
+
+const (
+	// DefaultCanaryFraction is the fraction of clients serving a candidate used when a model does not
+	// configure it.
+	DefaultCanaryFraction = 0.1
+	// DefaultPromotionTimeout is the time after which a candidate that was not evaluated is rejected.
+	DefaultPromotionTimeout = time.Hour
+)
+
+// PromotionConfig configures the promotion policy of a model. New versions start as candidates served to a
+// fraction of the clients, the canaries, while training is paused. A candidate is promoted to the current
+// version if the evaluations the canaries report beat the evaluations of the current version by Threshold,
+// and rejected otherwise.
+type PromotionConfig struct {
+	Enabled bool `json:"enabled"`
+	// CanaryFraction is the fraction of the active clients of the model serving a candidate, at least one
+	// client serves it. Defaults to DefaultCanaryFraction.
+	CanaryFraction float64 `json:"canaryFraction,omitempty"`
+	// Metric is the evaluation metric versions are compared by. Defaults to MetricLoss.
+	Metric string `json:"metric,omitempty"`
+	// Maximize is set if larger values of the metric are better, e.g. for MetricAccuracy.
+	Maximize bool `json:"maximize,omitempty"`
+	// Threshold is the improvement of the metric a candidate has to achieve. A negative threshold tolerates
+	// a regression.
+	Threshold float64 `json:"threshold,omitempty"`
+	// MinEvaluations is the number of canaries that have to evaluate a candidate before it is judged.
+	// Defaults to 1.
+	MinEvaluations int `json:"minEvaluations,omitempty"`
+	// TimeoutSeconds is the time after which a candidate that was not judged is rejected. Defaults to
+	// DefaultPromotionTimeout.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// canaryFraction returns the configured canary fraction.
+func (c PromotionConfig) canaryFraction() float64 {
+	if c.CanaryFraction == 0 {
+		return DefaultCanaryFraction
+	}
+
+	return c.CanaryFraction
+}
+
+// metric returns the configured metric.
+func (c PromotionConfig) metric() string {
+	if c.Metric == "" {
+		return MetricLoss
+	}
+
+	return c.Metric
+}
+
+// minEvaluations returns the configured number of evaluations.
+func (c PromotionConfig) minEvaluations() int {
+	if c.MinEvaluations <= 0 {
+		return 1
+	}
+
+	return c.MinEvaluations
+}
+
+// timeout returns the configured timeout.
+func (c PromotionConfig) timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return DefaultPromotionTimeout
+	}
+
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// validate checks the promotion policy of a model.
+func (c PromotionConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.CanaryFraction < 0 || c.CanaryFraction > 1 {
+		return fmt.Errorf("canary fraction must be between 0 and 1, got %g", c.CanaryFraction)
+	}
+	if math.IsNaN(c.Threshold) || math.IsInf(c.Threshold, 0) {
+		return fmt.Errorf("promotion threshold must be finite")
+	}
+
+	return nil
+}
+
+// Candidate is a version of a model waiting to be promoted.
+type Candidate struct {
+	Version int `json:"version"`
+	// Canaries are the clients serving the candidate.
+	Canaries []string  `json:"canaries"`
+	Since    time.Time `json:"since"`
+	// Optimizer is the state of the server optimizer after the step that created the candidate. It replaces
+	// the state of the model once the candidate is promoted.
+	Optimizer *OptimizerState `json:"optimizer,omitempty"`
+}
+
+// isCanary returns true if the client serves the candidate.
+func (c *Candidate) isCanary(clientID string) bool {
+	for _, id := range c.Canaries {
+		if id == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// startCandidate makes a new version the candidate of its model and picks the canaries serving it. Must be
+// called with the lock held.
+func (c *Coordinator) startCandidate(version *GlobalModel, config PromotionConfig) {
+	active := make([]string, 0)
+	for id, client := range c.clients {
+		if client.ModelID == version.ID && client.Status != ClientOffline {
+			active = append(active, id)
+		}
+	}
+
+	count := int(math.Ceil(config.canaryFraction() * float64(len(active))))
+	rand.Shuffle(len(active), func(i, j int) { active[i], active[j] = active[j], active[i] })
+	candidate := &Candidate{Version: version.Version, Canaries: active[:min(max(count, 1), len(active))],
+		Since: c.now()}
+
+	version.Promotion = PromotionCandidate
+	c.candidates[version.ID] = candidate
+	log.Printf("Version %d of model %s is a candidate served to clients %v", version.Version, version.ID,
+		candidate.Canaries)
+}
+
+// judgeCandidate promotes or rejects the candidate of a model once enough canaries evaluated it, or its
+// timeout passed. It returns true if the candidate was judged. Must be called with the lock held.
+func (c *Coordinator) judgeCandidate(modelID string) bool {
+	candidate, exists := c.candidates[modelID]
+	if !exists {
+		return false
+	}
+
+	config := c.configs[modelID].Promotion
+	version := c.findVersion(modelID, candidate.Version)
+	incumbent := c.models[modelID]
+	if version == nil {
+		c.resolveCandidate(modelID, false, "candidate version no longer exists")
+		return true
+	}
+
+	challenger, baseline := metricOf(version.Evaluation, config.metric()), metricOf(incumbent.Evaluation,
+		config.metric())
+	if challenger != nil && baseline != nil && challenger.Clients >= config.minEvaluations() {
+		improvement := baseline.Mean - challenger.Mean
+		if config.Maximize {
+			improvement = -improvement
+		}
+
+		c.resolveCandidate(modelID, improvement >= config.Threshold, fmt.Sprintf("%s %g of version %d against %g "+
+			"of version %d", config.metric(), challenger.Mean, version.Version, baseline.Mean, incumbent.Version))
+		return true
+	}
+
+	if c.now().Sub(candidate.Since) > config.timeout() {
+		c.resolveCandidate(modelID, false, fmt.Sprintf("not evaluated within %s", config.timeout()))
+		return true
+	}
+
+	return false
+}
+
+// needsBaseline returns true if a client should evaluate the current version of a model because the
+// candidate of the model lacks a baseline to be compared with. Must be called with the lock held.
+func (c *Coordinator) needsBaseline(modelID, clientID string) bool {
+	config := c.configs[modelID].Promotion
+	incumbent := c.models[modelID]
+	if incumbent.Evaluation.hasClient(clientID) {
+		return false
+	}
+
+	baseline := metricOf(incumbent.Evaluation, config.metric())
+	return baseline == nil || baseline.Clients < config.minEvaluations()
+}
+
+// resolveCandidate promotes or rejects the candidate of a model, and resumes training. Must be called with
+// the lock held.
+func (c *Coordinator) resolveCandidate(modelID string, promote bool, reason string) {
+	candidate := c.candidates[modelID]
+	delete(c.candidates, modelID)
+
+	version := c.findVersion(modelID, candidate.Version)
+	event := Event{ModelID: modelID, Version: candidate.Version, Message: reason}
+	if promote {
+		model, err := c.getVersion(modelID, candidate.Version)
+		if err != nil {
+			log.Printf("Failed to promote version %d of model %s: %v", candidate.Version, modelID, err)
+			promote = false
+		} else {
+			version.Promotion = PromotionPromoted
+			model.Promotion = PromotionPromoted
+			c.models[modelID] = model
+			if candidate.Optimizer != nil {
+				c.setOptimizer(modelID, candidate.Optimizer)
+			}
+			event.Type = EventVersionPromoted
+			log.Printf("Promoted version %d of model %s: %s", candidate.Version, modelID, reason)
+		}
+	}
+	if !promote {
+		if version != nil {
+			version.Promotion = PromotionRejected
+		}
+		// The step towards the rejected version is dropped, the optimizer continues from the current version.
+		if candidate.Optimizer != nil && c.store != nil {
+			if err := c.store.DeleteMoments(modelID, candidate.Optimizer.Version); err != nil {
+				log.Printf("Failed to remove optimizer moments of model %s version %d: %v", modelID,
+					candidate.Optimizer.Version, err)
+			}
+		}
+		event.Type = EventVersionRejected
+		log.Printf("Rejected version %d of model %s: %s", candidate.Version, modelID, reason)
+	}
+
+	c.emit(event)
+	c.openRound(modelID, c.configs[modelID].Round)
+	c.persistRoundEnd(modelID)
+}
+
+// checkCandidates judges candidates whose timeout passed.
+func (c *Coordinator) checkCandidates() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for modelID := range c.candidates {
+		c.judgeCandidate(modelID)
+	}
+}
+
+// findVersion returns the entry of a version in the version history of a model, or nil. Must be called
+// with the lock held.
+func (c *Coordinator) findVersion(modelID string, version int) *GlobalModel {
+	for _, v := range c.versions[modelID] {
+		if v.Version == version {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// metricOf returns the summary of a metric, or nil if it was not reported.
+func metricOf(summary *MetricsSummary, name string) *MetricSummary {
+	if summary == nil {
+		return nil
+	}
+
+	return summary.Metrics[name]
+}
@@ -0,0 +1,219 @@
+package federatedlearning
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPromotion(t *testing.T) {
+	c := NewCoordinator()
+	config := ModelConfig{
+		Round: RoundConfig{MinParticipants: 2},
+		Promotion: PromotionConfig{Enabled: true, CanaryFraction: 0.5, Metric: MetricAccuracy, Maximize: true,
+			Threshold: 0.05},
+	}
+	if _, err := c.CreateModel(&ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}), Config: config}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"})
+	}
+
+	evaluate := func(client string, version int, accuracy float64) {
+		err := c.SubmitEvaluation(&Evaluation{ClientID: client, ModelID: "test", Version: version,
+			Metrics: map[string]float64{MetricAccuracy: accuracy}})
+		if err != nil {
+			t.Fatalf("SubmitEvaluation(%s, %d): unexpected error: %v", client, version, err)
+		}
+	}
+
+	cases := []struct {
+		info      string
+		incumbent float64
+		candidate float64
+		current   int
+		state     PromotionState
+	}{
+		{"should promote better candidate", 0.8, 0.9, 2, PromotionPromoted},
+		{"should reject candidate below threshold", 0.9, 0.92, 2, PromotionRejected},
+	}
+
+	for _, tc := range cases {
+		incumbent, _ := c.GetModel("test")
+		trainTestRound(t, c, []float32{1, 1})
+		if model, _ := c.GetModel("test"); model.Version != incumbent.Version {
+			t.Fatalf("%s: expected candidate not to be served, got version %d", tc.info, model.Version)
+		}
+		if err := c.SubmitModelUpdate(&ModelUpdate{ClientID: "a", ModelID: "test", BaseVersion: incumbent.Version,
+			WeightUpdate: encodeWeights([]float32{1, 1})}); !errors.Is(err, ErrRoundNotOpen) {
+			t.Errorf("%s: expected training to pause, got %v", tc.info, err)
+		}
+
+		candidate := c.candidates["test"]
+		if candidate == nil || len(candidate.Canaries) != 1 {
+			t.Fatalf("%s: expected candidate served to a single canary, got %+v", tc.info, candidate)
+		}
+		canary, other := candidate.Canaries[0], map[string]string{"a": "b", "b": "a"}[candidate.Canaries[0]]
+		if model, _ := c.FetchModel("test", canary); model.Version != candidate.Version || model.Promotion != PromotionCandidate {
+			t.Errorf("%s: expected canary to be served candidate %d, got %d", tc.info, candidate.Version, model.Version)
+		}
+		if model, _ := c.FetchModel("test", other); model.Version != incumbent.Version {
+			t.Errorf("%s: expected other client to be served version %d, got %d", tc.info, incumbent.Version,
+				model.Version)
+		}
+
+		if incumbent.Evaluation == nil {
+			evaluate(other, incumbent.Version, tc.incumbent)
+		}
+		evaluate(canary, candidate.Version, tc.candidate)
+
+		model, _ := c.GetModel("test")
+		version, _ := c.GetModelVersion("test", candidate.Version)
+		round, _ := c.GetRound("test")
+		if model.Version != tc.current || version.Promotion != tc.state || round.State != RoundOpen ||
+			round.BaseVersion != tc.current {
+			t.Errorf("%s: expected version %d to be served and candidate to be %s, got version %d, %s and round %+v",
+				tc.info, tc.current, tc.state, model.Version, version.Promotion, round)
+		}
+	}
+}
+
+func TestPromotionBaseline(t *testing.T) {
+	c := NewCoordinator()
+	config := ModelConfig{
+		Round:     RoundConfig{MinParticipants: 2},
+		Promotion: PromotionConfig{Enabled: true, CanaryFraction: 0.3, Metric: MetricAccuracy, Maximize: true},
+	}
+	if _, err := c.CreateModel(&ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}), Config: config}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"})
+	}
+	trainTestRound(t, c, []float32{1, 1})
+
+	// Clients only evaluate the versions they are asked to, like the client library does. Nobody evaluated
+	// the initial version, so the clients that are not canaries provide the baseline.
+	candidate := c.candidates["test"]
+	if candidate == nil || len(candidate.Canaries) != 1 {
+		t.Fatalf("expected candidate served to a single canary, got %+v", candidate)
+	}
+	accuracies := map[int]float64{1: 0.8, 2: 0.9}
+	clients := []string{"a", "b", "c"}
+	for i, id := range clients {
+		if candidate.isCanary(id) {
+			clients[0], clients[i] = clients[i], clients[0]
+		}
+	}
+	baselines := 0
+	for _, id := range []string{clients[1], clients[2], clients[0]} {
+		model, err := c.FetchModel("test", id)
+		if err != nil {
+			t.Fatalf("FetchModel(%s): unexpected error: %v", id, err)
+		}
+		if model.Promotion != PromotionCandidate && model.Promotion != PromotionBaseline {
+			continue
+		}
+		if model.Promotion == PromotionBaseline {
+			baselines++
+		}
+
+		err = c.SubmitEvaluation(&Evaluation{ClientID: id, ModelID: "test", Version: model.Version,
+			Metrics: map[string]float64{MetricAccuracy: accuracies[model.Version]}})
+		if err != nil {
+			t.Fatalf("SubmitEvaluation(%s, %d): unexpected error: %v", id, model.Version, err)
+		}
+	}
+
+	if baselines != 1 {
+		t.Errorf("expected a single client to evaluate the baseline, got %d", baselines)
+	}
+	model, _ := c.GetModel("test")
+	version, _ := c.GetModelVersion("test", 2)
+	if model.Version != 2 || version.Promotion != PromotionPromoted {
+		t.Errorf("expected candidate to be promoted, got version %d served and candidate %s", model.Version,
+			version.Promotion)
+	}
+	if model.Promotion == PromotionBaseline {
+		t.Errorf("expected baseline state not to be persisted")
+	}
+}
+
+func TestPromotionTimeout(t *testing.T) {
+	store, _ := NewFileStore(t.TempDir())
+	c := newTestPersistentCoordinator(t, store)
+	config := ModelConfig{Round: RoundConfig{MinParticipants: 2}, Promotion: PromotionConfig{Enabled: true, TimeoutSeconds: 60}}
+	if _, err := c.CreateModel(&ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}), Config: config}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"})
+	}
+	trainTestRound(t, c, []float32{1, 1})
+
+	// The candidate survives a restart and is rejected once it was not evaluated in time.
+	restored := newTestPersistentCoordinator(t, store)
+	if candidate := restored.candidates["test"]; candidate == nil || candidate.Version != 2 {
+		t.Fatalf("expected candidate version 2 to be restored, got %+v", candidate)
+	}
+	restored.checkCandidates()
+	if _, exists := restored.candidates["test"]; !exists {
+		t.Fatalf("expected candidate to wait for evaluations")
+	}
+
+	now := time.Now().Add(2 * time.Minute)
+	restored.now = func() time.Time { return now }
+	restored.checkCandidates()
+	model, _ := restored.GetModel("test")
+	version, _ := restored.GetModelVersion("test", 2)
+	if model.Version != 1 || version.Promotion != PromotionRejected {
+		t.Errorf("expected candidate to be rejected, got version %d served and candidate %s", model.Version,
+			version.Promotion)
+	}
+	if round, _ := restored.GetRound("test"); round.State != RoundOpen || round.BaseVersion != 1 {
+		t.Errorf("expected training of version 1 to resume, got %+v", round)
+	}
+}
+
+func TestPromotionOptimizer(t *testing.T) {
+	store, _ := NewFileStore(t.TempDir())
+	c := newTestPersistentCoordinator(t, store)
+	config := ModelConfig{Round: RoundConfig{MinParticipants: 2},
+		Promotion: PromotionConfig{Enabled: true, TimeoutSeconds: 60},
+		Optimizer: OptimizerConfig{Algorithm: FedAvgM, Momentum: 0.5}}
+	if _, err := c.CreateModel(&ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}), Config: config}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"})
+	}
+	trainTestRound(t, c, []float32{1, 1})
+
+	// The step towards the candidate is kept with the candidate, also across a restart.
+	restored := newTestPersistentCoordinator(t, store)
+	if state := restored.optimizers["test"]; state != nil {
+		t.Errorf("expected optimizer state of the model to wait for promotion, got %+v", state)
+	}
+	if candidate := restored.candidates["test"]; candidate == nil || candidate.Optimizer == nil ||
+		candidate.Optimizer.Version != 2 || candidate.Optimizer.FirstMoment == nil {
+		t.Fatalf("expected candidate to carry the optimizer state of version 2, got %+v", candidate)
+	}
+
+	// The momentum of a rejected candidate does not push the next version.
+	now := time.Now().Add(2 * time.Minute)
+	restored.now = func() time.Time { return now }
+	restored.checkCandidates()
+	trainTestRound(t, restored, []float32{1, 1})
+	version, _ := restored.GetModelVersion("test", 3)
+	if weights, _ := decodeWeights(version.Weights); weights[0] != 1 {
+		t.Errorf("expected version 3 to be trained without momentum towards version 2, got %v", weights)
+	}
+
+	if _, err := restored.RollbackModel("test", 3); err != nil {
+		t.Fatalf("RollbackModel(): unexpected error: %v", err)
+	}
+	if state := restored.optimizers["test"]; state == nil || state.Version != 3 {
+		t.Errorf("expected optimizer state of the promoted version 3, got %+v", state)
+	}
+}
@@ -0,0 +1,359 @@
+package federatedlearning
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// QuotaConfig limits the updates clients submit to a model. Zero values fall back to the default quota of
+// the coordinator, see SetDefaultQuota, where zero means no limit.
+type QuotaConfig struct {
+	// MaxUpdateSize is the maximum size in bytes of the weights of an update as sent by a client.
+	MaxUpdateSize int `json:"maxUpdateSize,omitempty"`
+	// MaxUpdatesPerVersion is the number of updates a client may submit based on the same version, rejected
+	// updates included. Failed rounds are reopened for the same version, so it also caps retries.
+	// Asynchronous models without a staleness limit then reject updates based on versions older than the
+	// latest ten, whose counts are no longer kept.
+	MaxUpdatesPerVersion int `json:"maxUpdatesPerVersion,omitempty"`
+	// MaxPendingUpdates is the number of updates held until they are aggregated. Once it is reached, the
+	// pending updates based on the oldest version are evicted to make room for updates based on newer
+	// versions, other updates are rejected.
+	MaxPendingUpdates int `json:"maxPendingUpdates,omitempty"`
+}
+
+func (q QuotaConfig) validate() error {
+	if q.MaxUpdateSize < 0 || q.MaxUpdatesPerVersion < 0 || q.MaxPendingUpdates < 0 {
+		return fmt.Errorf("quota must not be negative")
+	}
+
+	return nil
+}
+
+// withDefaults returns the quota with zero values replaced by the values of defaults.
+func (q QuotaConfig) withDefaults(defaults QuotaConfig) QuotaConfig {
+	if q.MaxUpdateSize == 0 {
+		q.MaxUpdateSize = defaults.MaxUpdateSize
+	}
+	if q.MaxUpdatesPerVersion == 0 {
+		q.MaxUpdatesPerVersion = defaults.MaxUpdatesPerVersion
+	}
+	if q.MaxPendingUpdates == 0 {
+		q.MaxPendingUpdates = defaults.MaxPendingUpdates
+	}
+
+	return q
+}
+
+// submission identifies the updates of a client based on a version.
+type submission struct {
+	ClientID    string
+	BaseVersion int
+}
+
+// SetDefaultQuota sets the quota of models whose configuration leaves a limit at zero.
+func (c *Coordinator) SetDefaultQuota(quota QuotaConfig) error {
+	if err := quota.validate(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.defaultQuota = quota
+	return nil
+}
+
+// Quota returns the quota of a model, its configured quota completed with the default quota.
+func (c *Coordinator) Quota(modelID string) QuotaConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.quota(modelID)
+}
+
+// quota returns the quota of a model. Must be called with the lock held.
+func (c *Coordinator) quota(modelID string) QuotaConfig {
+	return c.configs[modelID].Quota.withDefaults(c.defaultQuota)
+}
+
+// RateLimitError tells a client that it exceeded its request rate, or the request rate of its remote
+// address, and when it should try again.
+type RateLimitError struct {
+	// ClientID is the client that exceeded its request rate. It is empty if the remote address exceeded its
+	// request rate before the client was authenticated.
+	ClientID   string
+	Address    string
+	RetryAfter time.Duration
+}
+
+// Error implements error interface.
+func (e *RateLimitError) Error() string {
+	if e.ClientID == "" {
+		return fmt.Sprintf("%v: address %s, retry after %s", ErrRateLimited, e.Address, e.RetryAfter)
+	}
+
+	return fmt.Sprintf("%v: client %s, retry after %s", ErrRateLimited, e.ClientID, e.RetryAfter)
+}
+
+// Unwrap allows to match RateLimitError with errors.Is(err, ErrRateLimited).
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// rateLimiters limit the request rate of each key, e.g. of each client. Requests are not limited if the
+// limit is zero. They have their own lock instead of the lock of the coordinator, because every request is
+// checked.
+type rateLimiters struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	limit    rate.Limit
+	burst    int
+}
+
+// set limits the requests of every key to requestsPerSecond, allowing bursts of burst requests.
+func (l *rateLimiters) set(requestsPerSecond float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = rate.Limit(requestsPerSecond)
+	l.burst = max(burst, 1)
+	l.limiters = make(map[string]*rate.Limiter)
+}
+
+// reserve counts a request of a key. It returns the time after which the key may send requests again if
+// the request exceeds its rate, and zero otherwise.
+func (l *rateLimiters) reserve(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit <= 0 {
+		return 0
+	}
+
+	limiter, exists := l.limiters[key]
+	if !exists {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[key] = limiter
+	}
+
+	reservation := limiter.Reserve()
+	delay := reservation.Delay()
+	if delay <= 0 {
+		return 0
+	}
+
+	reservation.Cancel()
+	return time.Duration(math.Ceil(delay.Seconds())) * time.Second
+}
+
+// prune forgets keys that did not send requests long enough to refill their burst.
+func (l *rateLimiters) prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, limiter := range l.limiters {
+		if limiter.Tokens() >= float64(l.burst) {
+			delete(l.limiters, key)
+		}
+	}
+}
+
+// SetRateLimit limits the requests of every client to requestsPerSecond, allowing bursts of burst requests.
+// A rate of zero disables rate limiting.
+func (c *Coordinator) SetRateLimit(requestsPerSecond float64, burst int) {
+	c.clientLimits.set(requestsPerSecond, burst)
+}
+
+// SetAddressRateLimit limits the requests from every remote address to requestsPerSecond, allowing bursts of
+// burst requests. Requests are checked before their caller is authenticated, so floods of unauthenticated
+// requests are limited as well. A rate of zero disables rate limiting.
+func (c *Coordinator) SetAddressRateLimit(requestsPerSecond float64, burst int) {
+	c.addressLimits.set(requestsPerSecond, burst)
+}
+
+// CheckRateLimit counts a request of a client against its request rate. It returns a RateLimitError if the
+// client exceeded it.
+func (c *Coordinator) CheckRateLimit(clientID string) error {
+	if retryAfter := c.clientLimits.reserve(clientID); retryAfter > 0 {
+		c.metrics.rateLimited.Inc()
+		return &RateLimitError{ClientID: clientID, RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// CheckAddressRateLimit counts a request from a remote address against its request rate. It returns a
+// RateLimitError if the address exceeded it.
+func (c *Coordinator) CheckAddressRateLimit(address string) error {
+	if retryAfter := c.addressLimits.reserve(address); retryAfter > 0 {
+		c.metrics.rateLimited.Inc()
+		return &RateLimitError{Address: address, RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// checkQuota counts an update against the quota of its model. It returns an error wrapping
+// ErrUpdateTooLarge or ErrQuotaExceeded if the update exceeds the quota. Must be called with the lock held.
+func (c *Coordinator) checkQuota(update *ModelUpdate) error {
+	quota := c.quota(update.ModelID)
+	if quota.MaxUpdateSize > 0 && len(update.WeightUpdate) > quota.MaxUpdateSize {
+		return fmt.Errorf("%w: update from client %s has %d bytes, model %s accepts at most %d bytes",
+			ErrUpdateTooLarge, update.ClientID, len(update.WeightUpdate), update.ModelID, quota.MaxUpdateSize)
+	}
+
+	// Only updates based on versions the open round accepts are counted, others are rejected as stale.
+	oldest, newest, bounded := c.countedVersions(update.ModelID)
+	if quota.MaxUpdatesPerVersion > 0 && update.BaseVersion < oldest && !bounded {
+		return fmt.Errorf("%w: updates based on version %d of model %s are no longer counted", ErrQuotaExceeded,
+			update.BaseVersion, update.ModelID)
+	}
+	if quota.MaxUpdatesPerVersion > 0 && update.BaseVersion >= oldest && update.BaseVersion <= newest {
+		submissions := c.submissions[update.ModelID]
+		if submissions == nil {
+			submissions = make(map[submission]int)
+			c.submissions[update.ModelID] = submissions
+		}
+
+		key := submission{ClientID: update.ClientID, BaseVersion: update.BaseVersion}
+		if submissions[key] >= quota.MaxUpdatesPerVersion {
+			return fmt.Errorf("%w: client %s submitted %d updates based on version %d of model %s", ErrQuotaExceeded,
+				update.ClientID, submissions[key], update.BaseVersion, update.ModelID)
+		}
+		submissions[key]++
+	}
+
+	if quota.MaxPendingUpdates > 0 && len(c.evictable(update.ModelID, update.BaseVersion, quota)) == 0 &&
+		len(c.modelUpdates[update.ModelID]) >= quota.MaxPendingUpdates {
+		return fmt.Errorf("%w: model %s holds %d pending updates", ErrQuotaExceeded, update.ModelID,
+			quota.MaxPendingUpdates)
+	}
+
+	return nil
+}
+
+// evictable returns the pending updates of a model that are evicted to make room for an update based on
+// baseVersion, the updates based on the oldest version if it is older than baseVersion. It returns nil if
+// the model has room for the update. Must be called with the lock held.
+func (c *Coordinator) evictable(modelID string, baseVersion int, quota QuotaConfig) []*ModelUpdate {
+	pending := c.modelUpdates[modelID]
+	if quota.MaxPendingUpdates <= 0 || len(pending) < quota.MaxPendingUpdates {
+		return nil
+	}
+
+	oldest := baseVersion
+	for _, update := range pending {
+		oldest = min(oldest, update.BaseVersion)
+	}
+	if oldest == baseVersion {
+		return nil
+	}
+
+	var evictable []*ModelUpdate
+	for _, update := range pending {
+		if update.BaseVersion == oldest {
+			evictable = append(evictable, update)
+		}
+	}
+
+	return evictable
+}
+
+// evictUpdates evicts pending updates of a model to make room for an update based on baseVersion. Clients
+// whose updates were evicted may submit a new update in the round. It returns true if updates were evicted,
+// the persisted updates have to be replaced then, see persistUpdates. Must be called with the lock held.
+func (c *Coordinator) evictUpdates(modelID string, baseVersion int) bool {
+	evicted := c.evictable(modelID, baseVersion, c.quota(modelID))
+	if len(evicted) == 0 {
+		return false
+	}
+
+	isEvicted := make(map[*ModelUpdate]bool, len(evicted))
+	clients := make(map[string]bool, len(evicted))
+	for _, update := range evicted {
+		isEvicted[update] = true
+		clients[update.ClientID] = true
+		c.emit(Event{Type: EventUpdateRejected, ModelID: modelID, ClientID: update.ClientID,
+			Message: fmt.Sprintf("evicted update based on version %d", update.BaseVersion)})
+	}
+
+	pending := make([]*ModelUpdate, 0, len(c.modelUpdates[modelID]))
+	for _, update := range c.modelUpdates[modelID] {
+		if !isEvicted[update] {
+			pending = append(pending, update)
+		}
+	}
+	c.modelUpdates[modelID] = pending
+
+	if round := c.rounds[modelID]; round != nil {
+		participants := make([]string, 0, len(round.Participants))
+		for _, id := range round.Participants {
+			if !clients[id] {
+				participants = append(participants, id)
+			}
+		}
+		round.Participants = participants
+	}
+
+	log.Printf("Evicted %d pending updates of model %s based on version %d", len(evicted), modelID,
+		evicted[0].BaseVersion)
+	c.metrics.evictedUpdates.WithLabelValues(modelID).Add(float64(len(evicted)))
+	return true
+}
+
+// persistUpdates replaces the persisted pending updates of a model with the pending updates held in memory.
+// Must be called with the lock held.
+func (c *Coordinator) persistUpdates(modelID string) {
+	if c.store == nil {
+		return
+	}
+
+	if err := c.store.DeleteUpdates(modelID); err != nil {
+		log.Printf("Failed to delete persisted updates of model %s: %v", modelID, err)
+		return
+	}
+	for _, update := range c.modelUpdates[modelID] {
+		if err := c.store.SaveUpdate(update); err != nil {
+			log.Printf("Failed to persist update from client %s: %v", update.ClientID, err)
+		}
+	}
+}
+
+// countedVersions returns the range of versions whose updates are counted against the quota of a model:
+// the versions the open round accepts updates for. Asynchronous models accepting updates of any staleness
+// count updates based on the latest retainedVersions only, bounded is false for them. Must be called with the
+// lock held.
+func (c *Coordinator) countedVersions(modelID string) (oldest, newest int, bounded bool) {
+	round := c.rounds[modelID]
+	if round == nil {
+		return 0, 0, true
+	}
+
+	oldest, newest, bounded = round.BaseVersion, round.BaseVersion, true
+	if async := c.configs[modelID].Async; async.Enabled {
+		if async.MaxStaleness > 0 {
+			oldest -= async.MaxStaleness
+		} else {
+			oldest -= retainedVersions
+			bounded = false
+		}
+	}
+
+	return oldest, newest, bounded
+}
+
+// pruneSubmissions forgets the updates counted for versions that the open round of a model no longer
+// accepts updates for. Must be called with the lock held.
+func (c *Coordinator) pruneSubmissions(round *Round) {
+	oldest, _, _ := c.countedVersions(round.ModelID)
+	for key := range c.submissions[round.ModelID] {
+		if key.BaseVersion < oldest {
+			delete(c.submissions[round.ModelID], key)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package federatedlearning
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQuotaUpdatesPerVersion(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a", "b")
+	if err := c.SetModelConfig("test", ModelConfig{Round: RoundConfig{MinParticipants: 2},
+		Quota: QuotaConfig{MaxUpdatesPerVersion: 2}}); err != nil {
+		t.Fatalf("SetModelConfig(): unexpected error: %v", err)
+	}
+
+	// Rejected updates count against the quota, so clients cannot retry forever.
+	invalid := &ModelUpdate{ClientID: "a", ModelID: "test", BaseVersion: 1,
+		WeightUpdate: encodeWeights([]float32{1}), NumSamples: 1}
+	if err := c.SubmitModelUpdate(invalid); !errors.Is(err, ErrInvalidUpdate) {
+		t.Fatalf("expected ErrInvalidUpdate, got %v", err)
+	}
+	if err := submitTestUpdate(c, "a", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := submitTestUpdate(c, "a", 1); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// Counts of versions the open round no longer accepts updates for are forgotten.
+	if err := submitTestUpdate(c, "b", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if submissions := c.submissions["test"]; len(submissions) != 0 {
+		t.Fatalf("expected counts of version 1 to be pruned, got %v", submissions)
+	}
+	if err := submitTestUpdate(c, "a", 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestQuotaEvictsOldUpdates(t *testing.T) {
+	c := newTestAsyncCoordinator(t, AsyncConfig{BufferSize: 1}, RoundConfig{}, "a", "b", "c", "d")
+	if err := submitAsyncUpdate(c, "a", 1, []float32{1, 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The round of version 2 was opened with the previous buffer size.
+	if err := c.SetModelConfig("test", ModelConfig{Async: AsyncConfig{Enabled: true, BufferSize: 3},
+		Quota: QuotaConfig{MaxPendingUpdates: 2}}); err != nil {
+		t.Fatalf("SetModelConfig(): unexpected error: %v", err)
+	}
+	if err := submitAsyncUpdate(c, "b", 1, []float32{1, 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events, cancel := c.Subscribe("test")
+	defer cancel()
+
+	if err := submitAsyncUpdate(c, "a", 2, []float32{1, 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := submitAsyncUpdate(c, "b", 3, []float32{1, 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The update based on the oldest version makes room for a newer one.
+	if err := submitAsyncUpdate(c, "c", 3, []float32{1, 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	round, _ := c.GetRound("test")
+	if expected := []string{"b", "c"}; !reflect.DeepEqual(round.Participants, expected) {
+		t.Fatalf("expected participants %v, got %v", expected, round.Participants)
+	}
+	if pending := len(c.modelUpdates["test"]); pending != 2 {
+		t.Fatalf("expected 2 pending updates, got %d", pending)
+	}
+
+	evicted := false
+	for len(events) > 0 {
+		event := <-events
+		evicted = evicted || event.Type == EventUpdateRejected && event.ClientID == "a"
+	}
+	if !evicted {
+		t.Fatal("expected eviction of the update of client a to be announced")
+	}
+
+	// Updates that are not newer than the pending ones are rejected.
+	if err := submitAsyncUpdate(c, "d", 2, []float32{1, 1}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if err := submitAsyncUpdate(c, "a", 3, []float32{1, 1}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+}
+
+func TestQuotaUnboundedStaleness(t *testing.T) {
+	c := newTestAsyncCoordinator(t, AsyncConfig{BufferSize: 1}, RoundConfig{}, "a")
+	if err := c.SetModelConfig("test", ModelConfig{Async: AsyncConfig{Enabled: true, BufferSize: 1},
+		Quota: QuotaConfig{MaxUpdatesPerVersion: 1}}); err != nil {
+		t.Fatalf("SetModelConfig(): unexpected error: %v", err)
+	}
+
+	// Updates of any staleness are accepted, but only counts of the latest versions are kept.
+	for version := 1; version <= 3*retainedVersions; version++ {
+		if err := submitAsyncUpdate(c, "a", version, []float32{1, 1}); err != nil {
+			t.Fatalf("version %d: unexpected error: %v", version, err)
+		}
+		if counted := len(c.submissions["test"]); counted > retainedVersions+1 {
+			t.Fatalf("version %d: expected at most %d counted versions, got %d", version, retainedVersions+1, counted)
+		}
+	}
+
+	if err := submitAsyncUpdate(c, "a", 1, []float32{1, 1}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected update based on a version that is no longer counted to be rejected, got %v", err)
+	}
+	if err := submitAsyncUpdate(c, "a", 3*retainedVersions+5, []float32{1, 1}); !errors.Is(err, ErrStaleUpdate) {
+		t.Errorf("expected update based on a future version to be rejected as stale, got %v", err)
+	}
+	if counted := len(c.submissions["test"]); counted > retainedVersions+1 {
+		t.Errorf("expected rejected updates not to be counted, got %d counted versions", counted)
+	}
+}
+
+// failingStore fails to persist updates while fail is set.
+type failingStore struct {
+	Store
+	fail bool
+}
+
+// SaveUpdate implements Store interface.
+func (s *failingStore) SaveUpdate(update *ModelUpdate) error {
+	if s.fail {
+		return errors.New("disk full")
+	}
+
+	return s.Store.SaveUpdate(update)
+}
+
+func TestQuotaEvictsPersistedUpdates(t *testing.T) {
+	fileStore, _ := NewFileStore(t.TempDir())
+	store := &failingStore{Store: fileStore}
+	c := newTestPersistentCoordinator(t, store)
+	spec := &ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}),
+		Config: ModelConfig{Async: AsyncConfig{Enabled: true, BufferSize: 1}}}
+	if _, err := c.CreateModel(spec); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"})
+	}
+	if err := submitAsyncUpdate(c, "a", 1, []float32{1, 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.SetModelConfig("test", ModelConfig{Async: AsyncConfig{Enabled: true, BufferSize: 3},
+		Quota: QuotaConfig{MaxPendingUpdates: 2}}); err != nil {
+		t.Fatalf("SetModelConfig(): unexpected error: %v", err)
+	}
+	for _, update := range []struct {
+		client      string
+		baseVersion int
+	}{{"b", 1}, {"a", 2}, {"b", 3}} {
+		if err := submitAsyncUpdate(c, update.client, update.baseVersion, []float32{1, 1}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// An update that fails to be persisted does not evict pending updates.
+	store.fail = true
+	if err := submitAsyncUpdate(c, "c", 3, []float32{1, 1}); err == nil {
+		t.Fatal("expected update to fail")
+	}
+	if round, _ := c.GetRound("test"); !reflect.DeepEqual(round.Participants, []string{"a", "b"}) {
+		t.Fatalf("expected pending updates to be kept, got participants %v", round.Participants)
+	}
+
+	store.fail = false
+	if err := submitAsyncUpdate(c, "c", 3, []float32{1, 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restored := newTestPersistentCoordinator(t, store)
+	var clients []string
+	for _, update := range restored.modelUpdates["test"] {
+		clients = append(clients, update.ClientID)
+	}
+	sort.Strings(clients)
+	if expected := []string{"b", "c"}; !reflect.DeepEqual(clients, expected) {
+		t.Fatalf("expected persisted updates of clients %v, got %v", expected, clients)
+	}
+}
+
+func TestQuotaDefaults(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a")
+	if err := c.SetDefaultQuota(QuotaConfig{MaxUpdateSize: -1}); err == nil {
+		t.Fatal("expected negative quota to be rejected")
+	}
+	if err := c.SetDefaultQuota(QuotaConfig{MaxUpdateSize: 4, MaxPendingUpdates: 10}); err != nil {
+		t.Fatalf("SetDefaultQuota(): unexpected error: %v", err)
+	}
+	if err := c.SetModelConfig("test", ModelConfig{Round: RoundConfig{MinParticipants: 2},
+		Quota: QuotaConfig{MaxUpdateSize: 8}}); err != nil {
+		t.Fatalf("SetModelConfig(): unexpected error: %v", err)
+	}
+
+	expected := QuotaConfig{MaxUpdateSize: 8, MaxPendingUpdates: 10}
+	if quota := c.Quota("test"); quota != expected {
+		t.Fatalf("expected quota %+v, got %+v", expected, quota)
+	}
+
+	err := c.SetModelConfig("test", ModelConfig{Quota: QuotaConfig{MaxUpdatesPerVersion: -1}})
+	if err == nil {
+		t.Fatal("expected negative quota to be rejected")
+	}
+}
+
+func TestAPIQuota(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a", "b")
+	if err := c.SetModelConfig("test", ModelConfig{Round: RoundConfig{MinParticipants: 2},
+		Quota: QuotaConfig{MaxUpdateSize: 8}}); err != nil {
+		t.Fatalf("SetModelConfig(): unexpected error: %v", err)
+	}
+	server := newTestAPIServer(c)
+	defer server.Close()
+	url := server.URL + "/api/v1/fl/model/test/update"
+
+	tests := []struct {
+		info     string
+		weights  []float32
+		expected int
+	}{
+		{"update exceeding the quota should be rejected", []float32{1, 1, 1}, http.StatusRequestEntityTooLarge},
+		{"request exceeding the quota should be rejected before it is read", make([]float32, 1<<15),
+			http.StatusRequestEntityTooLarge},
+		{"update within the quota should be accepted", []float32{1, 1}, http.StatusAccepted},
+	}
+	for _, test := range tests {
+		update := &ModelUpdate{ModelID: "test", BaseVersion: 1, WeightUpdate: encodeWeights(test.weights),
+			NumSamples: 1}
+		if status := doTestClientRequest(t, "a", http.MethodPost, url, update, nil); status != test.expected {
+			t.Fatalf("%s: expected status %d, got %d", test.info, test.expected, status)
+		}
+	}
+
+	// Requests rejected before they reach the coordinator are counted as well.
+	if rejected := testutil.ToFloat64(c.metrics.rejectedUpdates.WithLabelValues("test", "too_large")); rejected != 2 {
+		t.Errorf("expected 2 updates rejected as too large, got %g", rejected)
+	}
+}
+
+func TestAPIRateLimit(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a", "b")
+	c.SetRateLimit(0.01, 2)
+	server := newTestAPIServer(c)
+	defer server.Close()
+	url := server.URL + "/api/v1/fl/model/test?clientId="
+
+	for i := 0; i < 2; i++ {
+		if status := doTestClientRequest(t, "a", http.MethodGet, url+"a", nil, nil); status != http.StatusOK {
+			t.Fatalf("request %d: expected status %d, got %d", i, http.StatusOK, status)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, url+"a", nil)
+	req.Header.Set("Authorization", "Bearer a")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "100" {
+		t.Fatalf("expected status %d with Retry-After 100, got %d with %q", http.StatusTooManyRequests,
+			resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	if !strings.Contains(string(body), ErrRateLimited.Error()) {
+		t.Fatalf("expected error body to tell the rate was exceeded, got %q", body)
+	}
+
+	// Clients are limited independently.
+	if status := doTestClientRequest(t, "b", http.MethodGet, url+"b", nil, nil); status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, status)
+	}
+}
+
+func TestAPIAddressRateLimit(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a", "b")
+	c.SetAddressRateLimit(0.01, 2)
+	server := newTestAPIServer(c)
+	defer server.Close()
+	url := server.URL + "/api/v1/fl/model/test?clientId="
+
+	// Requests are counted before they are authenticated, so invalid tokens use up the rate of the address.
+	if status := doTestClientRequest(t, "", http.MethodGet, url+"a", nil, nil); status != http.StatusUnauthorized {
+		t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, status)
+	}
+	if status := doTestClientRequest(t, "a", http.MethodGet, url+"a", nil, nil); status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, status)
+	}
+	if status := doTestClientRequest(t, "b", http.MethodGet, url+"b", nil, nil); status != http.StatusTooManyRequests {
+		t.Fatalf("expected requests from the same address to be limited, got status %d", status)
+	}
+}
@@ -0,0 +1,235 @@
+package federatedlearning
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Names of the Byzantine-robust aggregators. They tolerate a minority of faulty or malicious clients
+// whose updates would skew a plain average, at the cost of ignoring the sample counts of updates.
+const (
+	// Median takes the coordinate-wise median of the updates.
+	Median = "median"
+	// TrimmedMean drops the largest and the smallest values of every coordinate and averages the rest.
+	TrimmedMean = "trimmed-mean"
+	// MultiKrum averages the updates that are closest to their neighbours (Blanchard et al.).
+	MultiKrum = "multi-krum"
+)
+
+// DefaultTrimFraction is the fraction of values dropped from each end by TrimmedMean when a model does not
+// configure it.
+const DefaultTrimFraction = 0.1
+
+// coordinateAggregator combines every coordinate of the updates separately. Only the values at positions
+// from..to of the sorted values of a coordinate are averaged. Nearly every update has some of its values
+// averaged, so an update is reported as discarded when fewer of its values were averaged than half the
+// share an update gets on average, i.e. when it was dropped from most coordinates.
+type coordinateAggregator struct {
+	// keep returns the range of sorted positions that are averaged out of n values.
+	keep func(n int) (from, to int)
+}
+
+func newMedianAggregator(AggregationConfig) (Aggregator, error) {
+	return &coordinateAggregator{keep: func(n int) (int, int) {
+		return (n - 1) / 2, n / 2
+	}}, nil
+}
+
+func newTrimmedMeanAggregator(config AggregationConfig) (Aggregator, error) {
+	fraction := config.TrimFraction
+	if fraction == 0 {
+		fraction = DefaultTrimFraction
+	}
+	if fraction < 0 || fraction >= 0.5 {
+		return nil, fmt.Errorf("trim fraction has to be at least 0 and less than 0.5, got %g", fraction)
+	}
+
+	return &coordinateAggregator{keep: func(n int) (int, int) {
+		trim := int(fraction * float64(n))
+		return trim, n - trim - 1
+	}}, nil
+}
+
+// Aggregate implements Aggregator interface. See Aggregator for more information.
+func (a *coordinateAggregator) Aggregate(model *GlobalModel, updates []*ModelUpdate) (*Aggregation, error) {
+	updates, vectors, faulty, err := decodeFiniteUpdates(model, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(vectors)
+	from, to := a.keep(n)
+	averaged := make([]int, n)
+	order := make([]int, n)
+	result := make([]float32, len(vectors[0]))
+	for j := range result {
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(x, y int) bool { return vectors[order[x]][j] < vectors[order[y]][j] })
+
+		var sum float64
+		for _, i := range order[from : to+1] {
+			sum += float64(vectors[i][j])
+			averaged[i]++
+		}
+		result[j] = float32(sum / float64(to-from+1))
+	}
+
+	// Every coordinate averages to-from+1 of the n values.
+	contributed := make([]bool, n)
+	for i, count := range averaged {
+		contributed[i] = 2*n*count >= (to-from+1)*len(result)
+	}
+
+	return &Aggregation{Weights: encodeWeights(result), Discarded: append(faulty, discarded(updates, contributed)...)},
+		nil
+}
+
+// multiKrumAggregator scores every update by the sum of squared distances to its closest neighbours and
+// averages the updates with the lowest scores.
+type multiKrumAggregator struct {
+	byzantine int
+	selected  int
+}
+
+func newMultiKrumAggregator(config AggregationConfig) (Aggregator, error) {
+	if config.ByzantineClients < 0 || config.KrumSelect < 0 {
+		return nil, fmt.Errorf("byzantine clients and krum select must not be negative")
+	}
+
+	return &multiKrumAggregator{byzantine: config.ByzantineClients, selected: config.KrumSelect}, nil
+}
+
+// Aggregate implements Aggregator interface. See Aggregator for more information.
+func (a *multiKrumAggregator) Aggregate(model *GlobalModel, updates []*ModelUpdate) (*Aggregation, error) {
+	updates, vectors, faulty, err := decodeFiniteUpdates(model, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(vectors)
+	if n < 2*a.byzantine+3 {
+		return nil, fmt.Errorf("multi-krum tolerating %d byzantine clients needs at least %d updates, got %d",
+			a.byzantine, 2*a.byzantine+3, n)
+	}
+	neighbours := n - a.byzantine - 2
+
+	distances := make([][]float64, n)
+	for i := range distances {
+		distances[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for k := i + 1; k < n; k++ {
+			distances[i][k] = squaredDistance(vectors[i], vectors[k])
+			distances[k][i] = distances[i][k]
+		}
+	}
+
+	scores := make([]float64, n)
+	for i := range scores {
+		others := make([]float64, 0, n-1)
+		for k, distance := range distances[i] {
+			if k != i {
+				others = append(others, distance)
+			}
+		}
+		sort.Float64s(others)
+		for _, distance := range others[:neighbours] {
+			scores[i] += distance
+		}
+	}
+
+	selected := a.selected
+	if selected == 0 || selected > n {
+		selected = n - a.byzantine
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(x, y int) bool { return scores[order[x]] < scores[order[y]] })
+
+	contributed := make([]bool, n)
+	sum := make([]float64, len(vectors[0]))
+	for _, i := range order[:selected] {
+		contributed[i] = true
+		for j, w := range vectors[i] {
+			sum[j] += float64(w)
+		}
+	}
+
+	result := make([]float32, len(sum))
+	for j := range sum {
+		result[j] = float32(sum[j] / float64(selected))
+	}
+
+	return &Aggregation{Weights: encodeWeights(result), Discarded: append(faulty, discarded(updates, contributed)...)},
+		nil
+}
+
+// decodeFiniteUpdates decodes updates like decodeUpdates, but leaves out updates with NaN or infinite values,
+// which have no place in the order of values and make every distance to them NaN. It returns the remaining
+// updates, their weights and the IDs of the clients whose updates were left out.
+func decodeFiniteUpdates(model *GlobalModel, updates []*ModelUpdate) ([]*ModelUpdate, [][]float32, []string,
+	error) {
+	vectors, err := decodeUpdates(model, updates)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var faulty []string
+	finiteUpdates := make([]*ModelUpdate, 0, len(updates))
+	finiteVectors := make([][]float32, 0, len(vectors))
+	for i, update := range updates {
+		if !finite(vectors[i]) {
+			faulty = append(faulty, update.ClientID)
+			continue
+		}
+
+		finiteUpdates = append(finiteUpdates, update)
+		finiteVectors = append(finiteVectors, vectors[i])
+	}
+
+	if len(finiteVectors) == 0 {
+		return nil, nil, nil, fmt.Errorf("no update of model %s has finite weights", model.ID)
+	}
+
+	return finiteUpdates, finiteVectors, faulty, nil
+}
+
+// finite tells whether all weights are neither NaN nor infinite.
+func finite(weights []float32) bool {
+	for _, w := range weights {
+		if math.IsNaN(float64(w)) || math.IsInf(float64(w), 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// squaredDistance returns the squared Euclidean distance of two vectors of the same length.
+func squaredDistance(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+
+	return sum
+}
+
+// discarded returns IDs of clients whose updates did not contribute to an aggregation.
+func discarded(updates []*ModelUpdate, contributed []bool) []string {
+	var result []string
+	for i, update := range updates {
+		if !contributed[i] {
+			result = append(result, update.ClientID)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,167 @@
+package federatedlearning
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRobustAggregate(t *testing.T) {
+	// Four honest clients agree on weights close to [1, 2], client e tries to poison the model.
+	updates := []*ModelUpdate{
+		{ClientID: "a", WeightUpdate: encodeWeights([]float32{1, 2})},
+		{ClientID: "b", WeightUpdate: encodeWeights([]float32{1, 2})},
+		{ClientID: "c", WeightUpdate: encodeWeights([]float32{0.5, 1.5})},
+		{ClientID: "d", WeightUpdate: encodeWeights([]float32{1.5, 2.5})},
+		{ClientID: "e", WeightUpdate: encodeWeights([]float32{100, -100})},
+	}
+
+	cases := []struct {
+		info              string
+		config            AggregationConfig
+		expected          []float32
+		expectedDiscarded []string
+	}{
+		{"median should ignore outlier", AggregationConfig{Algorithm: Median}, []float32{1, 2}, []string{"c", "d", "e"}},
+		{"trimmed mean should drop extreme values", AggregationConfig{Algorithm: TrimmedMean, TrimFraction: 0.2},
+			[]float32{7.0 / 6, 11.0 / 6}, []string{"e"}},
+		{"multi-krum should average closest updates", AggregationConfig{Algorithm: MultiKrum, ByzantineClients: 1},
+			[]float32{1, 2}, []string{"e"}},
+		{"multi-krum should average selected number of updates",
+			AggregationConfig{Algorithm: MultiKrum, ByzantineClients: 1, KrumSelect: 2}, []float32{1, 2},
+			[]string{"c", "d", "e"}},
+	}
+
+	for _, tc := range cases {
+		aggregator, err := NewAggregator(tc.config)
+		if err != nil {
+			t.Fatalf("%s: NewAggregator(): unexpected error: %v", tc.info, err)
+		}
+
+		aggregation, err := aggregator.Aggregate(&GlobalModel{ID: "test"}, updates)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.info, err)
+		}
+
+		actual, _ := decodeWeights(aggregation.Weights)
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s: expected weights %v, got %v", tc.info, tc.expected, actual)
+		}
+		if !reflect.DeepEqual(aggregation.Discarded, tc.expectedDiscarded) {
+			t.Errorf("%s: expected discarded %v, got %v", tc.info, tc.expectedDiscarded, aggregation.Discarded)
+		}
+	}
+}
+
+func TestCoordinateAggregatorDiscarded(t *testing.T) {
+	// Honest clients take turns at the extremes, client e is dropped from every coordinate but the last.
+	updates := []*ModelUpdate{
+		{ClientID: "a", WeightUpdate: encodeWeights([]float32{1, 2, 3, 4, 1})},
+		{ClientID: "b", WeightUpdate: encodeWeights([]float32{2, 3, 4, 1, 2})},
+		{ClientID: "c", WeightUpdate: encodeWeights([]float32{3, 4, 1, 2, 3})},
+		{ClientID: "d", WeightUpdate: encodeWeights([]float32{4, 1, 2, 3, 4})},
+		{ClientID: "e", WeightUpdate: encodeWeights([]float32{100, 100, 100, 100, 2.5})},
+	}
+
+	aggregator, _ := NewAggregator(AggregationConfig{Algorithm: TrimmedMean, TrimFraction: 0.2})
+	aggregation, err := aggregator.Aggregate(&GlobalModel{ID: "test"}, updates)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(aggregation.Discarded, []string{"e"}) {
+		t.Errorf("expected update of e to be discarded, got %v", aggregation.Discarded)
+	}
+}
+
+func TestRobustAggregateNonFinite(t *testing.T) {
+	// Every honest client is the median of one coordinate, client f sends NaN and infinite values.
+	nan := float32(math.NaN())
+	inf := float32(math.Inf(1))
+	updates := []*ModelUpdate{
+		{ClientID: "a", WeightUpdate: encodeWeights([]float32{1, 2, 3, 4, 5})},
+		{ClientID: "b", WeightUpdate: encodeWeights([]float32{2, 3, 4, 5, 1})},
+		{ClientID: "c", WeightUpdate: encodeWeights([]float32{3, 4, 5, 1, 2})},
+		{ClientID: "d", WeightUpdate: encodeWeights([]float32{4, 5, 1, 2, 3})},
+		{ClientID: "e", WeightUpdate: encodeWeights([]float32{5, 1, 2, 3, 4})},
+		{ClientID: "f", WeightUpdate: encodeWeights([]float32{nan, inf, -inf, nan, 1})},
+	}
+
+	configs := []AggregationConfig{
+		{Algorithm: Median},
+		{Algorithm: TrimmedMean, TrimFraction: 0.2},
+		{Algorithm: MultiKrum, ByzantineClients: 1, KrumSelect: 5},
+	}
+	for _, config := range configs {
+		aggregator, _ := NewAggregator(config)
+		aggregation, err := aggregator.Aggregate(&GlobalModel{ID: "test"}, updates)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", config.Algorithm, err)
+		}
+
+		weights, _ := decodeWeights(aggregation.Weights)
+		if !reflect.DeepEqual(weights, []float32{3, 3, 3, 3, 3}) {
+			t.Errorf("%s: expected weights [3 3 3 3 3], got %v", config.Algorithm, weights)
+		}
+		if !reflect.DeepEqual(aggregation.Discarded, []string{"f"}) {
+			t.Errorf("%s: expected only update of f to be discarded, got %v", config.Algorithm, aggregation.Discarded)
+		}
+	}
+
+	c := newTestCoordinator(t, 2, "a")
+	err := c.SubmitModelUpdate(&ModelUpdate{ClientID: "a", ModelID: "test", BaseVersion: 1,
+		WeightUpdate: encodeWeights([]float32{1, nan})})
+	if !errors.Is(err, ErrInvalidUpdate) {
+		t.Errorf("expected update with NaN weights to be rejected, got %v", err)
+	}
+}
+
+func TestRobustAggregatorConfig(t *testing.T) {
+	invalid := []AggregationConfig{
+		{Algorithm: TrimmedMean, TrimFraction: 0.5},
+		{Algorithm: TrimmedMean, TrimFraction: -0.1},
+		{Algorithm: MultiKrum, ByzantineClients: -1},
+	}
+	for _, config := range invalid {
+		if _, err := NewAggregator(config); err == nil {
+			t.Errorf("expected %+v to be rejected", config)
+		}
+	}
+
+	aggregator, _ := NewAggregator(AggregationConfig{Algorithm: MultiKrum, ByzantineClients: 1})
+	_, err := aggregator.Aggregate(&GlobalModel{ID: "test"}, []*ModelUpdate{
+		{ClientID: "a", WeightUpdate: encodeWeights([]float32{1})},
+		{ClientID: "b", WeightUpdate: encodeWeights([]float32{1})},
+		{ClientID: "c", WeightUpdate: encodeWeights([]float32{1})},
+		{ClientID: "d", WeightUpdate: encodeWeights([]float32{1})},
+	})
+	if err == nil {
+		t.Error("expected multi-krum to need at least 2f+3 updates")
+	}
+}
+
+func TestRobustAggregationRecordsDiscarded(t *testing.T) {
+	c := newTestCoordinator(t, 3, "a", "b", "c")
+	config := c.GetModelConfig("test")
+	config.Aggregation = AggregationConfig{Algorithm: TrimmedMean, TrimFraction: 0.34}
+	if err := c.SetModelConfig("test", config); err != nil {
+		t.Fatalf("SetModelConfig(): unexpected error: %v", err)
+	}
+
+	for client, weights := range map[string][]float32{"a": {1, 1}, "b": {2, 2}, "c": {50, 50}} {
+		if err := c.SubmitModelUpdate(&ModelUpdate{ClientID: client, ModelID: "test", BaseVersion: 1,
+			WeightUpdate: encodeWeights(weights)}); err != nil {
+			t.Fatalf("SubmitModelUpdate(%s): unexpected error: %v", client, err)
+		}
+	}
+
+	version, _ := c.GetModelVersion("test", 2)
+	if weights, _ := decodeWeights(version.Weights); !reflect.DeepEqual(weights, []float32{2, 2}) {
+		t.Errorf("expected weights of the middle client, got %v", weights)
+	}
+	sort.Strings(version.Discarded)
+	if !reflect.DeepEqual(version.Discarded, []string{"a", "c"}) {
+		t.Errorf("expected updates of a and c to be discarded, got %v", version.Discarded)
+	}
+}
@@ -0,0 +1,174 @@
+package federatedlearning
+
+import (
+	"time"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/secagg"
+)
+
+// RoundState is the state of a training round.
+type RoundState string
+
+const (
+	// RoundOpen is the state of a round that waits for its first update.
+	RoundOpen RoundState = "open"
+	// RoundCollecting is the state of a round that received updates and waits for more.
+	RoundCollecting RoundState = "collecting"
+	// RoundAggregating is the state of a round whose updates are being combined into a new version.
+	RoundAggregating RoundState = "aggregating"
+	// RoundClosed is the state of a round that produced a new model version.
+	RoundClosed RoundState = "closed"
+	// RoundFailed is the state of a round that missed its quorum or failed to aggregate.
+	RoundFailed RoundState = "failed"
+)
+
+// DefaultMinParticipants is the round quorum used when a model does not configure it.
+const DefaultMinParticipants = 3
+
+// RoundConfig configures the training rounds of a model.
+type RoundConfig struct {
+	// MinParticipants is the number of clients that have to submit an update for a round to be aggregated.
+	// Defaults to DefaultMinParticipants.
+	MinParticipants int `json:"minParticipants"`
+	// MaxParticipants caps the number of updates accepted in a round. The round is aggregated as soon as
+	// the cap is reached. Zero means no cap.
+	MaxParticipants int `json:"maxParticipants"`
+	// DurationSeconds is the time after which a round is aggregated if it reached its quorum, or failed
+	// otherwise. Zero means no deadline, in which case the round is aggregated as soon as the quorum is
+	// reached.
+	DurationSeconds int `json:"durationSeconds"`
+}
+
+// Round is a single training round of a model. Clients train on the base version of the round and
+// submit their updates until the round is aggregated into the next version.
+type Round struct {
+	Number          int        `json:"number"`
+	ModelID         string     `json:"modelId"`
+	BaseVersion     int        `json:"baseVersion"`
+	State           RoundState `json:"state"`
+	MinParticipants int        `json:"minParticipants"`
+	MaxParticipants int        `json:"maxParticipants"`
+	Participants    []string   `json:"participants"`
+	// Selected are the clients invited to the round. It is empty if every client may participate.
+	Selected []string `json:"selected,omitempty"`
+	// SecurePhase is the phase of the secure aggregation session of the round. It is empty if updates are
+	// not aggregated securely.
+	SecurePhase secagg.Phase `json:"securePhase,omitempty"`
+	OpenedAt    time.Time    `json:"openedAt"`
+	Deadline    *time.Time   `json:"deadline,omitempty"`
+	ClosedAt    *time.Time   `json:"closedAt,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	// ReceivedBytes is the size of the updates of the round as they were received.
+	ReceivedBytes int64 `json:"receivedBytes,omitempty"`
+	// UpdateBytes is the size of the updates of the round after decompression.
+	UpdateBytes int64 `json:"updateBytes,omitempty"`
+	// CompressionRatio is UpdateBytes divided by ReceivedBytes.
+	CompressionRatio float64 `json:"compressionRatio,omitempty"`
+}
+
+// newRound creates an open round training the given version of a model.
+func newRound(number int, model *GlobalModel, config RoundConfig, now time.Time) *Round {
+	round := &Round{
+		Number:          number,
+		ModelID:         model.ID,
+		BaseVersion:     model.Version,
+		State:           RoundOpen,
+		MinParticipants: config.MinParticipants,
+		MaxParticipants: config.MaxParticipants,
+		Participants:    make([]string, 0),
+		OpenedAt:        now,
+	}
+
+	if round.MinParticipants <= 0 {
+		round.MinParticipants = DefaultMinParticipants
+	}
+	if round.MaxParticipants > 0 && round.MaxParticipants < round.MinParticipants {
+		round.MaxParticipants = round.MinParticipants
+	}
+	if config.DurationSeconds > 0 {
+		deadline := now.Add(time.Duration(config.DurationSeconds) * time.Second)
+		round.Deadline = &deadline
+	}
+
+	return round
+}
+
+// Active returns true if the round did not finish yet.
+func (r *Round) Active() bool {
+	return r.State == RoundOpen || r.State == RoundCollecting || r.State == RoundAggregating
+}
+
+// accepting returns true if the round accepts updates.
+func (r *Round) accepting() bool {
+	return r.State == RoundOpen || r.State == RoundCollecting
+}
+
+// addTransfer records the size an update was received with and its size after decompression.
+func (r *Round) addTransfer(received, size int) {
+	r.ReceivedBytes += int64(received)
+	r.UpdateBytes += int64(size)
+	if r.ReceivedBytes > 0 {
+		r.CompressionRatio = float64(r.UpdateBytes) / float64(r.ReceivedBytes)
+	}
+}
+
+// hasParticipant returns true if the client already submitted an update in this round.
+func (r *Round) hasParticipant(clientID string) bool {
+	for _, id := range r.Participants {
+		if id == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSelected returns true if the client was invited to the round.
+func (r *Round) isSelected(clientID string) bool {
+	for _, id := range r.Selected {
+		if id == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// quorum returns true if enough clients submitted updates for the round to be aggregated.
+func (r *Round) quorum() bool {
+	return len(r.Participants) >= r.MinParticipants
+}
+
+// expired returns true if the deadline of the round passed.
+func (r *Round) expired(now time.Time) bool {
+	return r.Deadline != nil && !now.Before(*r.Deadline)
+}
+
+// ready returns true if the round accepting updates should be aggregated now.
+func (r *Round) ready(now time.Time) bool {
+	if r.MaxParticipants > 0 && len(r.Participants) >= r.MaxParticipants {
+		return true
+	}
+	if r.Deadline == nil {
+		return r.quorum()
+	}
+
+	return r.expired(now) && r.quorum()
+}
+
+// close moves the round to a final state.
+func (r *Round) close(state RoundState, now time.Time, reason string) {
+	r.State = state
+	r.ClosedAt = &now
+	r.Error = reason
+}
+
+// copy returns a copy of the round that can be handed out without holding the coordinator lock.
+func (r *Round) copy() *Round {
+	result := *r
+	result.Participants = append(make([]string, 0, len(r.Participants)), r.Participants...)
+	if r.Selected != nil {
+		result.Selected = append(make([]string, 0, len(r.Selected)), r.Selected...)
+	}
+	return &result
+}
@@ -0,0 +1,110 @@
+package federatedlearning
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func submitTestUpdate(c *Coordinator, client string, baseVersion int) error {
+	return c.SubmitModelUpdate(&ModelUpdate{ClientID: client, ModelID: "test", BaseVersion: baseVersion,
+		WeightUpdate: encodeWeights([]float32{1, 1}), NumSamples: 1})
+}
+
+func TestRoundLifecycle(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a", "b")
+
+	round, _ := c.GetRound("test")
+	if round.Number != 1 || round.State != RoundOpen || round.BaseVersion != 1 {
+		t.Fatalf("expected open round 1 of version 1, got %+v", round)
+	}
+
+	if err := submitTestUpdate(c, "a", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	round, _ = c.GetRound("test")
+	if round.State != RoundCollecting {
+		t.Fatalf("expected collecting round, got %s", round.State)
+	}
+
+	if err := submitTestUpdate(c, "a", 1); !errors.Is(err, ErrDuplicateUpdate) {
+		t.Errorf("expected duplicate update error, got %v", err)
+	}
+
+	if err := submitTestUpdate(c, "b", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	round, _ = c.GetRound("test")
+	if round.Number != 2 || round.State != RoundOpen || round.BaseVersion != 2 {
+		t.Fatalf("expected open round 2 of version 2, got %+v", round)
+	}
+
+	if err := submitTestUpdate(c, "a", 1); !errors.Is(err, ErrStaleUpdate) {
+		t.Errorf("expected stale update error, got %v", err)
+	}
+
+	if _, err := c.OpenRound("test", nil); !errors.Is(err, ErrRoundActive) {
+		t.Errorf("expected active round error, got %v", err)
+	}
+}
+
+func TestRoundDeadline(t *testing.T) {
+	cases := []struct {
+		info            string
+		clients         []string
+		expectedState   RoundState
+		expectedVersion int
+	}{
+		{"should fail round without quorum", []string{"a"}, RoundFailed, 1},
+		{"should aggregate round with quorum", []string{"a", "b"}, RoundClosed, 2},
+	}
+
+	for _, tc := range cases {
+		now := time.Now()
+		c := newTestCoordinatorWithConfig(t, RoundConfig{MinParticipants: 2, DurationSeconds: 60}, "a", "b", "c")
+		c.now = func() time.Time { return now }
+		c.rounds["test"] = newRound(1, c.models["test"], RoundConfig{MinParticipants: 2, DurationSeconds: 60}, now)
+
+		for _, client := range tc.clients {
+			if err := submitTestUpdate(c, client, 1); err != nil {
+				t.Fatalf("%s: unexpected error: %v", tc.info, err)
+			}
+		}
+
+		round := c.rounds["test"]
+		if round.State != RoundCollecting {
+			t.Fatalf("%s: expected round to collect until deadline, got %s", tc.info, round.State)
+		}
+
+		now = now.Add(time.Minute)
+		if err := submitTestUpdate(c, "c", 1); !errors.Is(err, ErrRoundNotOpen) {
+			t.Errorf("%s: expected update after deadline to be rejected, got %v", tc.info, err)
+		}
+
+		c.checkRounds()
+		if round.State != tc.expectedState {
+			t.Errorf("%s: expected state %s, got %s", tc.info, tc.expectedState, round.State)
+		}
+		if model, _ := c.GetModel("test"); model.Version != tc.expectedVersion {
+			t.Errorf("%s: expected version %d, got %d", tc.info, tc.expectedVersion, model.Version)
+		}
+		if next := c.rounds["test"]; next.Number != 2 || next.State != RoundOpen {
+			t.Errorf("%s: expected next round to be opened, got %+v", tc.info, next)
+		}
+	}
+}
+
+func TestRoundMaxParticipants(t *testing.T) {
+	c := newTestCoordinatorWithConfig(t, RoundConfig{MinParticipants: 1, MaxParticipants: 2, DurationSeconds: 60},
+		"a", "b")
+
+	for _, client := range []string{"a", "b"} {
+		if err := submitTestUpdate(c, client, 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if model, _ := c.GetModel("test"); model.Version != 2 {
+		t.Errorf("expected round to be aggregated once cap is reached, got version %d", model.Version)
+	}
+}
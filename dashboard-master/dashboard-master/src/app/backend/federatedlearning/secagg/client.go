@@ -0,0 +1,295 @@
+package secagg
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Client runs the client side of a single secure aggregation session. Its methods have to be called in the
+// order of the protocol phases: AdvertiseKeys, ShareKeys, MaskInput and Unmask. A Client must not be reused
+// for another session.
+type Client struct {
+	id string
+	// tag identifies the session. It is bound to every encrypted share so that shares cannot be replayed in
+	// another session.
+	tag string
+
+	encryptionKey *ecdh.PrivateKey
+	maskKey       *ecdh.PrivateKey
+	// seed is the seed of the self mask.
+	seed []byte
+
+	threshold int
+	// peers are the keys of all clients that advertised keys, including this client, by client ID.
+	peers map[string]*AdvertisedKeys
+	// received are the secrets shared with this client by client ID, including its own.
+	received map[string]*sharedSecrets
+	// unmasked is set once the client revealed its unmasking shares.
+	unmasked bool
+}
+
+// NewClient creates a client with the given ID for the session identified by tag, e.g. the model and round.
+func NewClient(id, tag string) (*Client, error) {
+	encryptionKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	maskKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := make([]byte, seedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+
+	return &Client{id: id, tag: tag, encryptionKey: encryptionKey, maskKey: maskKey, seed: seed}, nil
+}
+
+// ID returns the ID of the client.
+func (c *Client) ID() string {
+	return c.id
+}
+
+// AdvertiseKeys returns the public keys of the client to be sent to the coordinator.
+func (c *Client) AdvertiseKeys() *AdvertisedKeys {
+	return &AdvertisedKeys{
+		ClientID:      c.id,
+		EncryptionKey: c.encryptionKey.PublicKey().Bytes(),
+		MaskKey:       c.maskKey.PublicKey().Bytes(),
+	}
+}
+
+// ShareKeys splits the mask key and the self mask seed of the client into shares, any threshold of which
+// recover them, and encrypts one share of each for every other client that advertised keys.
+func (c *Client) ShareKeys(keys []*AdvertisedKeys, threshold int) ([]*EncryptedShare, error) {
+	if c.peers != nil {
+		return nil, fmt.Errorf("keys were already shared")
+	}
+
+	if threshold < 2 || threshold > len(keys) {
+		return nil, fmt.Errorf("threshold %d must be between 2 and the number of clients %d", threshold, len(keys))
+	}
+	if len(keys) > MaxClients {
+		return nil, fmt.Errorf("at most %d clients can take part in a session, got %d", MaxClients, len(keys))
+	}
+
+	peers := make(map[string]*AdvertisedKeys, len(keys))
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, exists := peers[key.ClientID]; exists {
+			return nil, fmt.Errorf("duplicate keys of client %s", key.ClientID)
+		}
+		peers[key.ClientID] = key
+		ids = append(ids, key.ClientID)
+	}
+	if _, exists := peers[c.id]; !exists {
+		return nil, fmt.Errorf("client %s is not part of the session", c.id)
+	}
+
+	sort.Strings(ids)
+	points := make([]byte, len(ids))
+	for i := range ids {
+		points[i] = sharePoint(i)
+	}
+
+	keyShares, err := splitSecret(c.maskKey.Bytes(), threshold, points)
+	if err != nil {
+		return nil, err
+	}
+	seedShares, err := splitSecret(c.seed, threshold, points)
+	if err != nil {
+		return nil, err
+	}
+
+	c.threshold = threshold
+	c.peers = peers
+	c.received = make(map[string]*sharedSecrets, len(ids))
+
+	shares := make([]*EncryptedShare, 0, len(ids)-1)
+	for i, id := range ids {
+		secrets := &sharedSecrets{From: c.id, To: id, KeyShare: keyShares[i], SeedShare: seedShares[i]}
+		if id == c.id {
+			c.received[c.id] = secrets
+			continue
+		}
+
+		share, err := c.encryptShare(secrets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt share for client %s: %v", id, err)
+		}
+		shares = append(shares, share)
+	}
+
+	return shares, nil
+}
+
+// MaskInput decrypts the shares sent to the client and masks its input. The input is masked with pairwise
+// masks agreed with every client whose share was received, so all of them have to be passed.
+func (c *Client) MaskInput(shares []*EncryptedShare, input []uint64) (*MaskedInput, error) {
+	if c.peers == nil {
+		return nil, fmt.Errorf("keys were not shared")
+	}
+	if len(c.received) > 1 {
+		return nil, fmt.Errorf("input was already masked")
+	}
+
+	for _, share := range shares {
+		if _, exists := c.peers[share.From]; !exists || share.To != c.id || share.From == c.id {
+			return nil, fmt.Errorf("unexpected share from %s to %s", share.From, share.To)
+		}
+		if _, exists := c.received[share.From]; exists {
+			return nil, fmt.Errorf("duplicate share from client %s", share.From)
+		}
+
+		secrets, err := c.decryptShare(share)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt share from client %s: %v", share.From, err)
+		}
+		c.received[share.From] = secrets
+	}
+
+	if len(c.received) < c.threshold {
+		return nil, fmt.Errorf("received shares of %d clients, the threshold is %d", len(c.received), c.threshold)
+	}
+
+	masked := append(make([]uint64, 0, len(input)), input...)
+	if err := addMask(masked, c.seed, 1); err != nil {
+		return nil, err
+	}
+
+	for id := range c.received {
+		if id == c.id {
+			continue
+		}
+
+		seed, err := agreeKey(c.maskKey, c.peers[id].MaskKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to agree on mask with client %s: %v", id, err)
+		}
+		if err := addMask(masked, seed, pairwiseSign(c.id, id)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &MaskedInput{ClientID: c.id, Masked: masked}, nil
+}
+
+// Unmask reveals the shares needed to remove the masks of the given survivors, the clients whose masked
+// input was received by the coordinator. Shares of the self mask seeds of survivors and of the mask keys of
+// the other clients that shared their keys are revealed, never both for the same client.
+func (c *Client) Unmask(survivors []string) (*UnmaskingShares, error) {
+	if len(c.received) <= 1 {
+		return nil, fmt.Errorf("input was not masked")
+	}
+	if c.unmasked {
+		return nil, fmt.Errorf("unmasking shares were already revealed")
+	}
+
+	alive := make(map[string]bool, len(survivors))
+	for _, id := range survivors {
+		if _, exists := c.received[id]; !exists {
+			return nil, fmt.Errorf("survivor %s did not share its keys", id)
+		}
+		alive[id] = true
+	}
+	if !alive[c.id] {
+		return nil, fmt.Errorf("client %s is not a survivor", c.id)
+	}
+	if len(alive) < c.threshold {
+		return nil, fmt.Errorf("%d survivors are less than the threshold %d", len(alive), c.threshold)
+	}
+
+	result := &UnmaskingShares{ClientID: c.id, SeedShares: make(map[string]Share),
+		KeyShares: make(map[string]Share)}
+	for id, secrets := range c.received {
+		if alive[id] {
+			result.SeedShares[id] = secrets.SeedShare
+		} else {
+			result.KeyShares[id] = secrets.KeyShare
+		}
+	}
+
+	c.unmasked = true
+	return result, nil
+}
+
+func (c *Client) encryptShare(secrets *sharedSecrets) (*EncryptedShare, error) {
+	key, err := agreeKey(c.encryptionKey, c.peers[secrets.To].EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := encrypt(key, plaintext, c.additionalData(secrets.From, secrets.To))
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedShare{From: secrets.From, To: secrets.To, Ciphertext: ciphertext}, nil
+}
+
+func (c *Client) decryptShare(share *EncryptedShare) (*sharedSecrets, error) {
+	key, err := agreeKey(c.encryptionKey, c.peers[share.From].EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := decrypt(key, share.Ciphertext, c.additionalData(share.From, share.To))
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := new(sharedSecrets)
+	if err := json.Unmarshal(plaintext, secrets); err != nil {
+		return nil, err
+	}
+	if secrets.From != share.From || secrets.To != share.To {
+		return nil, fmt.Errorf("share was addressed from %s to %s", secrets.From, secrets.To)
+	}
+
+	return secrets, nil
+}
+
+// additionalData binds an encrypted share to its sender, recipient and session.
+func (c *Client) additionalData(from, to string) []byte {
+	data, _ := json.Marshal([]string{from, to, c.tag})
+	return data
+}
+
+// pairwiseSign returns the sign the pairwise mask of two clients is added with by the first one. The masks
+// cancel out because the client with the lower ID adds it and the other one subtracts it.
+func pairwiseSign(id, peer string) int {
+	if id < peer {
+		return 1
+	}
+
+	return -1
+}
+
+// addMask adds or subtracts the mask expanded from seed to values, depending on sign.
+func addMask(values []uint64, seed []byte, sign int) error {
+	mask, err := expandMask(seed, len(values))
+	if err != nil {
+		return err
+	}
+
+	for i := range values {
+		if sign > 0 {
+			values[i] += mask[i]
+		} else {
+			values[i] -= mask[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package secagg
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// seedSize is the size of mask seeds and of derived symmetric keys.
+const seedSize = 32
+
+// agreeKey derives a symmetric key from an X25519 key agreement. Both parties of a pair derive the same key.
+func agreeKey(private *ecdh.PrivateKey, peerPublic []byte) ([]byte, error) {
+	public, err := ecdh.X25519().NewPublicKey(peerPublic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %v", err)
+	}
+
+	secret, err := private.ECDH(public)
+	if err != nil {
+		return nil, err
+	}
+
+	key := sha256.Sum256(secret)
+	return key[:], nil
+}
+
+// expandMask expands a seed into a pseudorandom vector of the given length with AES-256 in counter mode.
+func expandMask(seed []byte, length int) ([]uint64, error) {
+	block, err := aes.NewCipher(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := make([]byte, length*8)
+	cipher.NewCTR(block, make([]byte, aes.BlockSize)).XORKeyStream(stream, stream)
+
+	mask := make([]uint64, length)
+	for i := range mask {
+		mask[i] = binary.LittleEndian.Uint64(stream[i*8:])
+	}
+
+	return mask, nil
+}
+
+// encrypt seals plaintext with AES-256-GCM. The random nonce is prepended to the ciphertext.
+func encrypt(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// decrypt opens a ciphertext created by encrypt.
+func decrypt(key, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,114 @@
+package secagg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// httpTransport implements Transport on top of the HTTP API of the federated learning coordinator.
+type httpTransport struct {
+	client   *http.Client
+	endpoint string
+	clientID string
+}
+
+// NewHTTPTransport creates a Transport for the secure aggregation sessions of a model served by the
+// coordinator at baseURL, e.g. "http://kubernetes-dashboard/api/v1". The HTTP client is responsible for
+// authenticating the requests.
+func NewHTTPTransport(client *http.Client, baseURL, modelID, clientID string) Transport {
+	return &httpTransport{
+		client:   client,
+		endpoint: strings.TrimSuffix(baseURL, "/") + "/fl/model/" + url.PathEscape(modelID) + "/secagg",
+		clientID: clientID,
+	}
+}
+
+// State implements Transport interface. See Transport for more information.
+func (t *httpTransport) State(ctx context.Context) (*State, error) {
+	state := new(State)
+	if err := t.do(ctx, http.MethodGet, "", -1, nil, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// SendKeys implements Transport interface. See Transport for more information.
+func (t *httpTransport) SendKeys(ctx context.Context, round int, keys *AdvertisedKeys) error {
+	return t.do(ctx, http.MethodPost, "/keys", round, keys, nil)
+}
+
+// SendShares implements Transport interface. See Transport for more information.
+func (t *httpTransport) SendShares(ctx context.Context, round int, shares []*EncryptedShare) error {
+	return t.do(ctx, http.MethodPost, "/shares", round, shares, nil)
+}
+
+// ReceiveShares implements Transport interface. See Transport for more information.
+func (t *httpTransport) ReceiveShares(ctx context.Context, round int) ([]*EncryptedShare, error) {
+	shares := make([]*EncryptedShare, 0)
+	if err := t.do(ctx, http.MethodGet, "/shares", round, nil, &shares); err != nil {
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+// SendMasked implements Transport interface. See Transport for more information.
+func (t *httpTransport) SendMasked(ctx context.Context, round int, input *MaskedInput) error {
+	return t.do(ctx, http.MethodPost, "/masked", round, input, nil)
+}
+
+// SendUnmasking implements Transport interface. See Transport for more information.
+func (t *httpTransport) SendUnmasking(ctx context.Context, round int, shares *UnmaskingShares) error {
+	return t.do(ctx, http.MethodPost, "/unmask", round, shares, nil)
+}
+
+// do sends a request for the given round, or for the current round if round is negative, and decodes the
+// JSON response into out unless it is nil.
+func (t *httpTransport) do(ctx context.Context, method, path string, round int, in, out interface{}) error {
+	query := url.Values{"clientId": {t.clientID}}
+	if round >= 0 {
+		query.Set("round", strconv.Itoa(round))
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, t.endpoint+path+"?"+query.Encode(), body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: %s: %s", method, req.URL.Path, resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,152 @@
+package secagg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultPollInterval is the interval in which a Participant polls the state of a session when it does not
+// configure one.
+const DefaultPollInterval = time.Second
+
+// ErrSessionAborted is returned by Participant when the session it takes part in was abandoned by the
+// coordinator, or moved on without it.
+var ErrSessionAborted = errors.New("secure aggregation session aborted")
+
+// State is the state of the secure aggregation session of a model as reported by the coordinator.
+type State struct {
+	ModelID string `json:"modelId"`
+	// Round is the training round the session aggregates.
+	Round int `json:"round"`
+	// BaseVersion is the model version the aggregated updates have to be trained on.
+	BaseVersion int   `json:"baseVersion"`
+	Phase       Phase `json:"phase"`
+	Threshold   int   `json:"threshold"`
+	// Keys are the keys of all clients that joined the session. They are set once the keys phase closed.
+	Keys []*AdvertisedKeys `json:"keys,omitempty"`
+	// Survivors are the clients whose masked input was received. They are set in the unmask phase.
+	Survivors []string `json:"survivors,omitempty"`
+}
+
+// Transport carries the messages of a Participant to the coordinator. Messages are sent for a given round,
+// the coordinator rejects messages for rounds other than the current one.
+type Transport interface {
+	// State returns the state of the current session.
+	State(ctx context.Context) (*State, error)
+	SendKeys(ctx context.Context, round int, keys *AdvertisedKeys) error
+	SendShares(ctx context.Context, round int, shares []*EncryptedShare) error
+	// ReceiveShares returns the shares other clients sent to the participant.
+	ReceiveShares(ctx context.Context, round int) ([]*EncryptedShare, error)
+	SendMasked(ctx context.Context, round int, input *MaskedInput) error
+	SendUnmasking(ctx context.Context, round int, shares *UnmaskingShares) error
+}
+
+// Participant takes part in secure aggregation sessions on behalf of a client.
+type Participant struct {
+	Transport Transport
+	ClientID  string
+	// PollInterval is the interval in which the session state is polled. Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+}
+
+// Participate contributes an input computed on the given base version to the current session and runs all
+// protocol phases of the client. The session has to be in the keys phase. It returns once the unmasking
+// shares were revealed, the sum is recovered by the coordinator afterwards.
+func (p *Participant) Participate(ctx context.Context, baseVersion int, input []uint64) error {
+	state, err := p.Transport.State(ctx)
+	if err != nil {
+		return err
+	}
+	if state.Phase != PhaseKeys {
+		return fmt.Errorf("%w: round %d is in the %s phase", ErrSessionAborted, state.Round, state.Phase)
+	}
+	if state.BaseVersion != baseVersion {
+		return fmt.Errorf("%w: round %d aggregates updates of version %d, not %d", ErrSessionAborted,
+			state.Round, state.BaseVersion, baseVersion)
+	}
+
+	round := state.Round
+	client, err := NewClient(p.ClientID, fmt.Sprintf("%s/%d", state.ModelID, round))
+	if err != nil {
+		return err
+	}
+
+	if err := p.Transport.SendKeys(ctx, round, client.AdvertiseKeys()); err != nil {
+		return fmt.Errorf("failed to send keys: %w", err)
+	}
+
+	if state, err = p.await(ctx, round, PhaseShares); err != nil {
+		return err
+	}
+	shares, err := client.ShareKeys(state.Keys, state.Threshold)
+	if err != nil {
+		return err
+	}
+	if err := p.Transport.SendShares(ctx, round, shares); err != nil {
+		return fmt.Errorf("failed to send shares: %w", err)
+	}
+
+	if _, err = p.await(ctx, round, PhaseMasked); err != nil {
+		return err
+	}
+	if shares, err = p.Transport.ReceiveShares(ctx, round); err != nil {
+		return fmt.Errorf("failed to receive shares: %w", err)
+	}
+	masked, err := client.MaskInput(shares, input)
+	if err != nil {
+		return err
+	}
+	if err := p.Transport.SendMasked(ctx, round, masked); err != nil {
+		return fmt.Errorf("failed to send masked input: %w", err)
+	}
+
+	if state, err = p.await(ctx, round, PhaseUnmask); err != nil {
+		return err
+	}
+	unmasking, err := client.Unmask(state.Survivors)
+	if err != nil {
+		return err
+	}
+	if err := p.Transport.SendUnmasking(ctx, round, unmasking); err != nil {
+		return fmt.Errorf("failed to send unmasking shares: %w", err)
+	}
+
+	return nil
+}
+
+// phaseOrder orders the phases of a session.
+var phaseOrder = map[Phase]int{PhaseKeys: 0, PhaseShares: 1, PhaseMasked: 2, PhaseUnmask: 3, PhaseDone: 4}
+
+// await polls the session state until the session of the given round reaches a phase.
+func (p *Participant) await(ctx context.Context, round int, phase Phase) (*State, error) {
+	interval := p.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	for {
+		state, err := p.Transport.State(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if state.Round != round {
+			return nil, fmt.Errorf("%w: round %d ended", ErrSessionAborted, round)
+		}
+		if state.Phase == phase {
+			return state, nil
+		}
+		if phaseOrder[state.Phase] > phaseOrder[phase] {
+			return nil, fmt.Errorf("%w: round %d moved to the %s phase without the client", ErrSessionAborted,
+				round, state.Phase)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
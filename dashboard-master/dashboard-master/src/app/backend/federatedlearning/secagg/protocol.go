@@ -0,0 +1,122 @@
+// Package secagg implements secure aggregation of federated learning updates with pairwise masking as
+// described by Bonawitz et al., "Practical Secure Aggregation for Privacy-Preserving Machine Learning".
+//
+// Every client masks its input with a self mask and with masks it agrees on with every other client. The
+// pairwise masks cancel out in the sum, and the seeds of the self masks and the mask keys of clients that
+// drop out are secret shared among the clients, so that the coordinator can remove the remaining masks
+// with the help of any threshold of clients. The coordinator only ever learns the sum of the inputs of the
+// clients that completed the protocol. The protocol protects against an honest but curious coordinator.
+//
+// Inputs are vectors of 64-bit integers that are summed modulo 2^64. EncodeInput and DecodeSum convert
+// model weights to and from a fixed point representation that also carries the sample count of every
+// client, so that the sum yields the sample weighted average of the weights.
+package secagg
+
+import (
+	"fmt"
+	"math"
+)
+
+// Phase is a phase of the protocol.
+type Phase string
+
+const (
+	// PhaseKeys collects the public keys of the participating clients.
+	PhaseKeys Phase = "keys"
+	// PhaseShares collects the encrypted secret shares clients send each other through the coordinator.
+	PhaseShares Phase = "shares"
+	// PhaseMasked collects the masked inputs.
+	PhaseMasked Phase = "masked"
+	// PhaseUnmask collects the secret shares needed to remove the masks.
+	PhaseUnmask Phase = "unmask"
+	// PhaseDone is the phase of a session whose sum was recovered.
+	PhaseDone Phase = "done"
+)
+
+// FixedPointScale is the factor weights are scaled with before they are rounded to integers.
+const FixedPointScale = 1 << 20
+
+// AdvertisedKeys are the public keys of a client. The encryption key is used to encrypt secret shares
+// sent to the client, the mask key to agree on pairwise masks.
+type AdvertisedKeys struct {
+	ClientID      string `json:"clientId"`
+	EncryptionKey []byte `json:"encryptionKey"`
+	MaskKey       []byte `json:"maskKey"`
+}
+
+// EncryptedShare carries the shares of the mask key and of the self mask seed of a client, encrypted for
+// another client.
+type EncryptedShare struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// MaskedInput is the masked input of a client.
+type MaskedInput struct {
+	ClientID string   `json:"clientId"`
+	Masked   []uint64 `json:"masked"`
+}
+
+// UnmaskingShares are the shares a client reveals to remove masks. It reveals shares of the self mask seeds
+// of the survivors, and shares of the mask keys of clients that dropped out after sharing their keys.
+type UnmaskingShares struct {
+	ClientID string `json:"clientId"`
+	// SeedShares are shares of self mask seeds by client ID.
+	SeedShares map[string]Share `json:"seedShares"`
+	// KeyShares are shares of mask keys by client ID.
+	KeyShares map[string]Share `json:"keyShares"`
+}
+
+// sharedSecrets is the plaintext of an EncryptedShare.
+type sharedSecrets struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	KeyShare  Share  `json:"keyShare"`
+	SeedShare Share  `json:"seedShare"`
+}
+
+// EncodeInput converts weights trained on numSamples samples to an input whose sum over all clients
+// decodes to the sample weighted average of the weights. The last element carries the sample count.
+func EncodeInput(weights []float32, numSamples int) []uint64 {
+	if numSamples <= 0 {
+		numSamples = 1
+	}
+
+	input := make([]uint64, len(weights)+1)
+	for i, w := range weights {
+		input[i] = uint64(int64(math.Round(float64(w) * float64(numSamples) * FixedPointScale)))
+	}
+	input[len(weights)] = uint64(numSamples)
+
+	return input
+}
+
+// DecodeSum converts the sum of inputs created by EncodeInput to the sample weighted average of the weights
+// and the total number of samples.
+func DecodeSum(sum []uint64) ([]float32, int, error) {
+	if len(sum) == 0 {
+		return nil, 0, fmt.Errorf("empty sum")
+	}
+
+	samples := int64(sum[len(sum)-1])
+	if samples <= 0 {
+		return nil, 0, fmt.Errorf("invalid sample count %d", samples)
+	}
+
+	weights := make([]float32, len(sum)-1)
+	for i := range weights {
+		weights[i] = float32(float64(int64(sum[i])) / FixedPointScale / float64(samples))
+	}
+
+	return weights, int(samples), nil
+}
+
+// sharePoint returns the evaluation point of the secret shares held by the client at the given index of
+// the sorted list of participants.
+func sharePoint(index int) byte {
+	return byte(index + 1)
+}
+
+// MaxClients is the maximum number of clients of a session, limited by the number of share points.
+const MaxClients = 255
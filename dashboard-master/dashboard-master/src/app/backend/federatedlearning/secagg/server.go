@@ -0,0 +1,367 @@
+package secagg
+
+import (
+	"crypto/ecdh"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrWrongPhase is returned when a message of another phase than the current one is sent to a Session.
+var ErrWrongPhase = errors.New("message does not belong to the current phase")
+
+// Session runs the coordinator side of a single secure aggregation session. It only relays encrypted
+// shares and never learns individual inputs, only their sum. A Session is not safe for concurrent use.
+type Session struct {
+	threshold int
+	phase     Phase
+
+	// keys are the advertised keys of all clients that joined the session, the first set of clients.
+	keys map[string]*AdvertisedKeys
+	// shares are the encrypted shares by recipient and sender. Clients that sent shares form the second set.
+	shares  map[string]map[string]*EncryptedShare
+	senders map[string]bool
+	// masked are the masked inputs by client ID. Clients that sent a masked input are the survivors.
+	masked map[string][]uint64
+	// unmasking are the unmasking shares by client ID.
+	unmasking map[string]*UnmaskingShares
+}
+
+// NewSession creates a session in which the masks of a client can be removed with the help of any threshold
+// of clients. The threshold has to be at least 2.
+func NewSession(threshold int) (*Session, error) {
+	if threshold < 2 || threshold > MaxClients {
+		return nil, fmt.Errorf("threshold %d must be between 2 and %d", threshold, MaxClients)
+	}
+
+	return &Session{
+		threshold: threshold,
+		phase:     PhaseKeys,
+		keys:      make(map[string]*AdvertisedKeys),
+		shares:    make(map[string]map[string]*EncryptedShare),
+		senders:   make(map[string]bool),
+		masked:    make(map[string][]uint64),
+		unmasking: make(map[string]*UnmaskingShares),
+	}, nil
+}
+
+// Phase returns the current phase of the session.
+func (s *Session) Phase() Phase {
+	return s.phase
+}
+
+// Threshold returns the number of clients needed to remove masks.
+func (s *Session) Threshold() int {
+	return s.threshold
+}
+
+// Responses returns the number of clients that sent their message of the current phase.
+func (s *Session) Responses() int {
+	switch s.phase {
+	case PhaseKeys:
+		return len(s.keys)
+	case PhaseShares:
+		return len(s.senders)
+	case PhaseMasked:
+		return len(s.masked)
+	case PhaseUnmask:
+		return len(s.unmasking)
+	}
+
+	return 0
+}
+
+// Complete returns true if every client expected in the current phase sent its message. The keys phase is
+// never complete, it has to be closed explicitly.
+func (s *Session) Complete() bool {
+	switch s.phase {
+	case PhaseShares:
+		return len(s.senders) == len(s.keys)
+	case PhaseMasked:
+		return len(s.masked) == len(s.senders)
+	case PhaseUnmask:
+		return len(s.unmasking) == len(s.masked)
+	}
+
+	return false
+}
+
+// Expects returns true if the client is expected to send a message in the current phase.
+func (s *Session) Expects(clientID string) bool {
+	switch s.phase {
+	case PhaseKeys:
+		_, exists := s.keys[clientID]
+		return !exists && len(s.keys) < MaxClients
+	case PhaseShares:
+		_, exists := s.keys[clientID]
+		return exists && !s.senders[clientID]
+	case PhaseMasked:
+		_, exists := s.masked[clientID]
+		return s.senders[clientID] && !exists
+	case PhaseUnmask:
+		_, masked := s.masked[clientID]
+		_, exists := s.unmasking[clientID]
+		return masked && !exists
+	}
+
+	return false
+}
+
+// Keys returns the keys of all clients that joined the session, sorted by client ID. Clients need them to
+// share their keys once the keys phase was closed.
+func (s *Session) Keys() []*AdvertisedKeys {
+	keys := make([]*AdvertisedKeys, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ClientID < keys[j].ClientID })
+
+	return keys
+}
+
+// Survivors returns the sorted IDs of the clients whose masked input was received.
+func (s *Session) Survivors() []string {
+	survivors := make([]string, 0, len(s.masked))
+	for id := range s.masked {
+		survivors = append(survivors, id)
+	}
+	sort.Strings(survivors)
+
+	return survivors
+}
+
+// AddKeys adds the advertised keys of a client joining the session.
+func (s *Session) AddKeys(keys *AdvertisedKeys) error {
+	if s.phase != PhaseKeys {
+		return fmt.Errorf("%w: the session is in the %s phase", ErrWrongPhase, s.phase)
+	}
+	if _, exists := s.keys[keys.ClientID]; exists {
+		return fmt.Errorf("client %s already advertised keys", keys.ClientID)
+	}
+	if len(s.keys) >= MaxClients {
+		return fmt.Errorf("session is full")
+	}
+
+	for _, key := range [][]byte{keys.EncryptionKey, keys.MaskKey} {
+		if _, err := ecdh.X25519().NewPublicKey(key); err != nil {
+			return fmt.Errorf("invalid public key of client %s: %v", keys.ClientID, err)
+		}
+	}
+
+	s.keys[keys.ClientID] = keys
+	return nil
+}
+
+// AddShares adds the encrypted shares a client sends to all other clients that joined the session.
+func (s *Session) AddShares(clientID string, shares []*EncryptedShare) error {
+	if s.phase != PhaseShares {
+		return fmt.Errorf("%w: the session is in the %s phase", ErrWrongPhase, s.phase)
+	}
+	if !s.Expects(clientID) {
+		return fmt.Errorf("client %s did not join the session or already sent its shares", clientID)
+	}
+
+	recipients := make(map[string]*EncryptedShare, len(shares))
+	for _, share := range shares {
+		_, exists := s.keys[share.To]
+		if share.From != clientID || share.To == clientID || !exists || recipients[share.To] != nil {
+			return fmt.Errorf("invalid share from %s to %s", share.From, share.To)
+		}
+		recipients[share.To] = share
+	}
+	if len(recipients) != len(s.keys)-1 {
+		return fmt.Errorf("client %s sent %d shares, expected %d", clientID, len(recipients), len(s.keys)-1)
+	}
+
+	for to, share := range recipients {
+		if s.shares[to] == nil {
+			s.shares[to] = make(map[string]*EncryptedShare)
+		}
+		s.shares[to][clientID] = share
+	}
+	s.senders[clientID] = true
+
+	return nil
+}
+
+// SharesFor returns the shares sent to a client by all clients that sent their shares. It is available once
+// the shares phase was closed.
+func (s *Session) SharesFor(clientID string) ([]*EncryptedShare, error) {
+	if s.phase != PhaseMasked {
+		return nil, fmt.Errorf("%w: the session is in the %s phase", ErrWrongPhase, s.phase)
+	}
+	if !s.senders[clientID] {
+		return nil, fmt.Errorf("client %s did not send its shares", clientID)
+	}
+
+	shares := make([]*EncryptedShare, 0, len(s.shares[clientID]))
+	for from, share := range s.shares[clientID] {
+		if s.senders[from] {
+			shares = append(shares, share)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].From < shares[j].From })
+
+	return shares, nil
+}
+
+// AddMasked adds the masked input of a client. All masked inputs must have the same length.
+func (s *Session) AddMasked(input *MaskedInput) error {
+	if s.phase != PhaseMasked {
+		return fmt.Errorf("%w: the session is in the %s phase", ErrWrongPhase, s.phase)
+	}
+	if !s.Expects(input.ClientID) {
+		return fmt.Errorf("client %s did not send its shares or already sent its input", input.ClientID)
+	}
+	if len(input.Masked) == 0 {
+		return fmt.Errorf("masked input of client %s is empty", input.ClientID)
+	}
+	for _, other := range s.masked {
+		if len(other) != len(input.Masked) {
+			return fmt.Errorf("masked input of client %s has length %d, expected %d", input.ClientID,
+				len(input.Masked), len(other))
+		}
+		break
+	}
+
+	s.masked[input.ClientID] = input.Masked
+	return nil
+}
+
+// AddUnmasking adds the unmasking shares of a survivor. It has to reveal seed shares of exactly the
+// survivors and key shares of exactly the clients that dropped out after sending their shares.
+func (s *Session) AddUnmasking(shares *UnmaskingShares) error {
+	if s.phase != PhaseUnmask {
+		return fmt.Errorf("%w: the session is in the %s phase", ErrWrongPhase, s.phase)
+	}
+	if !s.Expects(shares.ClientID) {
+		return fmt.Errorf("client %s is not a survivor or already sent its shares", shares.ClientID)
+	}
+
+	dropped := 0
+	for id := range s.senders {
+		_, survived := s.masked[id]
+		_, seed := shares.SeedShares[id]
+		_, key := shares.KeyShares[id]
+		if survived != seed || survived == key {
+			return fmt.Errorf("client %s revealed unexpected shares of client %s", shares.ClientID, id)
+		}
+		if !survived {
+			dropped++
+		}
+	}
+	if len(shares.SeedShares) != len(s.masked) || len(shares.KeyShares) != dropped {
+		return fmt.Errorf("client %s revealed shares of unknown clients", shares.ClientID)
+	}
+
+	s.unmasking[shares.ClientID] = shares
+	return nil
+}
+
+// Close closes the current phase and moves the session to the next one. It fails if less clients than the
+// threshold responded in the phase, in which case the sum cannot be recovered anymore.
+func (s *Session) Close() error {
+	if s.phase == PhaseUnmask || s.phase == PhaseDone {
+		return fmt.Errorf("%w: the %s phase is closed by computing the sum", ErrWrongPhase, s.phase)
+	}
+
+	if responses := s.Responses(); responses < s.threshold {
+		return fmt.Errorf("%d clients responded in the %s phase, the threshold is %d", responses, s.phase,
+			s.threshold)
+	}
+
+	switch s.phase {
+	case PhaseKeys:
+		s.phase = PhaseShares
+	case PhaseShares:
+		s.phase = PhaseMasked
+	case PhaseMasked:
+		s.phase = PhaseUnmask
+	}
+
+	return nil
+}
+
+// Sum removes the masks from the sum of the masked inputs and closes the session. It needs the unmasking
+// shares of at least threshold survivors.
+func (s *Session) Sum() ([]uint64, error) {
+	if s.phase != PhaseUnmask {
+		return nil, fmt.Errorf("%w: the session is in the %s phase", ErrWrongPhase, s.phase)
+	}
+	if len(s.unmasking) < s.threshold {
+		return nil, fmt.Errorf("%d clients revealed unmasking shares, the threshold is %d", len(s.unmasking),
+			s.threshold)
+	}
+
+	survivors := s.Survivors()
+	sum := make([]uint64, len(s.masked[survivors[0]]))
+	for _, masked := range s.masked {
+		for i, value := range masked {
+			sum[i] += value
+		}
+	}
+
+	for _, id := range survivors {
+		seed, err := s.reconstruct(func(shares *UnmaskingShares) Share { return shares.SeedShares[id] })
+		if err != nil {
+			return nil, fmt.Errorf("failed to recover self mask of client %s: %v", id, err)
+		}
+		if err := addMask(sum, seed, -1); err != nil {
+			return nil, err
+		}
+	}
+
+	for dropped := range s.senders {
+		if _, survived := s.masked[dropped]; survived {
+			continue
+		}
+
+		if err := s.removePairwiseMasks(sum, dropped, survivors); err != nil {
+			return nil, err
+		}
+	}
+
+	s.phase = PhaseDone
+	return sum, nil
+}
+
+// removePairwiseMasks removes the masks survivors agreed with a client that dropped out after sending its
+// shares. They do not cancel out because the client never sent its masked input.
+func (s *Session) removePairwiseMasks(sum []uint64, dropped string, survivors []string) error {
+	secret, err := s.reconstruct(func(shares *UnmaskingShares) Share { return shares.KeyShares[dropped] })
+	if err != nil {
+		return fmt.Errorf("failed to recover mask key of client %s: %v", dropped, err)
+	}
+
+	key, err := ecdh.X25519().NewPrivateKey(secret)
+	if err != nil {
+		return fmt.Errorf("failed to recover mask key of client %s: %v", dropped, err)
+	}
+	if string(key.PublicKey().Bytes()) != string(s.keys[dropped].MaskKey) {
+		return fmt.Errorf("recovered mask key of client %s does not match its advertised key", dropped)
+	}
+
+	for _, id := range survivors {
+		seed, err := agreeKey(key, s.keys[id].MaskKey)
+		if err != nil {
+			return err
+		}
+
+		// The survivor added the mask with its own sign, remove it with the opposite one.
+		if err := addMask(sum, seed, -pairwiseSign(id, dropped)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reconstruct combines the shares of a secret revealed by all clients that sent unmasking shares.
+func (s *Session) reconstruct(share func(*UnmaskingShares) Share) ([]byte, error) {
+	shares := make([]Share, 0, len(s.unmasking))
+	for _, unmasking := range s.unmasking {
+		shares = append(shares, share(unmasking))
+	}
+
+	return combineShares(shares)
+}
@@ -0,0 +1,172 @@
+package secagg
+
+import (
+	"math"
+	"testing"
+)
+
+// runSession runs a session with the given clients. Clients in dropAfterShares do not send their masked
+// input, clients in dropAfterMasking do not send their unmasking shares.
+func runSession(t *testing.T, threshold int, inputs map[string][]uint64, dropAfterShares,
+	dropAfterMasking map[string]bool) []uint64 {
+	session, err := NewSession(threshold)
+	if err != nil {
+		t.Fatalf("NewSession(): unexpected error: %v", err)
+	}
+
+	clients := make(map[string]*Client)
+	for id := range inputs {
+		if clients[id], err = NewClient(id, "test/1"); err != nil {
+			t.Fatalf("NewClient(): unexpected error: %v", err)
+		}
+		if err := session.AddKeys(clients[id].AdvertiseKeys()); err != nil {
+			t.Fatalf("AddKeys(): unexpected error: %v", err)
+		}
+	}
+	mustClose(t, session)
+
+	for id, client := range clients {
+		shares, err := client.ShareKeys(session.Keys(), session.Threshold())
+		if err != nil {
+			t.Fatalf("ShareKeys(): unexpected error: %v", err)
+		}
+		if err := session.AddShares(id, shares); err != nil {
+			t.Fatalf("AddShares(): unexpected error: %v", err)
+		}
+	}
+	if !session.Complete() {
+		t.Fatal("expected shares phase to be complete")
+	}
+	mustClose(t, session)
+
+	for id, client := range clients {
+		if dropAfterShares[id] {
+			continue
+		}
+
+		shares, err := session.SharesFor(id)
+		if err != nil {
+			t.Fatalf("SharesFor(): unexpected error: %v", err)
+		}
+		masked, err := client.MaskInput(shares, inputs[id])
+		if err != nil {
+			t.Fatalf("MaskInput(): unexpected error: %v", err)
+		}
+		if err := session.AddMasked(masked); err != nil {
+			t.Fatalf("AddMasked(): unexpected error: %v", err)
+		}
+	}
+	mustClose(t, session)
+
+	for id, client := range clients {
+		if dropAfterShares[id] || dropAfterMasking[id] {
+			continue
+		}
+
+		unmasking, err := client.Unmask(session.Survivors())
+		if err != nil {
+			t.Fatalf("Unmask(): unexpected error: %v", err)
+		}
+		if err := session.AddUnmasking(unmasking); err != nil {
+			t.Fatalf("AddUnmasking(): unexpected error: %v", err)
+		}
+	}
+
+	sum, err := session.Sum()
+	if err != nil {
+		t.Fatalf("Sum(): unexpected error: %v", err)
+	}
+
+	return sum
+}
+
+func mustClose(t *testing.T, session *Session) {
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close(): unexpected error in %s phase: %v", session.Phase(), err)
+	}
+}
+
+func TestSessionSum(t *testing.T) {
+	inputs := map[string][]uint64{
+		"a": EncodeInput([]float32{1, 2}, 1),
+		"b": EncodeInput([]float32{2, -4}, 1),
+		"c": EncodeInput([]float32{4, 8}, 2),
+		"d": EncodeInput([]float32{-100, 100}, 4),
+	}
+
+	cases := []struct {
+		info             string
+		threshold        int
+		dropAfterShares  map[string]bool
+		dropAfterMasking map[string]bool
+		expected         []float32
+		expectedSamples  int
+	}{
+		{"sum of all inputs should be recovered", 3, nil, nil, []float32{-48.625, 51.75}, 8},
+		{"masks of dropped client should be removed", 3, map[string]bool{"d": true}, nil, []float32{2.75, 3.5}, 4},
+		{"survivors that do not unmask should be tolerated", 2, map[string]bool{"d": true},
+			map[string]bool{"a": true}, []float32{2.75, 3.5}, 4},
+	}
+
+	for _, tc := range cases {
+		sum := runSession(t, tc.threshold, inputs, tc.dropAfterShares, tc.dropAfterMasking)
+		actual, samples, err := DecodeSum(sum)
+		if err != nil {
+			t.Fatalf("%s: DecodeSum(): unexpected error: %v", tc.info, err)
+		}
+
+		if samples != tc.expectedSamples {
+			t.Errorf("%s: expected %d samples, got %d", tc.info, tc.expectedSamples, samples)
+		}
+		for i := range tc.expected {
+			if math.Abs(float64(actual[i]-tc.expected[i])) > 1e-5 {
+				t.Errorf("%s: expected weights %v, got %v", tc.info, tc.expected, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestSessionMaskedInputHidesInput(t *testing.T) {
+	session, _ := NewSession(2)
+	a, _ := NewClient("a", "test/1")
+	b, _ := NewClient("b", "test/1")
+	session.AddKeys(a.AdvertiseKeys())
+	session.AddKeys(b.AdvertiseKeys())
+	mustClose(t, session)
+
+	sharesA, _ := a.ShareKeys(session.Keys(), 2)
+	sharesB, _ := b.ShareKeys(session.Keys(), 2)
+	session.AddShares("a", sharesA)
+	session.AddShares("b", sharesB)
+	mustClose(t, session)
+
+	input := []uint64{1, 2, 3}
+	received, _ := session.SharesFor("a")
+	masked, err := a.MaskInput(received, input)
+	if err != nil {
+		t.Fatalf("MaskInput(): unexpected error: %v", err)
+	}
+
+	for i := range input {
+		if masked.Masked[i] == input[i] {
+			t.Errorf("expected input to be masked, got %v", masked.Masked)
+		}
+	}
+}
+
+func TestSessionThreshold(t *testing.T) {
+	session, _ := NewSession(3)
+	for _, id := range []string{"a", "b"} {
+		client, _ := NewClient(id, "test/1")
+		session.AddKeys(client.AdvertiseKeys())
+	}
+
+	if err := session.Close(); err == nil {
+		t.Error("expected error when closing keys phase with less clients than the threshold")
+	}
+
+	if _, err := NewSession(1); err == nil {
+		t.Error("expected error when creating session with threshold 1")
+	}
+}
@@ -0,0 +1,119 @@
+package secagg
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// Share is a Shamir secret share. Secrets are shared byte by byte over GF(2^8), X is the evaluation point
+// of the share and Y holds one polynomial value per secret byte.
+type Share struct {
+	X byte   `json:"x"`
+	Y []byte `json:"y"`
+}
+
+// expTable and logTable hold powers and discrete logarithms of the generator 3 of GF(2^8) with the AES
+// polynomial x^8 + x^4 + x^3 + x + 1.
+var expTable, logTable = func() ([510]byte, [256]byte) {
+	var exp [510]byte
+	var log [256]byte
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i] = x
+		exp[i+255] = x
+		log[x] = byte(i)
+		// Multiply by the generator 3 = x + 1.
+		high := x & 0x80
+		doubled := x << 1
+		if high != 0 {
+			doubled ^= 0x1b
+		}
+		x ^= doubled
+	}
+
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+
+	return expTable[int(logTable[a])+255-int(logTable[b])]
+}
+
+// splitSecret splits secret into shares evaluated at the given points, any threshold of which recover it.
+// Points must be distinct and non-zero.
+func splitSecret(secret []byte, threshold int, points []byte) ([]Share, error) {
+	if threshold < 1 || threshold > len(points) {
+		return nil, fmt.Errorf("threshold %d must be between 1 and the number of shares %d", threshold, len(points))
+	}
+
+	coefficients := make([]byte, threshold-1)
+	shares := make([]Share, len(points))
+	for i, x := range points {
+		if x == 0 {
+			return nil, fmt.Errorf("share point must not be zero")
+		}
+		shares[i] = Share{X: x, Y: make([]byte, len(secret))}
+	}
+
+	for j, b := range secret {
+		if _, err := rand.Read(coefficients); err != nil {
+			return nil, err
+		}
+
+		for i, x := range points {
+			// Horner's scheme, the constant term is the secret byte.
+			y := byte(0)
+			for k := len(coefficients) - 1; k >= 0; k-- {
+				y = gfMul(y, x) ^ coefficients[k]
+			}
+			shares[i].Y[j] = gfMul(y, x) ^ b
+		}
+	}
+
+	return shares, nil
+}
+
+// combineShares recovers a secret from shares by Lagrange interpolation at zero. It has to be given at
+// least as many shares as the threshold the secret was split with.
+func combineShares(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no shares to combine")
+	}
+
+	size := len(shares[0].Y)
+	seen := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if share.X == 0 || seen[share.X] || len(share.Y) != size {
+			return nil, fmt.Errorf("invalid or duplicate share %d", share.X)
+		}
+		seen[share.X] = true
+	}
+
+	secret := make([]byte, size)
+	for i, share := range shares {
+		// Lagrange basis polynomial of the share evaluated at zero.
+		basis := byte(1)
+		for k, other := range shares {
+			if k != i {
+				basis = gfMul(basis, gfDiv(other.X, other.X^share.X))
+			}
+		}
+
+		for j, y := range share.Y {
+			secret[j] ^= gfMul(y, basis)
+		}
+	}
+
+	return secret, nil
+}
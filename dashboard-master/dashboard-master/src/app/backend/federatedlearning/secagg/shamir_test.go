@@ -0,0 +1,39 @@
+package secagg
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestShamir(t *testing.T) {
+	secret := []byte("a secret of some length")
+	shares, err := splitSecret(secret, 3, []byte{1, 2, 3, 4, 5})
+	if err != nil {
+		t.Fatalf("splitSecret(): unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		info     string
+		shares   []Share
+		expected bool
+	}{
+		{"threshold of shares should recover secret", []Share{shares[4], shares[0], shares[2]}, true},
+		{"all shares should recover secret", shares, true},
+		{"less shares than threshold should not recover secret", shares[1:3], false},
+	}
+
+	for _, tc := range cases {
+		actual, err := combineShares(tc.shares)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.info, err)
+		}
+
+		if bytes.Equal(actual, secret) != tc.expected {
+			t.Errorf("%s: expected recovery %v, got %q", tc.info, tc.expected, actual)
+		}
+	}
+
+	if _, err := combineShares([]Share{shares[0], shares[0]}); err == nil {
+		t.Error("expected error when combining duplicate shares")
+	}
+}
@@ -0,0 +1,501 @@
+package federatedlearning
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/secagg"
+)
+
+// DefaultSecurePhaseTimeout is the time after which a phase of a secure aggregation session is closed when a
+// model does not configure it.
+const DefaultSecurePhaseTimeout = time.Minute
+
+// SecureAggregationConfig configures secure aggregation of a model. Clients of secure models do not submit
+// their updates, they take part in a secure aggregation session of every round with the secagg client
+// library, and the coordinator only recovers the sum of their updates. Sessions are kept in memory, a round
+// whose session was interrupted by a restart of the coordinator fails.
+type SecureAggregationConfig struct {
+	Enabled bool `json:"enabled"`
+	// Threshold is the number of clients needed to remove the masks of a client. Less clients than the
+	// threshold have to collude with the coordinator to learn an individual update, and the sum is lost if
+	// less than the threshold complete a session. Defaults to the round quorum, at least 2.
+	Threshold int `json:"threshold,omitempty"`
+	// PhaseTimeoutSeconds is the time after which a phase of a session is closed without the clients that
+	// did not respond. The keys phase is closed after this time once the quorum joined. Defaults to
+	// DefaultSecurePhaseTimeout.
+	PhaseTimeoutSeconds int `json:"phaseTimeoutSeconds,omitempty"`
+}
+
+// threshold returns the threshold of sessions of the given round.
+func (c SecureAggregationConfig) threshold(round *Round) int {
+	if c.Threshold > 0 {
+		return c.Threshold
+	}
+
+	return max(2, min(round.MinParticipants, secagg.MaxClients))
+}
+
+// phaseTimeout returns the time after which a phase of a session is closed.
+func (c SecureAggregationConfig) phaseTimeout() time.Duration {
+	if c.PhaseTimeoutSeconds <= 0 {
+		return DefaultSecurePhaseTimeout
+	}
+
+	return time.Duration(c.PhaseTimeoutSeconds) * time.Second
+}
+
+// validate checks the secure aggregation configuration of a model.
+func (c SecureAggregationConfig) validate(config ModelConfig) error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Threshold != 0 && (c.Threshold < 2 || c.Threshold > secagg.MaxClients) {
+		return fmt.Errorf("secure aggregation threshold must be between 2 and %d", secagg.MaxClients)
+	}
+	if c.PhaseTimeoutSeconds < 0 {
+		return fmt.Errorf("secure aggregation phase timeout must not be negative")
+	}
+
+	// Only the sum of the updates is recovered, which is all the weighted average needs.
+	if config.Aggregation.Algorithm != "" && config.Aggregation.Algorithm != FedAvg {
+		return fmt.Errorf("secure aggregation requires the %s aggregator, got %s", FedAvg,
+			config.Aggregation.Algorithm)
+	}
+	if config.Privacy.enabled() {
+		return fmt.Errorf("differential privacy clips individual updates and cannot be combined with secure " +
+			"aggregation")
+	}
+
+	return nil
+}
+
+// secureSession is the secure aggregation session of a round.
+type secureSession struct {
+	round   int
+	session *secagg.Session
+	// phaseStarted is the time the current phase started, or the first client joined in the keys phase.
+	phaseStarted time.Time
+}
+
+// SecureAggregationState returns the state of the secure aggregation session of the current round of a
+// model.
+func (c *Coordinator) SecureAggregationState(modelID, clientID string) (*secagg.State, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	round, s, err := c.secureRound(modelID, clientID, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &secagg.State{ModelID: modelID, Round: round.Number, BaseVersion: round.BaseVersion,
+		Phase: s.session.Phase(), Threshold: s.session.Threshold()}
+	if state.Phase != secagg.PhaseKeys {
+		state.Keys = s.session.Keys()
+	}
+	if state.Phase == secagg.PhaseUnmask {
+		state.Survivors = s.session.Survivors()
+	}
+
+	return state, nil
+}
+
+// AdvertiseKeys adds a client to the secure aggregation session of a round. The client has to be selected
+// for the round.
+func (c *Coordinator) AdvertiseKeys(modelID, clientID string, number int, keys *secagg.AdvertisedKeys) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	round, s, err := c.secureRound(modelID, clientID, number)
+	if err != nil {
+		return err
+	}
+
+	if s.session.Phase() == secagg.PhaseKeys && round.expired(c.now()) {
+		return fmt.Errorf("%w: round %d of model %s is past its deadline", ErrRoundNotOpen, round.Number, modelID)
+	}
+	if err := c.checkSelected(round, clientID); err != nil {
+		return err
+	}
+
+	keys.ClientID = clientID
+	if err := s.session.AddKeys(keys); err != nil {
+		return sessionError(clientID, err)
+	}
+
+	if s.phaseStarted.IsZero() {
+		s.phaseStarted = c.now()
+	}
+	round.State = RoundCollecting
+	c.touchClient(clientID, ClientTraining)
+	c.advanceSession(round, s)
+	return nil
+}
+
+// ShareKeys relays the encrypted shares a client sends to the other clients of a session.
+func (c *Coordinator) ShareKeys(modelID, clientID string, number int, shares []*secagg.EncryptedShare) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	round, s, err := c.secureRound(modelID, clientID, number)
+	if err != nil {
+		return err
+	}
+
+	if err := s.session.AddShares(clientID, shares); err != nil {
+		return sessionError(clientID, err)
+	}
+
+	c.touchClient(clientID, ClientTraining)
+	c.advanceSession(round, s)
+	return nil
+}
+
+// ReceiveShares returns the encrypted shares other clients of a session sent to a client.
+func (c *Coordinator) ReceiveShares(modelID, clientID string, number int) ([]*secagg.EncryptedShare, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, s, err := c.secureRound(modelID, clientID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := s.session.SharesFor(clientID)
+	if err != nil {
+		return nil, sessionError(clientID, err)
+	}
+
+	return shares, nil
+}
+
+// SubmitMaskedInput submits the masked update of a client to a session. The client becomes a participant of
+// the round.
+func (c *Coordinator) SubmitMaskedInput(modelID, clientID string, number int, input *secagg.MaskedInput) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	round, s, err := c.secureRound(modelID, clientID, number)
+	if err != nil {
+		return err
+	}
+
+	// The input holds every weight scaled by the number of samples, followed by the number of samples.
+	model := c.models[modelID]
+	if len(model.Weights) > 0 && len(input.Masked) != numWeights(model)+1 {
+		return fmt.Errorf("%w from client %s: got %d masked values, model %s has %d weights", ErrInvalidUpdate,
+			clientID, len(input.Masked), modelID, numWeights(model))
+	}
+
+	input.ClientID = clientID
+	if err := s.session.AddMasked(input); err != nil {
+		return sessionError(clientID, err)
+	}
+
+	round.Participants = append(round.Participants, clientID)
+	c.touchClient(clientID, ClientTraining)
+	c.advanceSession(round, s)
+	return nil
+}
+
+// SubmitUnmaskingShares submits the shares a client reveals to remove the masks of a session. Once all
+// survivors revealed their shares the sum of the masked updates is aggregated into the next model version.
+func (c *Coordinator) SubmitUnmaskingShares(modelID, clientID string, number int,
+	shares *secagg.UnmaskingShares) error {
+	c.mu.Lock()
+
+	round, s, err := c.secureRound(modelID, clientID, number)
+	if err == nil {
+		shares.ClientID = clientID
+		if err = s.session.AddUnmasking(shares); err != nil {
+			err = sessionError(clientID, err)
+		}
+	}
+	if err != nil {
+		c.mu.Unlock()
+		return err
+	}
+
+	c.touchClient(clientID, ClientAvailable)
+	job := c.advanceSession(round, s)
+	c.mu.Unlock()
+
+	if job != nil {
+		c.runAggregation(job)
+	}
+
+	return nil
+}
+
+// secureRound returns the open round of a secure model and its session. If number is not negative, it has
+// to be the number of the round. Must be called with the lock held.
+func (c *Coordinator) secureRound(modelID, clientID string, number int) (*Round, *secureSession, error) {
+	client, exists := c.clients[clientID]
+	if !exists {
+		return nil, nil, fmt.Errorf("%w: %s", ErrClientNotRegistered, clientID)
+	}
+
+	if client.ModelID != modelID {
+		return nil, nil, fmt.Errorf("%w: client %s is enrolled in model %s, not %s", ErrClientNotEnrolled,
+			clientID, client.ModelID, modelID)
+	}
+
+	if _, exists := c.models[modelID]; !exists {
+		return nil, nil, fmt.Errorf("%w: %s", ErrModelNotFound, modelID)
+	}
+
+	if !c.configs[modelID].SecureAggregation.Enabled {
+		return nil, nil, fmt.Errorf("%w: model %s does not use secure aggregation", ErrInvalidUpdate, modelID)
+	}
+
+	round, exists := c.rounds[modelID]
+	if !exists || !round.accepting() || number >= 0 && round.Number != number {
+		return nil, nil, fmt.Errorf("%w: model %s has no open round %d", ErrRoundNotOpen, modelID, number)
+	}
+
+	s := c.secureSession(round)
+	if s == nil {
+		return nil, nil, fmt.Errorf("%w: secure aggregation session of round %d of model %s was lost",
+			ErrRoundNotOpen, round.Number, modelID)
+	}
+
+	return round, s, nil
+}
+
+// secureSession returns the session of a round of a secure model, creating it if the round did not start
+// one yet. It returns nil if the session was lost in a restart of the coordinator. Must be called with the
+// lock held.
+func (c *Coordinator) secureSession(round *Round) *secureSession {
+	if s, exists := c.sessions[round.ModelID]; exists && s.round == round.Number {
+		return s
+	}
+	if round.SecurePhase != "" {
+		return nil
+	}
+
+	session, err := secagg.NewSession(c.configs[round.ModelID].SecureAggregation.threshold(round))
+	if err != nil {
+		// The configuration was validated, so the threshold is valid.
+		panic(err)
+	}
+
+	s := &secureSession{round: round.Number, session: session}
+	c.sessions[round.ModelID] = s
+	round.SecurePhase = session.Phase()
+	return s
+}
+
+// advanceSession closes the current phase of the session of a round once every expected client responded or
+// the phase timed out. The round fails if less clients than needed responded. It returns an aggregation job
+// once the unmasking shares were collected. Must be called with the lock held.
+func (c *Coordinator) advanceSession(round *Round, s *secureSession) *aggregationJob {
+	now := c.now()
+	session := s.session
+	timedOut := !s.phaseStarted.IsZero() &&
+		now.Sub(s.phaseStarted) >= c.configs[round.ModelID].SecureAggregation.phaseTimeout()
+
+	switch session.Phase() {
+	case secagg.PhaseKeys:
+		joined := session.Responses()
+		quorum := joined >= max(session.Threshold(), round.MinParticipants)
+		full := round.MaxParticipants > 0 && joined >= round.MaxParticipants
+		if !full && !(quorum && (timedOut || round.expired(now))) {
+			if round.expired(now) {
+				c.failRound(round, fmt.Sprintf("quorum not reached: %d of %d clients joined secure aggregation",
+					joined, max(session.Threshold(), round.MinParticipants)))
+			}
+			return nil
+		}
+	case secagg.PhaseUnmask:
+		if !session.Complete() && !timedOut {
+			return nil
+		}
+		if session.Responses() < session.Threshold() {
+			c.failRound(round, fmt.Sprintf("%d clients revealed unmasking shares, the threshold is %d",
+				session.Responses(), session.Threshold()))
+			return nil
+		}
+
+		job := c.startAggregation(round)
+		job.session = session
+		if err := c.persistModel(round.ModelID); err != nil {
+			log.Print(err)
+		}
+		return job
+	default:
+		if !session.Complete() && !timedOut {
+			return nil
+		}
+	}
+
+	if err := session.Close(); err != nil {
+		c.failRound(round, err.Error())
+		return nil
+	}
+
+	s.phaseStarted = now
+	round.SecurePhase = session.Phase()
+	if err := c.persistModel(round.ModelID); err != nil {
+		log.Print(err)
+	}
+	return nil
+}
+
+// checkSessions advances the sessions of secure rounds whose phase timed out or whose deadline passed. It
+// returns aggregation jobs of sessions whose unmasking phase ended. Must be called with the lock held.
+func (c *Coordinator) checkSessions() []*aggregationJob {
+	jobs := make([]*aggregationJob, 0)
+	for modelID, round := range c.rounds {
+		if !round.accepting() || !c.configs[modelID].SecureAggregation.Enabled {
+			continue
+		}
+
+		s := c.secureSession(round)
+		if s == nil {
+			c.failRound(round, "secure aggregation session was lost")
+			continue
+		}
+
+		if job := c.advanceSession(round, s); job != nil {
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs
+}
+
+// secureAggregate recovers the sum of the masked updates of a session and computes their average.
+func secureAggregate(job *aggregationJob) (*Aggregation, error) {
+	if job.session == nil {
+		return nil, fmt.Errorf("secure aggregation session was lost")
+	}
+
+	sum, err := job.session.Sum()
+	if err != nil {
+		return nil, err
+	}
+
+	weights, _, err := secagg.DecodeSum(sum)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Aggregation{Weights: encodeWeights(weights)}, nil
+}
+
+// sessionError wraps an error returned by a secure aggregation session.
+func sessionError(clientID string, err error) error {
+	if errors.Is(err, secagg.ErrWrongPhase) {
+		return fmt.Errorf("%w: %v", ErrRoundNotOpen, err)
+	}
+
+	return fmt.Errorf("%w from client %s: %v", ErrInvalidUpdate, clientID, err)
+}
+
+// secureRequest authorizes a secure aggregation request and returns the ID of the caller and the round number
+// given in the round query parameter, or -1 if it is missing.
+func (a *API) secureRequest(req *restful.Request) (string, int, error) {
+	identity, err := a.authorizeClient(req, req.QueryParameter("clientId"))
+	if err != nil {
+		return "", 0, err
+	}
+
+	number := -1
+	if value := req.QueryParameter("round"); value != "" {
+		if number, err = strconv.Atoi(value); err != nil {
+			return "", 0, fmt.Errorf("%w: invalid round query parameter: %s", ErrInvalidUpdate, value)
+		}
+	}
+
+	return identity.ClientID, number, nil
+}
+
+func (a *API) getSecureAggregation(req *restful.Request, resp *restful.Response) {
+	clientID, _, err := a.secureRequest(req)
+	if err != nil {
+		writeClientError(resp, err)
+		return
+	}
+
+	state, err := a.coordinator.SecureAggregationState(req.PathParameter("modelId"), clientID)
+	if err != nil {
+		writeClientError(resp, err)
+		return
+	}
+
+	resp.WriteEntity(state)
+}
+
+func (a *API) advertiseKeys(req *restful.Request, resp *restful.Response) {
+	keys := new(secagg.AdvertisedKeys)
+	a.handleSecureMessage(req, resp, keys, func(clientID string, number int) error {
+		return a.coordinator.AdvertiseKeys(req.PathParameter("modelId"), clientID, number, keys)
+	})
+}
+
+func (a *API) shareKeys(req *restful.Request, resp *restful.Response) {
+	shares := make([]*secagg.EncryptedShare, 0)
+	a.handleSecureMessage(req, resp, &shares, func(clientID string, number int) error {
+		return a.coordinator.ShareKeys(req.PathParameter("modelId"), clientID, number, shares)
+	})
+}
+
+func (a *API) receiveShares(req *restful.Request, resp *restful.Response) {
+	clientID, number, err := a.secureRequest(req)
+	if err != nil {
+		writeClientError(resp, err)
+		return
+	}
+
+	shares, err := a.coordinator.ReceiveShares(req.PathParameter("modelId"), clientID, number)
+	if err != nil {
+		resp.WriteError(httpStatus(err), err)
+		return
+	}
+
+	resp.WriteEntity(shares)
+}
+
+func (a *API) submitMaskedInput(req *restful.Request, resp *restful.Response) {
+	input := new(secagg.MaskedInput)
+	a.handleSecureMessage(req, resp, input, func(clientID string, number int) error {
+		return a.coordinator.SubmitMaskedInput(req.PathParameter("modelId"), clientID, number, input)
+	})
+}
+
+func (a *API) submitUnmaskingShares(req *restful.Request, resp *restful.Response) {
+	shares := new(secagg.UnmaskingShares)
+	a.handleSecureMessage(req, resp, shares, func(clientID string, number int) error {
+		return a.coordinator.SubmitUnmaskingShares(req.PathParameter("modelId"), clientID, number, shares)
+	})
+}
+
+// handleSecureMessage authorizes a secure aggregation message, reads it into message and hands it to submit.
+func (a *API) handleSecureMessage(req *restful.Request, resp *restful.Response, message interface{},
+	submit func(clientID string, number int) error) {
+	clientID, number, err := a.secureRequest(req)
+	if err != nil {
+		writeClientError(resp, err)
+		return
+	}
+
+	if err := req.ReadEntity(message); err != nil {
+		resp.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := submit(clientID, number); err != nil {
+		writeClientError(resp, err)
+		return
+	}
+
+	resp.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,200 @@
+package federatedlearning
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/secagg"
+)
+
+// bearerTransport authenticates requests of a test client with testAuthenticator.
+type bearerTransport string
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newTestSecureCoordinator(t *testing.T, config ModelConfig, clients ...string) *Coordinator {
+	c := NewCoordinator()
+	config.SecureAggregation.Enabled = true
+	spec := &ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}), Config: config}
+	if _, err := c.CreateModel(spec); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+
+	for _, id := range clients {
+		c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"})
+	}
+
+	return c
+}
+
+func TestSecureAggregationAPI(t *testing.T) {
+	c := newTestSecureCoordinator(t, ModelConfig{Round: RoundConfig{MinParticipants: 3, MaxParticipants: 3}},
+		"a", "b", "c")
+	server := newTestAPIServer(c)
+	defer server.Close()
+
+	inputs := map[string][]uint64{
+		"a": secagg.EncodeInput([]float32{1, 2}, 1),
+		"b": secagg.EncodeInput([]float32{2, 4}, 1),
+		"c": secagg.EncodeInput([]float32{4, 8}, 2),
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(inputs))
+	for id, input := range inputs {
+		participant := &secagg.Participant{
+			Transport: secagg.NewHTTPTransport(&http.Client{Transport: bearerTransport(id)},
+				server.URL+"/api/v1", "test", id),
+			ClientID:     id,
+			PollInterval: 10 * time.Millisecond,
+		}
+
+		wg.Add(1)
+		go func(input []uint64) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			errs <- participant.Participate(ctx, 1, input)
+		}(input)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Participate(): unexpected error: %v", err)
+		}
+	}
+
+	model, _ := c.GetModel("test")
+	if model.Version != 2 {
+		t.Fatalf("expected version 2 after secure aggregation, got %d", model.Version)
+	}
+	weights, _ := decodeWeights(model.Weights)
+	if math.Abs(float64(weights[0]-2.75)) > 1e-5 || math.Abs(float64(weights[1]-5.5)) > 1e-5 {
+		t.Errorf("expected weights [2.75 5.5], got %v", weights)
+	}
+
+	update := &ModelUpdate{ClientID: "a", ModelID: "test", BaseVersion: 2, WeightUpdate: encodeWeights([]float32{1, 1})}
+	status := doTestClientRequest(t, "a", http.MethodPost, server.URL+"/api/v1/fl/model/test/update", update, nil)
+	if status != http.StatusBadRequest {
+		t.Errorf("expected plain updates of secure model to be refused with %d, got %d", http.StatusBadRequest,
+			status)
+	}
+}
+
+func TestSecureAggregationDropout(t *testing.T) {
+	config := ModelConfig{Round: RoundConfig{MinParticipants: 3},
+		SecureAggregation: SecureAggregationConfig{Threshold: 3, PhaseTimeoutSeconds: 10}}
+	c := newTestSecureCoordinator(t, config, "a", "b", "c", "d")
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	tick := func() {
+		now = now.Add(11 * time.Second)
+		c.checkRounds()
+	}
+
+	ids := []string{"a", "b", "c", "d"}
+	clients := make(map[string]*secagg.Client)
+	for _, id := range ids {
+		clients[id], _ = secagg.NewClient(id, "test/1")
+		if err := c.AdvertiseKeys("test", id, 1, clients[id].AdvertiseKeys()); err != nil {
+			t.Fatalf("AdvertiseKeys(%s): unexpected error: %v", id, err)
+		}
+	}
+	tick()
+
+	state, _ := c.SecureAggregationState("test", "a")
+	if state.Phase != secagg.PhaseShares || len(state.Keys) != 4 {
+		t.Fatalf("expected shares phase with 4 clients after keys phase timed out, got %+v", state)
+	}
+
+	for _, id := range ids {
+		shares, _ := clients[id].ShareKeys(state.Keys, state.Threshold)
+		if err := c.ShareKeys("test", id, 1, shares); err != nil {
+			t.Fatalf("ShareKeys(%s): unexpected error: %v", id, err)
+		}
+	}
+
+	// Client d drops out before sending its masked input.
+	for i, id := range ids[:3] {
+		shares, _ := c.ReceiveShares("test", id, 1)
+		masked, _ := clients[id].MaskInput(shares, secagg.EncodeInput([]float32{float32(i), 1}, 1))
+		if err := c.SubmitMaskedInput("test", id, 1, masked); err != nil {
+			t.Fatalf("SubmitMaskedInput(%s): unexpected error: %v", id, err)
+		}
+	}
+	tick()
+
+	state, _ = c.SecureAggregationState("test", "a")
+	if state.Phase != secagg.PhaseUnmask || len(state.Survivors) != 3 {
+		t.Fatalf("expected unmask phase with 3 survivors after masked phase timed out, got %+v", state)
+	}
+
+	for _, id := range ids[:3] {
+		unmasking, _ := clients[id].Unmask(state.Survivors)
+		if err := c.SubmitUnmaskingShares("test", id, 1, unmasking); err != nil {
+			t.Fatalf("SubmitUnmaskingShares(%s): unexpected error: %v", id, err)
+		}
+	}
+
+	model, _ := c.GetModel("test")
+	weights, _ := decodeWeights(model.Weights)
+	if model.Version != 2 || math.Abs(float64(weights[0]-1)) > 1e-5 || math.Abs(float64(weights[1]-1)) > 1e-5 {
+		t.Errorf("expected version 2 with weights [1 1], got version %d with %v", model.Version, weights)
+	}
+
+	err := c.AdvertiseKeys("test", "a", 1, clients["a"].AdvertiseKeys())
+	if !errors.Is(err, ErrRoundNotOpen) {
+		t.Errorf("expected keys of finished round to be refused, got %v", err)
+	}
+}
+
+func TestSecureAggregationFailsBelowThreshold(t *testing.T) {
+	config := ModelConfig{Round: RoundConfig{MinParticipants: 2},
+		SecureAggregation: SecureAggregationConfig{Threshold: 2, PhaseTimeoutSeconds: 10}}
+	c := newTestSecureCoordinator(t, config, "a", "b")
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	for _, id := range []string{"a", "b"} {
+		client, _ := secagg.NewClient(id, "test/1")
+		c.AdvertiseKeys("test", id, 1, client.AdvertiseKeys())
+	}
+	now = now.Add(11 * time.Second)
+	c.checkRounds()
+
+	// Nobody shares keys before the phase times out.
+	now = now.Add(11 * time.Second)
+	c.checkRounds()
+
+	round, _ := c.GetRound("test")
+	if round.Number != 2 || round.State != RoundOpen {
+		t.Errorf("expected failed round to be replaced by round 2, got round %d in state %s", round.Number,
+			round.State)
+	}
+}
+
+func TestSecureAggregationConfigValidation(t *testing.T) {
+	invalid := []ModelConfig{
+		{SecureAggregation: SecureAggregationConfig{Enabled: true, Threshold: 1}},
+		{SecureAggregation: SecureAggregationConfig{Enabled: true}, Aggregation: AggregationConfig{Algorithm: Median}},
+		{SecureAggregation: SecureAggregationConfig{Enabled: true},
+			Privacy: PrivacyConfig{ClipNorm: 1, NoiseMultiplier: 1}},
+	}
+
+	for _, config := range invalid {
+		if err := validateConfig(config); err == nil {
+			t.Errorf("expected error for config %+v", config)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package federatedlearning
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Names of the built-in selection strategies.
+const (
+	// SelectAll invites every enrolled client to every round.
+	SelectAll = "all"
+	// SelectRandom samples clients uniformly at random.
+	SelectRandom = "random"
+	// SelectRoundRobin invites the clients that were selected least recently.
+	SelectRoundRobin = "round-robin"
+	// SelectDataSize samples clients with a probability proportional to the number of samples they hold.
+	SelectDataSize = "data-size"
+)
+
+// DefaultRetryAfter is the time after which clients that were not selected are asked to try again, unless
+// the round ends sooner.
+const DefaultRetryAfter = 30 * time.Second
+
+// Selector decides which clients are invited to train in a round.
+type Selector interface {
+	// Select returns up to count of the candidates. Candidates are sorted by ID.
+	Select(candidates []*Client, count int) []*Client
+}
+
+// SelectorFactory creates a selector from the selection configuration of a model.
+type SelectorFactory func(config SelectionConfig) (Selector, error)
+
+// SelectionConfig configures which clients train a model.
+type SelectionConfig struct {
+	// Strategy is the name of a registered selector. Defaults to SelectAll.
+	Strategy string `json:"strategy"`
+	// ClientsPerRound is the number of clients invited to a round. It must not be below the quorum of the
+	// round, which could never be reached otherwise. Zero invites every client, regardless of the strategy.
+	ClientsPerRound int `json:"clientsPerRound"`
+	// RetryAfterSeconds is the time after which clients that were not selected should try again. Defaults
+	// to DefaultRetryAfter, clients are asked to retry at the deadline of the round if it is sooner.
+	RetryAfterSeconds int `json:"retryAfterSeconds"`
+}
+
+// retryAfter returns the configured retry time.
+func (c SelectionConfig) retryAfter() time.Duration {
+	if c.RetryAfterSeconds <= 0 {
+		return DefaultRetryAfter
+	}
+
+	return time.Duration(c.RetryAfterSeconds) * time.Second
+}
+
+// validate checks the selection configuration of a model.
+func (c SelectionConfig) validate(config ModelConfig) error {
+	if _, err := NewSelector(c); err != nil {
+		return err
+	}
+
+	quorum := config.Round.MinParticipants
+	if quorum <= 0 {
+		quorum = DefaultMinParticipants
+	}
+	if config.Async.Enabled {
+		quorum = config.Async.bufferSize()
+	}
+	if c.ClientsPerRound > 0 && c.ClientsPerRound < quorum {
+		return fmt.Errorf("clients per round must be at least the %d participants a round needs, got %d", quorum,
+			c.ClientsPerRound)
+	}
+
+	return nil
+}
+
+// ErrNotSelected is returned to clients that are not invited to the current round of a model. Use
+// errors.As with *WaitError to get the suggested retry time.
+var ErrNotSelected = errors.New("client not selected for round")
+
+// WaitError tells a client that it was not selected and when it should try again.
+type WaitError struct {
+	ModelID    string
+	Round      int
+	RetryAfter time.Duration
+}
+
+// Error implements error interface.
+func (e *WaitError) Error() string {
+	return fmt.Sprintf("%v: round %d of model %s, retry after %s", ErrNotSelected, e.Round, e.ModelID,
+		e.RetryAfter)
+}
+
+// Unwrap allows to match WaitError with errors.Is(err, ErrNotSelected).
+func (e *WaitError) Unwrap() error {
+	return ErrNotSelected
+}
+
+var (
+	selectorsMu sync.RWMutex
+	selectors   = map[string]SelectorFactory{
+		SelectAll:        func(SelectionConfig) (Selector, error) { return allSelector{}, nil },
+		SelectRandom:     func(SelectionConfig) (Selector, error) { return randomSelector{}, nil },
+		SelectRoundRobin: func(SelectionConfig) (Selector, error) { return roundRobinSelector{}, nil },
+		SelectDataSize:   func(SelectionConfig) (Selector, error) { return dataSizeSelector{}, nil },
+	}
+)
+
+// RegisterSelector makes a selector available under the given name. Registering the same name twice
+// replaces the previous factory.
+func RegisterSelector(name string, factory SelectorFactory) {
+	selectorsMu.Lock()
+	defer selectorsMu.Unlock()
+	selectors[name] = factory
+}
+
+// Selectors returns names of all registered selectors.
+func Selectors() []string {
+	selectorsMu.RLock()
+	defer selectorsMu.RUnlock()
+
+	names := make([]string, 0, len(selectors))
+	for name := range selectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewSelector creates the selector described by config.
+func NewSelector(config SelectionConfig) (Selector, error) {
+	name := config.Strategy
+	if name == "" {
+		name = SelectAll
+	}
+
+	selectorsMu.RLock()
+	factory, exists := selectors[name]
+	selectorsMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown selection strategy: %s", name)
+	}
+
+	return factory(config)
+}
+
+// allSelector selects the first count candidates.
+type allSelector struct{}
+
+// Select implements Selector interface. See Selector for more information.
+func (allSelector) Select(candidates []*Client, count int) []*Client {
+	return candidates[:min(count, len(candidates))]
+}
+
+// randomSelector samples candidates uniformly without replacement.
+type randomSelector struct{}
+
+// Select implements Selector interface. See Selector for more information.
+func (randomSelector) Select(candidates []*Client, count int) []*Client {
+	shuffled := append(make([]*Client, 0, len(candidates)), candidates...)
+	rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return shuffled[:min(count, len(shuffled))]
+}
+
+// roundRobinSelector selects the candidates that were selected least recently, so that over consecutive
+// rounds every client gets its turn.
+type roundRobinSelector struct{}
+
+// Select implements Selector interface. See Selector for more information.
+func (roundRobinSelector) Select(candidates []*Client, count int) []*Client {
+	sorted := append(make([]*Client, 0, len(candidates)), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].LastSelected == nil || sorted[j].LastSelected == nil {
+			return sorted[i].LastSelected == nil && sorted[j].LastSelected != nil
+		}
+
+		return sorted[i].LastSelected.Before(*sorted[j].LastSelected)
+	})
+	return sorted[:min(count, len(sorted))]
+}
+
+// dataSizeSelector samples candidates without replacement with a probability proportional to the number of
+// samples they reported, using the weighted sampling algorithm of Efraimidis and Spirakis. Clients that
+// did not report their data size count as a single sample.
+type dataSizeSelector struct{}
+
+// Select implements Selector interface. See Selector for more information.
+func (dataSizeSelector) Select(candidates []*Client, count int) []*Client {
+	keys := make(map[*Client]float64, len(candidates))
+	for _, client := range candidates {
+		weight := math.Max(float64(client.NumSamples), 1)
+		keys[client] = math.Pow(rand.Float64(), 1/weight)
+	}
+
+	sorted := append(make([]*Client, 0, len(candidates)), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool { return keys[sorted[i]] > keys[sorted[j]] })
+	return sorted[:min(count, len(sorted))]
+}
+
+// selectClients invites enrolled clients to a round until the number of clients configured for the model
+// is reached, and at least the quorum of the round. Selected clients that went offline without submitting an
+// update are replaced. Must be called with the lock held.
+func (c *Coordinator) selectClients(round *Round) {
+	config := c.configs[round.ModelID].Selection
+	if config.ClientsPerRound <= 0 || !round.accepting() {
+		return
+	}
+
+	selector, err := NewSelector(config)
+	if err != nil {
+		log.Printf("Failed to select clients for round %d of model %s: %v", round.Number, round.ModelID, err)
+		return
+	}
+
+	// Rounds opened with their own configuration may need more participants than the model configures.
+	count := max(config.ClientsPerRound, round.MinParticipants)
+	active := 0
+	selected := make(map[string]bool, len(round.Selected))
+	for _, id := range round.Selected {
+		selected[id] = true
+		if client, exists := c.clients[id]; round.hasParticipant(id) || exists && client.Status != ClientOffline {
+			active++
+		}
+	}
+
+	candidates := make([]*Client, 0)
+	for _, client := range c.clients {
+		if client.ModelID == round.ModelID && client.Status != ClientOffline && !selected[client.ID] {
+			candidates = append(candidates, client)
+		}
+	}
+	if active >= count || len(candidates) == 0 {
+		return
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+	now := c.now()
+	for _, client := range selector.Select(candidates, count-active) {
+		round.Selected = append(round.Selected, client.ID)
+		client.LastSelected = &now
+		c.persistClient(client)
+	}
+}
+
+// checkSelected returns a WaitError if selection is enabled for the round and the client was not invited.
+// Must be called with the lock held.
+func (c *Coordinator) checkSelected(round *Round, clientID string) error {
+	config := c.configs[round.ModelID].Selection
+	if config.ClientsPerRound <= 0 || round.isSelected(clientID) {
+		return nil
+	}
+
+	c.selectClients(round)
+	if round.isSelected(clientID) {
+		return c.persistModel(round.ModelID)
+	}
+
+	retryAfter := config.retryAfter()
+	if round.Deadline != nil {
+		if untilDeadline := round.Deadline.Sub(c.now()); untilDeadline > 0 && untilDeadline < retryAfter {
+			retryAfter = untilDeadline
+		}
+	}
+
+	return &WaitError{ModelID: round.ModelID, Round: round.Number, RetryAfter: retryAfter}
+}
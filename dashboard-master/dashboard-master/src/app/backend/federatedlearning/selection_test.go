@@ -0,0 +1,160 @@
+package federatedlearning
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func clientIDs(clients []*Client) []string {
+	ids := make([]string, 0, len(clients))
+	for _, client := range clients {
+		ids = append(ids, client.ID)
+	}
+
+	return ids
+}
+
+func TestSelectors(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Minute)
+	candidates := []*Client{
+		{ID: "a", LastSelected: &now, NumSamples: 1},
+		{ID: "b", NumSamples: 1},
+		{ID: "c", LastSelected: &earlier, NumSamples: 1000000},
+	}
+
+	cases := []struct {
+		strategy string
+		count    int
+		expected []string
+	}{
+		{SelectAll, 2, []string{"a", "b"}},
+		{SelectRoundRobin, 2, []string{"b", "c"}},
+		{SelectRoundRobin, 5, []string{"b", "c", "a"}},
+		{SelectDataSize, 1, []string{"c"}},
+	}
+
+	for _, tc := range cases {
+		selector, err := NewSelector(SelectionConfig{Strategy: tc.strategy})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.strategy, err)
+		}
+
+		if selected := clientIDs(selector.Select(candidates, tc.count)); !reflect.DeepEqual(selected, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.strategy, tc.expected, selected)
+		}
+	}
+
+	selector, _ := NewSelector(SelectionConfig{Strategy: SelectRandom})
+	selected := selector.Select(candidates, 2)
+	if len(selected) != 2 || selected[0] == selected[1] {
+		t.Errorf("random: expected 2 distinct clients, got %v", clientIDs(selected))
+	}
+
+	if _, err := NewSelector(SelectionConfig{Strategy: "unknown"}); err == nil {
+		t.Error("expected unknown strategy to be rejected")
+	}
+}
+
+func TestClientSelection(t *testing.T) {
+	c := NewCoordinator()
+	config := ModelConfig{
+		Round:     RoundConfig{MinParticipants: 2},
+		Selection: SelectionConfig{Strategy: SelectRoundRobin, ClientsPerRound: 2},
+	}
+	if _, err := c.CreateModel(&ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}), Config: config}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"}); err != nil {
+			t.Fatalf("RegisterClient(%s): unexpected error: %v", id, err)
+		}
+	}
+
+	for _, id := range []string{"a", "b"} {
+		if _, err := c.FetchModel("test", id); err != nil {
+			t.Fatalf("FetchModel(%s): expected client to be selected, got %v", id, err)
+		}
+	}
+
+	var wait *WaitError
+	if _, err := c.FetchModel("test", "c"); !errors.As(err, &wait) || wait.RetryAfter != DefaultRetryAfter {
+		t.Errorf("expected client c to wait %s, got %v", DefaultRetryAfter, err)
+	}
+	if err := submitTestUpdate(c, "c", 1); !errors.Is(err, ErrNotSelected) {
+		t.Errorf("expected update of client that was not selected to be rejected, got %v", err)
+	}
+
+	for _, id := range []string{"a", "b"} {
+		if err := submitTestUpdate(c, id, 1); err != nil {
+			t.Fatalf("SubmitModelUpdate(%s): unexpected error: %v", id, err)
+		}
+	}
+
+	round, _ := c.GetRound("test")
+	if round.Number != 2 || !reflect.DeepEqual(round.Selected, []string{"c", "a"}) {
+		t.Errorf("expected round 2 to select c, which did not train yet, and a, got %+v", round)
+	}
+}
+
+func TestClientSelectionAPI(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a", "b", "c")
+	config := c.GetModelConfig("test")
+	config.Selection = SelectionConfig{ClientsPerRound: 2, RetryAfterSeconds: 10}
+	if err := c.SetModelConfig("test", config); err != nil {
+		t.Fatalf("SetModelConfig(): unexpected error: %v", err)
+	}
+	server := newTestAPIServer(c)
+	defer server.Close()
+
+	if status := doTestClientRequest(t, "a", http.MethodGet, server.URL+"/api/v1/fl/model/test?clientId=a", nil,
+		nil); status != http.StatusOK {
+		t.Fatalf("expected selected client to get model, got %d", status)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/fl/model/test?clientId=c", nil)
+	req.Header.Set("Authorization", "Bearer c")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "10" {
+		t.Errorf("expected client c to be told to wait 10s, got %d with Retry-After %q", resp.StatusCode,
+			resp.Header.Get("Retry-After"))
+	}
+}
+
+func TestClientSelectionQuorum(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a", "b", "c", "d")
+
+	// Rounds need three participants by default, two invited clients could never reach the quorum.
+	config := ModelConfig{Selection: SelectionConfig{ClientsPerRound: 2}}
+	if err := c.SetModelConfig("test", config); err == nil {
+		t.Fatal("expected clients per round below the quorum to be rejected")
+	}
+	config.Round.MinParticipants = 2
+	if err := c.SetModelConfig("test", config); err != nil {
+		t.Fatalf("SetModelConfig(): unexpected error: %v", err)
+	}
+
+	// Rounds opened with their own configuration invite at least their quorum.
+	c.mu.Lock()
+	round := c.openRound("test", RoundConfig{MinParticipants: 3})
+	c.mu.Unlock()
+	if expected := []string{"a", "b", "c"}; !reflect.DeepEqual(round.Selected, expected) {
+		t.Fatalf("expected clients %v to be selected, got %v", expected, round.Selected)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if err := submitTestUpdate(c, id, 1); err != nil {
+			t.Fatalf("SubmitModelUpdate(%s): unexpected error: %v", id, err)
+		}
+	}
+	if model, _ := c.GetModel("test"); model.Version != 2 {
+		t.Errorf("expected round to reach its quorum and produce version 2, got version %d", model.Version)
+	}
+}
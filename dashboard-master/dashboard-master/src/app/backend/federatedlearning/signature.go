@@ -0,0 +1,117 @@
+package federatedlearning
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SignatureAlgorithm is the algorithm signers sign the provenance of versions with.
+const SignatureAlgorithm = "Ed25519"
+
+// Provenance is the record of a version that is signed when the version is published. Clients verify the
+// signature, decode the payload and check that it describes the version they fetched and that the weights
+// they downloaded match its digest.
+type Provenance struct {
+	ModelID       string `json:"modelId"`
+	Version       int    `json:"version"`
+	ParentVersion int    `json:"parentVersion,omitempty"`
+	// Digest is the digest of the weights of the version.
+	Digest string `json:"digest"`
+	// Contributors are the sorted IDs of the clients whose updates were aggregated into the version.
+	Contributors []string `json:"contributors,omitempty"`
+}
+
+// Signature is the signature of the provenance of a version.
+type Signature struct {
+	// KeyID identifies the public key the signature is verified with.
+	KeyID     string `json:"keyId"`
+	Algorithm string `json:"algorithm"`
+	// Payload is the JSON encoded Provenance that was signed.
+	Payload []byte `json:"payload"`
+	Value   []byte `json:"value"`
+}
+
+// Signer signs the provenance of the versions published by the coordinator.
+type Signer interface {
+	// Sign signs a payload with SignatureAlgorithm and returns the ID of the key it used and the signature.
+	Sign(payload []byte) (keyID string, signature []byte, err error)
+}
+
+// SetSigner sets the signer of new versions. Versions that were published unsigned, e.g. before signing was
+// enabled, are signed right away so that clients verifying signatures can keep training them.
+func (c *Coordinator) SetSigner(signer Signer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.signer = signer
+	for id, versions := range c.versions {
+		signed := false
+		for _, version := range append([]*GlobalModel{c.models[id]}, versions...) {
+			if version == nil || version.Signature != nil {
+				continue
+			}
+			if err := c.sign(version); err != nil {
+				return err
+			}
+			signed = true
+		}
+
+		if signed {
+			if err := c.persistModel(id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// sign signs the provenance of a version if the coordinator has a signer. Must be called with the lock held.
+func (c *Coordinator) sign(model *GlobalModel) error {
+	if c.signer == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(&Provenance{ModelID: model.ID, Version: model.Version,
+		ParentVersion: model.ParentVersion, Digest: model.Digest, Contributors: model.Contributors})
+	if err != nil {
+		return err
+	}
+
+	keyID, value, err := c.signer.Sign(payload)
+	if err != nil {
+		return fmt.Errorf("failed to sign model %s version %d: %v", model.ID, model.Version, err)
+	}
+
+	model.Signature = &Signature{KeyID: keyID, Algorithm: SignatureAlgorithm, Payload: payload, Value: value}
+	return nil
+}
+
+// contributors returns the sorted IDs of the clients whose updates were aggregated by a job. Clients that
+// dropped out of secure aggregation and outliers discarded by the aggregator did not contribute.
+func contributors(job *aggregationJob, aggregation *Aggregation) []string {
+	var ids []string
+	if job.session != nil {
+		ids = job.session.Survivors()
+	} else {
+		for _, update := range job.updates {
+			ids = append(ids, update.ClientID)
+		}
+	}
+
+	discarded := make(map[string]bool, len(aggregation.Discarded))
+	for _, id := range aggregation.Discarded {
+		discarded[id] = true
+	}
+
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !discarded[id] {
+			result = append(result, id)
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,96 @@
+package federatedlearning
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// testSigner signs with a static key.
+type testSigner struct {
+	key ed25519.PrivateKey
+}
+
+func newTestSigner() *testSigner {
+	return &testSigner{key: ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))}
+}
+
+// Sign implements Signer interface.
+func (s *testSigner) Sign(payload []byte) (string, []byte, error) {
+	return "test", ed25519.Sign(s.key, payload), nil
+}
+
+// verifyProvenance verifies the signature of a version and returns its provenance.
+func verifyProvenance(t *testing.T, signer *testSigner, model *GlobalModel) *Provenance {
+	signature := model.Signature
+	if signature == nil {
+		t.Fatalf("expected version %d to be signed", model.Version)
+	}
+	if signature.KeyID != "test" || signature.Algorithm != SignatureAlgorithm ||
+		!ed25519.Verify(signer.key.Public().(ed25519.PublicKey), signature.Payload, signature.Value) {
+		t.Fatalf("expected valid signature of version %d, got %+v", model.Version, signature)
+	}
+
+	provenance := new(Provenance)
+	if err := json.Unmarshal(signature.Payload, provenance); err != nil {
+		t.Fatalf("expected JSON encoded provenance, got %v", err)
+	}
+
+	return provenance
+}
+
+func TestSignature(t *testing.T) {
+	store, _ := NewFileStore(t.TempDir())
+	c, err := NewPersistentCoordinator(store)
+	if err != nil {
+		t.Fatalf("NewPersistentCoordinator(): unexpected error: %v", err)
+	}
+	spec := &ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}),
+		Config: ModelConfig{Round: RoundConfig{MinParticipants: 2}}}
+	if _, err := c.CreateModel(spec); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+
+	model, _ := c.GetModel("test")
+	if model.Signature != nil {
+		t.Fatal("expected versions to be unsigned without a signer")
+	}
+
+	// Versions published before signing was enabled are signed when the signer is set.
+	signer := newTestSigner()
+	if err := c.SetSigner(signer); err != nil {
+		t.Fatalf("SetSigner(): unexpected error: %v", err)
+	}
+	model, _ = c.GetModel("test")
+	expected := &Provenance{ModelID: "test", Version: 1, Digest: model.Digest}
+	if provenance := verifyProvenance(t, signer, model); !reflect.DeepEqual(provenance, expected) {
+		t.Fatalf("expected provenance %+v, got %+v", expected, provenance)
+	}
+
+	for _, id := range []string{"b", "a"} {
+		if _, err := c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: "test"}); err != nil {
+			t.Fatalf("RegisterClient(%s): unexpected error: %v", id, err)
+		}
+		if err := submitTestUpdate(c, id, 1); err != nil {
+			t.Fatalf("SubmitModelUpdate(%s): unexpected error: %v", id, err)
+		}
+	}
+
+	model, _ = c.GetModel("test")
+	expected = &Provenance{ModelID: "test", Version: 2, ParentVersion: 1, Digest: model.Digest,
+		Contributors: []string{"a", "b"}}
+	if provenance := verifyProvenance(t, signer, model); !reflect.DeepEqual(provenance, expected) {
+		t.Fatalf("expected provenance %+v, got %+v", expected, provenance)
+	}
+
+	// Signatures are persisted with the versions.
+	restored, err := NewPersistentCoordinator(store)
+	if err != nil {
+		t.Fatalf("NewPersistentCoordinator(): unexpected error: %v", err)
+	}
+	versions, _ := restored.ListVersions("test")
+	for _, version := range versions {
+		verifyProvenance(t, signer, version)
+	}
+}
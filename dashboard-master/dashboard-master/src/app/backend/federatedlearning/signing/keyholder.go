@@ -0,0 +1,175 @@
+// Package signing holds the key the federated learning coordinator signs model versions with. The key is
+// kept in a Secret synchronized with a sync.Synchronizer, so that every replica of the dashboard signs with
+// the same key and the key survives restarts.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/kubernetes/dashboard/src/app/backend/errors"
+	syncApi "github.com/kubernetes/dashboard/src/app/backend/sync/api"
+)
+
+const (
+	// PrivateKeyEntry is the Secret entry holding the PEM encoded PKCS #8 private key.
+	PrivateKeyEntry = "priv"
+	// PublicKeyEntry is the Secret entry holding the PEM encoded PKIX public key. xApps verify signatures
+	// with it.
+	PublicKeyEntry = "pub"
+)
+
+// KeyHolder signs payloads with an Ed25519 key synchronized with a Secret. It implements the Signer interface
+// of the coordinator.
+type KeyHolder struct {
+	namespace    string
+	name         string
+	synchronizer syncApi.Synchronizer
+
+	key ed25519.PrivateKey
+	mux sync.Mutex
+}
+
+// NewKeyHolder creates a KeyHolder for the Secret synchronized by synchronizer. The key is read from the
+// Secret. If it does not exist, a new key is generated and stored in it.
+func NewKeyHolder(synchronizer syncApi.Synchronizer, namespace, name string) (*KeyHolder, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	holder := &KeyHolder{namespace: namespace, name: name, synchronizer: synchronizer, key: key}
+	synchronizer.RegisterActionHandler(holder.update, watch.Added, watch.Modified)
+	synchronizer.RegisterActionHandler(holder.recreate, watch.Deleted)
+
+	if obj := synchronizer.Get(); obj != nil {
+		secret := obj.(*v1.Secret)
+		key, err := ParsePrivateKey(secret.Data[PrivateKeyEntry])
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing key in secret %s/%s: %v", namespace, name, err)
+		}
+
+		log.Printf("Initializing federated learning signing key %s from secret %s/%s", KeyID(key.Public()),
+			namespace, name)
+		holder.key = key
+		return holder, nil
+	}
+
+	log.Printf("Storing federated learning signing key %s in secret %s/%s", KeyID(key.Public()), namespace, name)
+	secret, err := holder.secret()
+	if err != nil {
+		return nil, err
+	}
+	if err := synchronizer.Create(secret); err != nil && !errors.IsAlreadyExists(err) {
+		return nil, err
+	}
+
+	return holder, nil
+}
+
+// Sign implements Signer interface. See Signer for more information.
+func (h *KeyHolder) Sign(payload []byte) (string, []byte, error) {
+	key := h.Key()
+	return KeyID(key.Public()), ed25519.Sign(key, payload), nil
+}
+
+// Key returns the current signing key.
+func (h *KeyHolder) Key() ed25519.PrivateKey {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	return h.key
+}
+
+// update takes the key of a synchronized Secret. An invalid key is ignored, the Secret is not overwritten
+// because it may hold a key provided by the administrator.
+func (h *KeyHolder) update(obj runtime.Object) {
+	secret := obj.(*v1.Secret)
+	key, err := ParsePrivateKey(secret.Data[PrivateKeyEntry])
+	if err != nil {
+		log.Printf("Ignoring invalid signing key in secret %s/%s: %v", h.namespace, h.name, err)
+		return
+	}
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	if !h.key.Equal(key) {
+		log.Printf("Federated learning signing key changed to %s", KeyID(key.Public()))
+	}
+	h.key = key
+}
+
+// recreate stores the current key in the Secret again after it was deleted.
+func (h *KeyHolder) recreate(obj runtime.Object) {
+	log.Printf("Synchronized secret %s/%s has been deleted. Recreating.", h.namespace, h.name)
+	secret, err := h.secret()
+	if err == nil {
+		err = h.synchronizer.Create(secret)
+	}
+	if err != nil && !errors.IsAlreadyExists(err) {
+		log.Printf("Failed to recreate secret %s/%s: %v", h.namespace, h.name, err)
+	}
+}
+
+// secret returns the Secret holding the current key.
+func (h *KeyHolder) secret() (*v1.Secret, error) {
+	key := h.Key()
+	priv, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.Secret{
+		ObjectMeta: metaV1.ObjectMeta{Namespace: h.namespace, Name: h.name},
+		Data: map[string][]byte{
+			PrivateKeyEntry: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: priv}),
+			PublicKeyEntry:  pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}),
+		},
+	}, nil
+}
+
+// ParsePrivateKey parses a PEM encoded PKCS #8 Ed25519 private key.
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded key found")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ed25519Key, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected Ed25519 key, got %T", key)
+	}
+
+	return ed25519Key, nil
+}
+
+// KeyID returns the ID of a public key, the first 8 bytes of the SHA-256 hash of its PKIX encoding in hex.
+func KeyID(key interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,106 @@
+package signing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubernetes/dashboard/src/app/backend/sync"
+)
+
+func parsePublicKey(t *testing.T, data []byte) ed25519.PublicKey {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatal("expected PEM encoded public key")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("ParsePKIXPublicKey(): unexpected error: %v", err)
+	}
+
+	return key.(ed25519.PublicKey)
+}
+
+func TestKeyHolder(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	manager := sync.NewSynchronizerManager(client)
+
+	holder, err := NewKeyHolder(manager.Secret("kubernetes-dashboard", "fl-signing-key"), "kubernetes-dashboard",
+		"fl-signing-key")
+	if err != nil {
+		t.Fatalf("NewKeyHolder(): unexpected error: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("kubernetes-dashboard").Get(context.Background(), "fl-signing-key",
+		metaV1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected key to be stored in a secret, got %v", err)
+	}
+
+	publicKey := parsePublicKey(t, secret.Data[PublicKeyEntry])
+	keyID, signature, err := holder.Sign([]byte("payload"))
+	if err != nil {
+		t.Fatalf("Sign(): unexpected error: %v", err)
+	}
+	if keyID != KeyID(publicKey) || !ed25519.Verify(publicKey, []byte("payload"), signature) {
+		t.Fatalf("expected signature verified by the public key %s in the secret, got key %s", KeyID(publicKey),
+			keyID)
+	}
+
+	// Other replicas sign with the stored key.
+	other, err := NewKeyHolder(manager.Secret("kubernetes-dashboard", "fl-signing-key"), "kubernetes-dashboard",
+		"fl-signing-key")
+	if err != nil {
+		t.Fatalf("NewKeyHolder(): unexpected error: %v", err)
+	}
+	if !other.Key().Equal(holder.Key()) {
+		t.Fatal("expected key to be read from the secret")
+	}
+}
+
+func TestKeyHolderUpdate(t *testing.T) {
+	holder, err := NewKeyHolder(sync.NewSynchronizerManager(fake.NewSimpleClientset()).Secret("default", "key"),
+		"default", "key")
+	if err != nil {
+		t.Fatalf("NewKeyHolder(): unexpected error: %v", err)
+	}
+
+	rotated, err := NewKeyHolder(sync.NewSynchronizerManager(fake.NewSimpleClientset()).Secret("default", "key"),
+		"default", "key")
+	if err != nil {
+		t.Fatalf("NewKeyHolder(): unexpected error: %v", err)
+	}
+	secret, err := rotated.secret()
+	if err != nil {
+		t.Fatalf("secret(): unexpected error: %v", err)
+	}
+
+	holder.update(secret)
+	if !holder.Key().Equal(rotated.Key()) {
+		t.Fatal("expected key of the modified secret to be used")
+	}
+
+	holder.update(&v1.Secret{Data: map[string][]byte{PrivateKeyEntry: []byte("invalid")}})
+	if !holder.Key().Equal(rotated.Key()) {
+		t.Fatal("expected invalid key to be ignored")
+	}
+}
+
+func TestNewKeyHolderInvalidSecret(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metaV1.ObjectMeta{Namespace: "default", Name: "key"},
+		Data:       map[string][]byte{PrivateKeyEntry: []byte("invalid")},
+	})
+
+	if _, err := NewKeyHolder(sync.NewSynchronizerManager(client).Secret("default", "key"), "default",
+		"key"); err == nil {
+		t.Fatal("expected invalid key in the secret to be rejected")
+	}
+}
@@ -0,0 +1,392 @@
+package federatedlearning
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// ErrBlobNotFound is returned by blob stores when the requested key does not exist.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// Store persists the state of the coordinator so that it can be rebuilt after a restart.
+type Store interface {
+	// SaveModel persists the metadata of a model: its current version, configuration and round. Weights
+	// are persisted separately with SaveWeights.
+	SaveModel(state *ModelState) error
+	// SaveWeights persists the weights of a model version.
+	SaveWeights(model *GlobalModel) error
+	// LoadWeights returns the persisted weights of a model version.
+	LoadWeights(modelID string, version int) ([]byte, error)
+	// SaveMoments persists the moment estimates of the server optimizer of a model under the version of the
+	// state.
+	SaveMoments(modelID string, state *OptimizerState) error
+	// DeleteMoments removes the moment estimates persisted under a version of a model.
+	DeleteMoments(modelID string, version int) error
+	// SaveUpdate persists a pending update of a model.
+	SaveUpdate(update *ModelUpdate) error
+	// DeleteUpdates removes all pending updates of a model.
+	DeleteUpdates(modelID string) error
+	// SaveClient persists a registered client.
+	SaveClient(client *Client) error
+	// LoadModels returns state of all persisted models including weights of their current version, moments
+	// of their server optimizer and pending updates.
+	LoadModels() ([]*ModelState, error)
+	// LoadClients returns all persisted clients.
+	LoadClients() ([]*Client, error)
+}
+
+// ModelState is the persisted state of a single model.
+type ModelState struct {
+	// ID is the ID of the model.
+	ID string `json:"id"`
+	// Model is the current version of the model. It is nil if only the configuration was set.
+	Model *GlobalModel `json:"model,omitempty"`
+	// Versions holds metadata of all versions of the model, oldest first. Stores may persist every version
+	// separately, so that the state of a model does not grow with its history.
+	Versions []*GlobalModel `json:"versions,omitempty"`
+	Config   ModelConfig    `json:"config"`
+	Round    *Round         `json:"round,omitempty"`
+	// Privacy is the privacy budget spent by the model.
+	Privacy *PrivacyBudget `json:"privacy,omitempty"`
+	// Optimizer is the state of the server optimizer of the model.
+	Optimizer *OptimizerState `json:"optimizer,omitempty"`
+	// Candidate is the version of the model waiting to be promoted.
+	Candidate *Candidate `json:"candidate,omitempty"`
+	// Upstream is the progress of the model towards its upstream model.
+	Upstream *UpstreamState `json:"upstream,omitempty"`
+	// Updates are the pending updates of the current round. They are persisted with SaveUpdate.
+	Updates []*ModelUpdate `json:"-"`
+}
+
+// BlobStore stores binary objects under slash separated keys.
+type BlobStore interface {
+	// Put creates or replaces the object stored under key.
+	Put(key string, data []byte) error
+	// Get returns the object stored under key or ErrBlobNotFound.
+	Get(key string) ([]byte, error)
+	// Delete removes the object stored under key. Deleting a missing object is not an error.
+	Delete(key string) error
+	// List returns keys of all objects whose key starts with prefix.
+	List(prefix string) ([]string, error)
+}
+
+// Key prefixes used by the blob store backed Store.
+const (
+	modelsPrefix   = "models/"
+	versionsPrefix = "versions/"
+	clientsPrefix  = "clients/"
+	updatesPrefix  = "updates/"
+	weightsPrefix  = "weights/"
+	momentsPrefix  = "moments/"
+)
+
+// blobBackedStore implements Store on top of two blob stores, one for small JSON metadata documents and one
+// for model weights and update payloads. Metadata of every version is kept in a document of its own, so
+// that no document grows with the history of a model.
+type blobBackedStore struct {
+	metadata BlobStore
+	blobs    BlobStore
+
+	mu sync.Mutex
+	// written holds digests of the version documents known to be stored, so that versions which did not
+	// change are not written again each time their model is saved.
+	written map[string][sha256.Size]byte
+}
+
+// NewStore creates a Store that keeps JSON metadata in the metadata blob store and model weights and
+// update payloads in the blobs blob store.
+func NewStore(metadata, blobs BlobStore) Store {
+	return &blobBackedStore{metadata: metadata, blobs: blobs, written: make(map[string][sha256.Size]byte)}
+}
+
+// SaveModel implements Store interface. See Store for more information.
+func (s *blobBackedStore) SaveModel(state *ModelState) error {
+	for _, version := range state.Versions {
+		if err := s.saveVersion(version); err != nil {
+			return err
+		}
+	}
+
+	document := *state
+	document.Versions = nil
+	return s.putJSON(modelsPrefix+escapeKey(state.ID), &document)
+}
+
+// saveVersion persists metadata of a version unless it is already stored.
+func (s *blobBackedStore) saveVersion(version *GlobalModel) error {
+	data, err := json.Marshal(version)
+	if err != nil {
+		return err
+	}
+
+	key := versionKey(version.ID, version.Version)
+	digest := sha256.Sum256(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.written[key] == digest {
+		return nil
+	}
+	if err := s.metadata.Put(key, data); err != nil {
+		return err
+	}
+
+	s.written[key] = digest
+	return nil
+}
+
+// SaveWeights implements Store interface. See Store for more information.
+func (s *blobBackedStore) SaveWeights(model *GlobalModel) error {
+	return s.blobs.Put(weightsKey(model.ID, model.Version), model.Weights)
+}
+
+// LoadWeights implements Store interface. See Store for more information.
+func (s *blobBackedStore) LoadWeights(modelID string, version int) ([]byte, error) {
+	return s.blobs.Get(weightsKey(modelID, version))
+}
+
+// SaveMoments implements Store interface. See Store for more information.
+func (s *blobBackedStore) SaveMoments(modelID string, state *OptimizerState) error {
+	if err := s.blobs.Put(momentsKey(modelID, state.Version, "first"), state.FirstMoment); err != nil {
+		return err
+	}
+
+	return s.blobs.Put(momentsKey(modelID, state.Version, "second"), state.SecondMoment)
+}
+
+// DeleteMoments implements Store interface. See Store for more information.
+func (s *blobBackedStore) DeleteMoments(modelID string, version int) error {
+	for _, moment := range []string{"first", "second"} {
+		if err := s.blobs.Delete(momentsKey(modelID, version, moment)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveUpdate implements Store interface. See Store for more information.
+func (s *blobBackedStore) SaveUpdate(update *ModelUpdate) error {
+	key := updateKey(update.ModelID, update.ClientID)
+	if err := s.blobs.Put(key, update.WeightUpdate); err != nil {
+		return err
+	}
+
+	metadata := *update
+	metadata.WeightUpdate = nil
+	return s.putJSON(key, &metadata)
+}
+
+// DeleteUpdates implements Store interface. See Store for more information.
+func (s *blobBackedStore) DeleteUpdates(modelID string) error {
+	prefix := updatesPrefix + escapeKey(modelID) + "/"
+	for _, store := range []BlobStore{s.metadata, s.blobs} {
+		keys, err := store.List(prefix)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err := store.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SaveClient implements Store interface. See Store for more information.
+func (s *blobBackedStore) SaveClient(client *Client) error {
+	return s.putJSON(clientsPrefix+escapeKey(client.ID), client)
+}
+
+// LoadModels implements Store interface. See Store for more information.
+func (s *blobBackedStore) LoadModels() ([]*ModelState, error) {
+	keys, err := s.metadata.List(modelsPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]*ModelState, 0, len(keys))
+	for _, key := range keys {
+		state := new(ModelState)
+		if err := s.getJSON(key, state); err != nil {
+			return nil, err
+		}
+
+		// Models saved before versions were kept in documents of their own embed their versions.
+		if len(state.Versions) == 0 {
+			if state.Versions, err = s.loadVersions(state.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		if state.Model != nil {
+			state.Model.Weights, err = s.blobs.Get(weightsKey(state.Model.ID, state.Model.Version))
+			if err != nil {
+				return nil, fmt.Errorf("failed to load weights of model %s version %d: %v", state.Model.ID,
+					state.Model.Version, err)
+			}
+
+			if state.Updates, err = s.loadUpdates(state.Model.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := s.loadMoments(state.ID, state.Optimizer); err != nil {
+			return nil, err
+		}
+		if state.Candidate != nil {
+			if err := s.loadMoments(state.ID, state.Candidate.Optimizer); err != nil {
+				return nil, err
+			}
+		}
+
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+// LoadClients implements Store interface. See Store for more information.
+func (s *blobBackedStore) LoadClients() ([]*Client, error) {
+	keys, err := s.metadata.List(clientsPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make([]*Client, 0, len(keys))
+	for _, key := range keys {
+		client := new(Client)
+		if err := s.getJSON(key, client); err != nil {
+			return nil, err
+		}
+
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
+
+func (s *blobBackedStore) loadVersions(modelID string) ([]*GlobalModel, error) {
+	keys, err := s.metadata.List(versionsPrefix + escapeKey(modelID) + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]*GlobalModel, 0, len(keys))
+	for _, key := range keys {
+		version := new(GlobalModel)
+		if err := s.getJSON(key, version); err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	return versions, nil
+}
+
+// loadMoments loads the moment estimates of an optimizer state. Moments that were deleted before the state
+// referring to them was replaced leave the state without moments, the optimizer then starts from zero.
+func (s *blobBackedStore) loadMoments(modelID string, state *OptimizerState) error {
+	if state == nil {
+		return nil
+	}
+
+	for _, m := range []struct {
+		moment string
+		target *[]byte
+	}{{"first", &state.FirstMoment}, {"second", &state.SecondMoment}} {
+		data, err := s.blobs.Get(momentsKey(modelID, state.Version, m.moment))
+		if errors.Is(err, ErrBlobNotFound) {
+			log.Printf("Moments of the server optimizer of model %s version %d are missing, starting from zero",
+				modelID, state.Version)
+			state.FirstMoment, state.SecondMoment = nil, nil
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load moments of model %s version %d: %v", modelID, state.Version, err)
+		}
+		*m.target = data
+	}
+
+	return nil
+}
+
+func (s *blobBackedStore) loadUpdates(modelID string) ([]*ModelUpdate, error) {
+	keys, err := s.metadata.List(updatesPrefix + escapeKey(modelID) + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make([]*ModelUpdate, 0, len(keys))
+	for _, key := range keys {
+		update := new(ModelUpdate)
+		if err := s.getJSON(key, update); err != nil {
+			return nil, err
+		}
+
+		if update.WeightUpdate, err = s.blobs.Get(key); err != nil {
+			return nil, fmt.Errorf("failed to load update of client %s: %v", update.ClientID, err)
+		}
+
+		updates = append(updates, update)
+	}
+
+	return updates, nil
+}
+
+func (s *blobBackedStore) putJSON(key string, obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	return s.metadata.Put(key, data)
+}
+
+func (s *blobBackedStore) getJSON(key string, obj interface{}) error {
+	data, err := s.metadata.Get(key)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, obj); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", key, err)
+	}
+
+	return nil
+}
+
+func weightsKey(modelID string, version int) string {
+	return weightsPrefix + escapeKey(modelID) + "/" + strconv.Itoa(version)
+}
+
+func versionKey(modelID string, version int) string {
+	return versionsPrefix + escapeKey(modelID) + "/" + strconv.Itoa(version)
+}
+
+func momentsKey(modelID string, version int, moment string) string {
+	return momentsPrefix + escapeKey(modelID) + "/" + strconv.Itoa(version) + "/" + moment
+}
+
+func updateKey(modelID, clientID string) string {
+	return updatesPrefix + escapeKey(modelID) + "/" + escapeKey(clientID)
+}
+
+// escapeKey escapes an ID so that it can be used as a single segment of a blob store key. Client IDs
+// may contain characters such as '/'.
+func escapeKey(id string) string {
+	return url.PathEscape(id)
+}
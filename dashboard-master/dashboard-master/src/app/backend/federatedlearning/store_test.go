@@ -0,0 +1,200 @@
+package federatedlearning
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newTestPersistentCoordinator(t *testing.T, store Store) *Coordinator {
+	c, err := NewPersistentCoordinator(store)
+	if err != nil {
+		t.Fatalf("NewPersistentCoordinator(): unexpected error: %v", err)
+	}
+
+	return c
+}
+
+func TestPersistentCoordinatorRestore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore(): unexpected error: %v", err)
+	}
+
+	c := newTestPersistentCoordinator(t, store)
+	model, err := c.CreateModel(&ModelSpec{ID: "rrm-power-control", Weights: encodeWeights([]float32{0, 0}),
+		Config: ModelConfig{Round: RoundConfig{MinParticipants: 2}}})
+	if err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, id := range []string{"10.0.0.1:5000", "10.0.0.2:5000"} {
+		if _, err := c.RegisterClient(&Identity{ClientID: id}, &ClientRegistration{ModelID: model.ID}); err != nil {
+			t.Fatalf("RegisterClient(): unexpected error: %v", err)
+		}
+	}
+	if err := c.SubmitModelUpdate(&ModelUpdate{ClientID: "10.0.0.1:5000", ModelID: model.ID, BaseVersion: 1,
+		WeightUpdate: encodeWeights([]float32{2, 4}), NumSamples: 1}); err != nil {
+		t.Fatalf("SubmitModelUpdate(): unexpected error: %v", err)
+	}
+
+	restored := newTestPersistentCoordinator(t, store)
+	if client, err := restored.GetClient("10.0.0.1:5000"); err != nil || client.ModelID != model.ID {
+		t.Errorf("expected restored client to be enrolled in %s, got %+v, %v", model.ID, client, err)
+	}
+	if config := restored.GetModelConfig(model.ID); config.Round.MinParticipants != 2 {
+		t.Errorf("expected restored config, got %+v", config)
+	}
+	round, _ := restored.GetRound(model.ID)
+	if !reflect.DeepEqual(round.Participants, []string{"10.0.0.1:5000"}) || round.State != RoundCollecting {
+		t.Errorf("expected restored round, got %+v", round)
+	}
+
+	if err := restored.SubmitModelUpdate(&ModelUpdate{ClientID: "10.0.0.2:5000", ModelID: model.ID, BaseVersion: 1,
+		WeightUpdate: encodeWeights([]float32{4, 8}), NumSamples: 1}); err != nil {
+		t.Fatalf("SubmitModelUpdate(): unexpected error: %v", err)
+	}
+
+	restored = newTestPersistentCoordinator(t, store)
+	current, err := restored.GetModel(model.ID)
+	if err != nil {
+		t.Fatalf("GetModel(): unexpected error: %v", err)
+	}
+	weights, _ := decodeWeights(current.Weights)
+	if current.Version != 2 || !reflect.DeepEqual(weights, []float32{3, 6}) {
+		t.Errorf("expected restored version 2 with weights [3 6], got version %d with %v", current.Version, weights)
+	}
+	if pending := len(restored.modelUpdates[model.ID]); pending != 0 {
+		t.Errorf("expected no pending updates after aggregation, got %d", pending)
+	}
+}
+
+func TestStoreVersions(t *testing.T) {
+	metadata, _ := NewFileBlobStore(t.TempDir())
+	blobs, _ := NewFileBlobStore(t.TempDir())
+	store := NewStore(metadata, blobs)
+	c := newTestPersistentCoordinator(t, store)
+	if _, err := c.CreateModel(&ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}),
+		Config: ModelConfig{Round: RoundConfig{MinParticipants: 2}}}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, client := range []string{"a", "b"} {
+		c.RegisterClient(&Identity{ClientID: client}, &ClientRegistration{ModelID: "test"})
+	}
+	trainTestRound(t, c, []float32{1, 1})
+	trainTestRound(t, c, []float32{2, 2})
+
+	// The model document does not grow with the history of the model.
+	state := new(ModelState)
+	if data, err := metadata.Get(modelsPrefix + "test"); err != nil || json.Unmarshal(data, state) != nil {
+		t.Fatalf("expected model document, got %v", err)
+	}
+	if len(state.Versions) != 0 {
+		t.Errorf("expected versions to be stored separately, got %d embedded versions", len(state.Versions))
+	}
+	if keys, _ := metadata.List(versionsPrefix + "test/"); len(keys) != 3 {
+		t.Errorf("expected a document for each of 3 versions, got %v", keys)
+	}
+
+	restored := newTestPersistentCoordinator(t, store)
+	versions, _ := restored.ListVersions("test")
+	numbers := make([]int, 0)
+	for _, v := range versions {
+		numbers = append(numbers, v.Version)
+	}
+	if !reflect.DeepEqual(numbers, []int{1, 2, 3}) {
+		t.Errorf("expected restored versions [1 2 3], got %v", numbers)
+	}
+}
+
+func TestStoreLegacyVersions(t *testing.T) {
+	metadata, _ := NewFileBlobStore(t.TempDir())
+	blobs, _ := NewFileBlobStore(t.TempDir())
+	store := NewStore(metadata, blobs)
+
+	// Models saved before versions were kept in documents of their own embed their versions.
+	data, _ := json.Marshal(&ModelState{ID: "test", Versions: []*GlobalModel{
+		{ID: "test", Version: 1},
+		{ID: "test", Version: 2},
+	}})
+	if err := metadata.Put(modelsPrefix+"test", data); err != nil {
+		t.Fatalf("Put(): unexpected error: %v", err)
+	}
+
+	states, err := store.LoadModels()
+	if err != nil || len(states) != 1 {
+		t.Fatalf("LoadModels(): expected a single model, got %v, %v", states, err)
+	}
+	numbers := make([]int, 0)
+	for _, v := range states[0].Versions {
+		numbers = append(numbers, v.Version)
+	}
+	if !reflect.DeepEqual(numbers, []int{1, 2}) {
+		t.Errorf("expected embedded versions [1 2], got %v", numbers)
+	}
+
+	// Saving the model again moves its versions into documents of their own.
+	if err := store.SaveModel(states[0]); err != nil {
+		t.Fatalf("SaveModel(): unexpected error: %v", err)
+	}
+	if keys, _ := metadata.List(versionsPrefix + "test/"); len(keys) != 2 {
+		t.Errorf("expected a document for each of 2 versions, got %v", keys)
+	}
+	if states, err = store.LoadModels(); err != nil || len(states[0].Versions) != 2 {
+		t.Errorf("expected 2 versions after migration, got %v", err)
+	}
+}
+
+func TestStoreMoments(t *testing.T) {
+	metadata, _ := NewFileBlobStore(t.TempDir())
+	blobs, _ := NewFileBlobStore(t.TempDir())
+	store := NewStore(metadata, blobs)
+
+	// Moments of a model with a million weights would not fit into a ConfigMap.
+	moment := make([]float64, 1<<20)
+	for i := range moment {
+		moment[i] = 0.5
+	}
+	state := &OptimizerState{Algorithm: FedAdam, Steps: 3, Version: 3, FirstMoment: encodeVector(moment),
+		SecondMoment: encodeVector(moment)}
+	if err := store.SaveMoments("test", state); err != nil {
+		t.Fatalf("SaveMoments(): unexpected error: %v", err)
+	}
+	if err := store.SaveModel(&ModelState{ID: "test", Optimizer: state}); err != nil {
+		t.Fatalf("SaveModel(): unexpected error: %v", err)
+	}
+
+	if data, _ := metadata.Get(modelsPrefix + "test"); len(data) > 1024 {
+		t.Errorf("expected moments to be kept out of the model document, got %d bytes", len(data))
+	}
+	states, err := store.LoadModels()
+	if err != nil || len(states) != 1 {
+		t.Fatalf("LoadModels(): expected a single model, got %v, %v", states, err)
+	}
+	if restored := states[0].Optimizer; !reflect.DeepEqual(restored, state) {
+		t.Errorf("expected optimizer state with moments to be restored, got version %d with %d bytes", restored.Version,
+			len(restored.FirstMoment))
+	}
+
+	// The optimizer starts from zero if the moments were removed.
+	if err := store.DeleteMoments("test", 3); err != nil {
+		t.Fatalf("DeleteMoments(): unexpected error: %v", err)
+	}
+	states, err = store.LoadModels()
+	if err != nil || states[0].Optimizer.FirstMoment != nil {
+		t.Errorf("expected optimizer state without moments, got %v", err)
+	}
+}
+
+func TestFileBlobStoreInvalidKey(t *testing.T) {
+	store, _ := NewFileBlobStore(t.TempDir())
+	for _, key := range []string{"", "a//b", "../escape", "a/./b"} {
+		if err := store.Put(key, []byte("data")); err == nil {
+			t.Errorf("expected error for key %q", key)
+		}
+	}
+
+	if _, err := store.Get("missing"); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
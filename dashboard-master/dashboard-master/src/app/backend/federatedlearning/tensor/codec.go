@@ -0,0 +1,158 @@
+package tensor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// magic starts every encoded container.
+var magic = []byte("FLTS")
+
+// formatVersion is the version of the binary layout written by Encode.
+const formatVersion = 1
+
+// IsEncoded returns true if data starts like an encoded container. Data that does not is typically a flat
+// little-endian float32 vector.
+func IsEncoded(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// Encode encodes tensors into a container.
+func Encode(tensors []*Tensor) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(magic)
+	buf.WriteByte(formatVersion)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(tensors)))
+
+	names := make(map[string]bool, len(tensors))
+	for _, t := range tensors {
+		if err := t.validate(); err != nil {
+			return nil, err
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("duplicate tensor %s", t.Name)
+		}
+		names[t.Name] = true
+		if len(t.Data) != t.Len()*t.DType.Size() {
+			return nil, fmt.Errorf("tensor %s has %d bytes of data, expected %d", t.Name, len(t.Data),
+				t.Len()*t.DType.Size())
+		}
+
+		binary.Write(&buf, binary.LittleEndian, uint16(len(t.Name)))
+		buf.WriteString(t.Name)
+		buf.WriteByte(byte(t.DType))
+		buf.WriteByte(byte(len(t.Shape)))
+		for _, dim := range t.Shape {
+			binary.Write(&buf, binary.LittleEndian, uint32(dim))
+		}
+		if t.DType == Int8 {
+			binary.Write(&buf, binary.LittleEndian, math.Float32bits(t.Scale))
+		}
+	}
+
+	for _, t := range tensors {
+		buf.Write(t.Data)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decode decodes a container. The data of the returned tensors references data.
+func Decode(data []byte) ([]*Tensor, error) {
+	r := &reader{data: data}
+	if !IsEncoded(data) {
+		return nil, fmt.Errorf("data is not a tensor container")
+	}
+	r.offset = len(magic)
+
+	if version := r.uint8(); version != formatVersion {
+		return nil, fmt.Errorf("unsupported tensor container version %d", version)
+	}
+
+	count := int(r.uint32())
+	// Every header takes at least 4 bytes, which bounds the number of tensors by the size of the data.
+	if count > len(data)/4 {
+		return nil, fmt.Errorf("tensor container of %d bytes cannot hold %d tensors", len(data), count)
+	}
+
+	tensors := make([]*Tensor, count)
+	names := make(map[string]bool, count)
+	for i := range tensors {
+		t := &Tensor{Name: string(r.bytes(int(r.uint16()))), DType: DType(r.uint8())}
+		t.Shape = make([]int, r.uint8())
+		for j := range t.Shape {
+			t.Shape[j] = int(r.uint32())
+		}
+		if t.DType == Int8 {
+			t.Scale = math.Float32frombits(r.uint32())
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		if err := t.validate(); err != nil {
+			return nil, err
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("duplicate tensor %s", t.Name)
+		}
+		names[t.Name] = true
+		tensors[i] = t
+	}
+
+	for _, t := range tensors {
+		t.Data = r.bytes(t.Len() * t.DType.Size())
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.offset != len(data) {
+		return nil, fmt.Errorf("tensor container has %d trailing bytes", len(data)-r.offset)
+	}
+
+	return tensors, nil
+}
+
+// reader reads little-endian values from a byte slice. Reads past the end set err and return zero values.
+type reader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data)-r.offset {
+		r.err = fmt.Errorf("tensor container is truncated at offset %d", r.offset)
+		return nil
+	}
+
+	b := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return b
+}
+
+func (r *reader) uint8() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) uint16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
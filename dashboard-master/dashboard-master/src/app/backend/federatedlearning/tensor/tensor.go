@@ -0,0 +1,304 @@
+// Package tensor implements a framework-neutral container for the weights of federated learning models.
+//
+// A container holds named tensors with a data type and a shape. All values are little-endian. The binary
+// layout is:
+//
+//	magic      "FLTS"
+//	version    uint8, currently 1
+//	count      uint32, number of tensors
+//	headers    count tensor headers
+//	data       values of all tensors in header order, row-major
+//
+// and every tensor header is:
+//
+//	name       uint16 length followed by the UTF-8 name
+//	dtype      uint8, see DType
+//	rank       uint8
+//	shape      rank uint32 dimensions
+//	scale      float32, only present for Int8 tensors
+package tensor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DType is the data type of the values of a tensor.
+type DType uint8
+
+const (
+	// Float32 values are IEEE 754 single precision floats.
+	Float32 DType = 1
+	// Float16 values are IEEE 754 half precision floats.
+	Float16 DType = 2
+	// Int8 values are symmetrically quantized, a value v represents v times the scale of the tensor.
+	Int8 DType = 3
+)
+
+var dtypeNames = map[DType]string{Float32: "float32", Float16: "float16", Int8: "int8"}
+
+// Size returns the size of a single value in bytes, or 0 for unknown data types.
+func (d DType) Size() int {
+	switch d {
+	case Float32:
+		return 4
+	case Float16:
+		return 2
+	case Int8:
+		return 1
+	}
+
+	return 0
+}
+
+// String returns the name of the data type.
+func (d DType) String() string {
+	if name, exists := dtypeNames[d]; exists {
+		return name
+	}
+
+	return fmt.Sprintf("dtype(%d)", uint8(d))
+}
+
+// MarshalText encodes the data type as its name.
+func (d DType) MarshalText() ([]byte, error) {
+	if _, exists := dtypeNames[d]; !exists {
+		return nil, fmt.Errorf("unknown dtype %d", uint8(d))
+	}
+
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText decodes a data type from its name.
+func (d *DType) UnmarshalText(text []byte) error {
+	for dtype, name := range dtypeNames {
+		if name == string(text) {
+			*d = dtype
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown dtype %q", text)
+}
+
+// Spec describes the layout of a tensor.
+type Spec struct {
+	Name  string `json:"name"`
+	DType DType  `json:"dtype"`
+	Shape []int  `json:"shape"`
+}
+
+// Len returns the number of values of a tensor with the spec.
+func (s Spec) Len() int {
+	n := 1
+	for _, dim := range s.Shape {
+		n *= dim
+	}
+
+	return n
+}
+
+// Tensor is a named tensor.
+type Tensor struct {
+	Name  string
+	DType DType
+	Shape []int
+	// Scale is the quantization scale of Int8 tensors.
+	Scale float32
+	// Data holds the little-endian values in row-major order.
+	Data []byte
+}
+
+// Spec returns the layout of the tensor.
+func (t *Tensor) Spec() Spec {
+	return Spec{Name: t.Name, DType: t.DType, Shape: append([]int(nil), t.Shape...)}
+}
+
+// Len returns the number of values of the tensor.
+func (t *Tensor) Len() int {
+	return t.Spec().Len()
+}
+
+// New creates a tensor of the given data type holding values. Int8 tensors are quantized with the scale
+// that maps the largest absolute value to 127.
+func New(name string, dtype DType, shape []int, values []float32) (*Tensor, error) {
+	t := &Tensor{Name: name, DType: dtype, Shape: append([]int(nil), shape...)}
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	if t.Len() != len(values) {
+		return nil, fmt.Errorf("tensor %s of shape %v needs %d values, got %d", name, shape, t.Len(), len(values))
+	}
+
+	t.Data = make([]byte, len(values)*dtype.Size())
+	switch dtype {
+	case Float32:
+		for i, v := range values {
+			binary.LittleEndian.PutUint32(t.Data[i*4:], math.Float32bits(v))
+		}
+	case Float16:
+		for i, v := range values {
+			binary.LittleEndian.PutUint16(t.Data[i*2:], float32ToFloat16(v))
+		}
+	case Int8:
+		var max float64
+		for _, v := range values {
+			max = math.Max(max, math.Abs(float64(v)))
+		}
+		if max > 0 {
+			t.Scale = float32(max / 127)
+		}
+		for i, v := range values {
+			if t.Scale != 0 {
+				t.Data[i] = byte(int8(math.Max(-127, math.Min(127, math.Round(float64(v/t.Scale))))))
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// Float32s returns the values of the tensor as float32. Int8 values are multiplied by the scale.
+func (t *Tensor) Float32s() ([]float32, error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	if len(t.Data) != t.Len()*t.DType.Size() {
+		return nil, fmt.Errorf("tensor %s has %d bytes of data, expected %d", t.Name, len(t.Data),
+			t.Len()*t.DType.Size())
+	}
+
+	values := make([]float32, t.Len())
+	for i := range values {
+		switch t.DType {
+		case Float32:
+			values[i] = math.Float32frombits(binary.LittleEndian.Uint32(t.Data[i*4:]))
+		case Float16:
+			values[i] = float16ToFloat32(binary.LittleEndian.Uint16(t.Data[i*2:]))
+		case Int8:
+			values[i] = float32(int8(t.Data[i])) * t.Scale
+		}
+	}
+
+	return values, nil
+}
+
+// validate checks the header of the tensor.
+func (t *Tensor) validate() error {
+	if t.Name == "" || len(t.Name) > math.MaxUint16 {
+		return fmt.Errorf("tensor name must be between 1 and %d bytes", math.MaxUint16)
+	}
+	if t.DType.Size() == 0 {
+		return fmt.Errorf("tensor %s has unknown dtype %d", t.Name, uint8(t.DType))
+	}
+	if len(t.Shape) > math.MaxUint8 {
+		return fmt.Errorf("tensor %s has rank %d, at most %d is supported", t.Name, len(t.Shape), math.MaxUint8)
+	}
+
+	n := 1
+	for _, dim := range t.Shape {
+		if dim < 0 || dim > math.MaxUint32 {
+			return fmt.Errorf("tensor %s has invalid shape %v", t.Name, t.Shape)
+		}
+		if dim > 0 && n > math.MaxInt32/dim {
+			return fmt.Errorf("tensor %s of shape %v is too large", t.Name, t.Shape)
+		}
+		n *= dim
+	}
+
+	return nil
+}
+
+// Schema returns the layout of tensors.
+func Schema(tensors []*Tensor) []Spec {
+	schema := make([]Spec, len(tensors))
+	for i, t := range tensors {
+		schema[i] = t.Spec()
+	}
+
+	return schema
+}
+
+// CheckSchema checks that tensors with the actual layout can be combined with tensors of the expected
+// layout: both have to hold the same tensors in the same order with the same shapes. Data types may differ,
+// values are combined as float32.
+func CheckSchema(expected, actual []Spec) error {
+	if len(expected) != len(actual) {
+		return fmt.Errorf("expected %d tensors, got %d", len(expected), len(actual))
+	}
+
+	for i := range expected {
+		if expected[i].Name != actual[i].Name {
+			return fmt.Errorf("expected tensor %s at position %d, got %s", expected[i].Name, i, actual[i].Name)
+		}
+		if !equalShapes(expected[i].Shape, actual[i].Shape) {
+			return fmt.Errorf("expected tensor %s of shape %v, got shape %v", expected[i].Name, expected[i].Shape,
+				actual[i].Shape)
+		}
+	}
+
+	return nil
+}
+
+func equalShapes(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Flatten returns the values of all tensors, in order, as a single float32 vector.
+func Flatten(tensors []*Tensor) ([]float32, error) {
+	values := make([]float32, 0)
+	for _, t := range tensors {
+		v, err := t.Float32s()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v...)
+	}
+
+	return values, nil
+}
+
+// Unflatten splits a float32 vector into tensors of the given layout. It is the inverse of Flatten.
+func Unflatten(schema []Spec, values []float32) ([]*Tensor, error) {
+	total := 0
+	for _, spec := range schema {
+		total += spec.Len()
+	}
+	if total != len(values) {
+		return nil, fmt.Errorf("layout holds %d values, got %d", total, len(values))
+	}
+
+	tensors := make([]*Tensor, len(schema))
+	offset := 0
+	for i, spec := range schema {
+		t, err := New(spec.Name, spec.DType, spec.Shape, values[offset:offset+spec.Len()])
+		if err != nil {
+			return nil, err
+		}
+		tensors[i] = t
+		offset += spec.Len()
+	}
+
+	return tensors, nil
+}
+
+// String returns a short description of a layout, e.g. "dense/kernel:float32[4 2], dense/bias:float32[2]".
+func String(schema []Spec) string {
+	parts := make([]string, len(schema))
+	for i, spec := range schema {
+		parts[i] = fmt.Sprintf("%s:%s%v", spec.Name, spec.DType, spec.Shape)
+	}
+
+	return strings.Join(parts, ", ")
+}
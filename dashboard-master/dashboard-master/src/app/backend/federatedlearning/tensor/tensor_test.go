@@ -0,0 +1,134 @@
+package tensor
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	values := []float32{-1.5, 0, 0.25, 1, 2, 3}
+	cases := []struct {
+		info      string
+		dtype     DType
+		tolerance float64
+	}{
+		{"float32 should be exact", Float32, 0},
+		{"float16 should be exact for representable values", Float16, 0},
+		{"int8 should be within half a quantization step", Int8, 3.0 / 127 / 2},
+	}
+
+	for _, tc := range cases {
+		kernel, err := New("dense/kernel", tc.dtype, []int{3, 2}, values)
+		if err != nil {
+			t.Fatalf("%s: New(): unexpected error: %v", tc.info, err)
+		}
+		bias, _ := New("dense/bias", Float32, []int{2}, []float32{7, 8})
+
+		data, err := Encode([]*Tensor{kernel, bias})
+		if err != nil {
+			t.Fatalf("%s: Encode(): unexpected error: %v", tc.info, err)
+		}
+		if !IsEncoded(data) {
+			t.Errorf("%s: expected encoded data to be recognized", tc.info)
+		}
+
+		tensors, err := Decode(data)
+		if err != nil {
+			t.Fatalf("%s: Decode(): unexpected error: %v", tc.info, err)
+		}
+		expected := []Spec{{"dense/kernel", tc.dtype, []int{3, 2}}, {"dense/bias", Float32, []int{2}}}
+		if !reflect.DeepEqual(Schema(tensors), expected) {
+			t.Errorf("%s: expected schema %v, got %v", tc.info, expected, Schema(tensors))
+		}
+
+		flat, err := Flatten(tensors)
+		if err != nil {
+			t.Fatalf("%s: Flatten(): unexpected error: %v", tc.info, err)
+		}
+		for i, v := range append(append([]float32(nil), values...), 7, 8) {
+			if math.Abs(float64(flat[i]-v)) > tc.tolerance {
+				t.Errorf("%s: expected value %d to be %g, got %g", tc.info, i, v, flat[i])
+			}
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tensor, _ := New("w", Float32, []int{2}, []float32{1, 2})
+	data, _ := Encode([]*Tensor{tensor})
+	duplicate, _ := Encode([]*Tensor{tensor})
+	// Declare a second tensor without a header.
+	duplicate[5] = 2
+
+	cases := []struct {
+		info string
+		data []byte
+	}{
+		{"should reject flat vectors", []byte{0, 0, 128, 63}},
+		{"should reject truncated data", data[:len(data)-1]},
+		{"should reject trailing data", append(append([]byte(nil), data...), 0)},
+		{"should reject unknown versions", append([]byte("FLTS\x02"), data[5:]...)},
+		{"should reject missing headers", duplicate},
+		{"should reject huge tensor counts", []byte("FLTS\x01\xff\xff\xff\xff")},
+	}
+
+	for _, tc := range cases {
+		if _, err := Decode(tc.data); err == nil {
+			t.Errorf("%s: expected error", tc.info)
+		}
+	}
+}
+
+func TestFloat16(t *testing.T) {
+	cases := []struct {
+		value    float32
+		expected uint16
+	}{
+		{1, 0x3c00},
+		{-2, 0xc000},
+		{65504, 0x7bff},
+		{65520, 0x7c00},
+		{float32(math.Inf(-1)), 0xfc00},
+		// Smallest subnormal, 2^-24.
+		{5.9604645e-08, 0x0001},
+		// Ties round to even.
+		{1 + 1.0/2048, 0x3c00},
+		{1 + 3.0/2048, 0x3c02},
+	}
+
+	for _, tc := range cases {
+		if actual := float32ToFloat16(tc.value); actual != tc.expected {
+			t.Errorf("float32ToFloat16(%g): expected %#04x, got %#04x", tc.value, tc.expected, actual)
+		}
+	}
+
+	for _, h := range []uint16{0x0001, 0x03ff, 0x3c00, 0x7bff, 0xc000} {
+		if actual := float32ToFloat16(float16ToFloat32(h)); actual != h {
+			t.Errorf("expected %#04x to round trip, got %#04x", h, actual)
+		}
+	}
+	if v := float16ToFloat32(float32ToFloat16(float32(math.NaN()))); !math.IsNaN(float64(v)) {
+		t.Errorf("expected NaN to round trip, got %g", v)
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	expected := []Spec{{"kernel", Float32, []int{2, 2}}, {"bias", Float32, []int{2}}}
+	cases := []struct {
+		info   string
+		actual []Spec
+		valid  bool
+	}{
+		{"should accept other data types", []Spec{{"kernel", Int8, []int{2, 2}}, {"bias", Float16, []int{2}}}, true},
+		{"should reject other shapes", []Spec{{"kernel", Float32, []int{4}}, {"bias", Float32, []int{2}}}, false},
+		{"should reject other order", []Spec{{"bias", Float32, []int{2}}, {"kernel", Float32, []int{2, 2}}}, false},
+		{"should reject missing tensors", expected[:1], false},
+	}
+
+	for _, tc := range cases {
+		if err := CheckSchema(expected, tc.actual); (err == nil) != tc.valid {
+			t.Errorf("%s: unexpected result %v", tc.info, err)
+		}
+	}
+}
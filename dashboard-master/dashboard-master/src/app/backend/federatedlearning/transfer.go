@@ -0,0 +1,452 @@
+package federatedlearning
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+const (
+	// digestHeader carries the SHA-256 digest of transferred weights as defined by RFC 3230, e.g.
+	// "sha-256=<base64>".
+	digestHeader = "Digest"
+	// versionHeader carries the version of downloaded weights.
+	versionHeader = "X-Model-Version"
+	// uploadTTL is the time after which an incomplete upload is discarded.
+	uploadTTL = time.Hour
+	// uploadExpiryPeriod is how often Run looks for expired uploads.
+	uploadExpiryPeriod = time.Minute
+)
+
+// uploads keeps track of weight uploads that arrive in multiple chunks. Chunks are appended to a temporary
+// file until the whole payload was received.
+type uploads struct {
+	mu       sync.Mutex
+	dir      string
+	sessions map[string]*upload
+}
+
+// upload is a single, possibly incomplete, weight upload.
+type upload struct {
+	mu       sync.Mutex
+	path     string
+	size     int64
+	received int64
+	// done is set once the upload was completed or discarded.
+	done bool
+	// updated is the time the last chunk arrived. It is guarded by the lock of uploads.
+	updated time.Time
+}
+
+func newUploads() *uploads {
+	return &uploads{dir: filepath.Join(os.TempDir(), "federated-learning-uploads"), sessions: make(map[string]*upload)}
+}
+
+// Run discards uploads that were not continued for uploadTTL until stopCh is closed, so that their
+// temporary files are removed even if no other upload arrives. It blocks, so it should be started in a
+// separate goroutine.
+func (a *API) Run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(uploadExpiryPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			a.uploads.expire()
+		}
+	}
+}
+
+// weightsDownloader is implemented by coordinator calls that return weights to be downloaded.
+type weightsDownloader func() (*GlobalModel, error)
+
+// downloadWeights streams the weights of a model version. Range requests are supported so that interrupted
+// downloads can be resumed, and the digest of the weights is sent so that clients can verify them.
+func (a *API) downloadWeights(req *restful.Request, resp *restful.Response, get weightsDownloader) {
+	model, err := get()
+	if err != nil {
+		resp.WriteError(httpStatus(err), err)
+		return
+	}
+
+	if digest := weightsDigest(model.Weights); model.Digest != "" && digest != model.Digest {
+		log.Printf("Weights of model %s version %d do not match recorded digest: expected %s, got %s", model.ID,
+			model.Version, model.Digest, digest)
+		resp.WriteErrorString(http.StatusInternalServerError, "stored weights do not match recorded digest")
+		return
+	}
+
+	header := resp.ResponseWriter.Header()
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set("ETag", strconv.Quote(weightsDigest(model.Weights)))
+	header.Set(digestHeader, formatDigestHeader(model.Weights))
+	header.Set(versionHeader, strconv.Itoa(model.Version))
+	http.ServeContent(resp.ResponseWriter, req.Request, "", model.CreatedAt, bytes.NewReader(model.Weights))
+}
+
+func (a *API) downloadModelWeights(req *restful.Request, resp *restful.Response) {
+	a.downloadWeights(req, resp, func() (*GlobalModel, error) {
+		return a.coordinator.GetModel(req.PathParameter("modelId"))
+	})
+}
+
+func (a *API) downloadVersionWeights(req *restful.Request, resp *restful.Response) {
+	a.downloadWeights(req, resp, func() (*GlobalModel, error) {
+		version, err := strconv.Atoi(req.PathParameter("version"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrVersionNotFound, req.PathParameter("version"))
+		}
+
+		return a.coordinator.GetModelVersion(req.PathParameter("modelId"), version)
+	})
+}
+
+// uploadUpdateWeights accepts a model update whose weights are sent as raw bytes. Update metadata is
+// passed in query parameters and the Digest header is required. Large payloads can be sent in chunks
+// using Content-Range headers, each chunk has to start where the previous one ended. Incomplete uploads are
+// answered with 308 and a Range header describing the bytes received so far.
+func (a *API) uploadUpdateWeights(req *restful.Request, resp *restful.Response) {
+	update, err := updateFromQuery(req)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err := a.authorizeClient(req, update.ClientID); err != nil {
+		writeClientError(resp, err)
+		return
+	}
+
+	digest, err := parseDigestHeader(req.HeaderParameter(digestHeader))
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	start, size, err := parseContentRange(req.HeaderParameter("Content-Range"), req.Request.ContentLength)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	// Uploads exceeding the quota are rejected before they are stored.
+	if maxSize := a.coordinator.Quota(update.ModelID).MaxUpdateSize; maxSize > 0 && size > int64(maxSize) {
+		err := fmt.Errorf("%w: upload of %d bytes, model %s accepts at most %d bytes", ErrUpdateTooLarge, size,
+			update.ModelID, maxSize)
+		a.coordinator.observeRejectedRequest(update.ModelID, err)
+		writeClientError(resp, err)
+		return
+	}
+
+	key := strings.Join([]string{update.ModelID, update.ClientID, digest}, "/")
+	data, received, err := a.uploads.write(key, start, size, req.Request.Body)
+	if err != nil {
+		setReceivedRange(resp, received)
+		resp.WriteError(http.StatusRequestedRangeNotSatisfiable, err)
+		return
+	}
+
+	if data == nil {
+		setReceivedRange(resp, received)
+		resp.WriteHeader(http.StatusPermanentRedirect)
+		return
+	}
+
+	if actual := weightsDigest(data); actual != digest {
+		resp.WriteError(http.StatusBadRequest, fmt.Errorf("%w: weights digest %s does not match %s",
+			ErrInvalidUpdate, actual, digest))
+		return
+	}
+
+	update.WeightUpdate = data
+	if err := a.coordinator.SubmitModelUpdate(update); err != nil {
+		writeClientError(resp, err)
+		return
+	}
+
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// uploadStatus reports how many bytes of an upload were received, so that clients can resume it.
+func (a *API) uploadStatus(req *restful.Request, resp *restful.Response) {
+	update, err := updateFromQuery(req)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err := a.authorizeClient(req, update.ClientID); err != nil {
+		writeClientError(resp, err)
+		return
+	}
+
+	digest, err := parseDigestHeader(req.HeaderParameter(digestHeader))
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	received, exists := a.uploads.status(strings.Join([]string{update.ModelID, update.ClientID, digest}, "/"))
+	if !exists {
+		resp.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	setReceivedRange(resp, received)
+	resp.WriteHeader(http.StatusPermanentRedirect)
+}
+
+// write appends a chunk to an upload. It returns the whole payload once the last chunk was received,
+// together with the number of bytes received so far.
+func (u *uploads) write(key string, start, size int64, body io.Reader) ([]byte, int64, error) {
+	session, err := u.session(key, start, size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if session.done {
+		return nil, 0, fmt.Errorf("upload was completed or discarded")
+	}
+
+	if start != session.received || size != session.size {
+		return nil, session.received, fmt.Errorf("chunk starts at %d of %d bytes, expected %d of %d bytes", start,
+			size, session.received, session.size)
+	}
+
+	file, err := os.OpenFile(session.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, session.received, err
+	}
+	written, err := io.Copy(file, io.LimitReader(body, session.size-session.received))
+	file.Close()
+	session.received += written
+	if err != nil {
+		return nil, session.received, err
+	}
+
+	if session.received < session.size {
+		return nil, session.received, nil
+	}
+
+	data, err := os.ReadFile(session.path)
+	u.mu.Lock()
+	delete(u.sessions, key)
+	u.mu.Unlock()
+	session.discard()
+	return data, int64(len(data)), err
+}
+
+// session returns the upload with the given key. A new upload is started if the chunk starts at offset 0.
+// Uploads that were not continued for uploadTTL are discarded.
+func (u *uploads) session(key string, start, size int64) (*upload, error) {
+	u.mu.Lock()
+	expired := u.removeExpired()
+	// A client uploads one update of a model at a time, a new upload replaces an unfinished one.
+	if _, exists := u.sessions[key]; !exists && start == 0 {
+		prefix := key[:strings.LastIndex(key, "/")+1]
+		for k, session := range u.sessions {
+			if strings.HasPrefix(k, prefix) {
+				expired = append(expired, session)
+				delete(u.sessions, k)
+			}
+		}
+	}
+	session, err := u.getOrCreate(key, start, size)
+	u.mu.Unlock()
+
+	discardUploads(expired)
+	return session, err
+}
+
+// expire discards uploads that were not continued for uploadTTL.
+func (u *uploads) expire() {
+	u.mu.Lock()
+	expired := u.removeExpired()
+	u.mu.Unlock()
+
+	discardUploads(expired)
+}
+
+// removeExpired removes uploads that were not continued for uploadTTL and returns them, so that they can be
+// discarded once the lock is released. Must be called with the lock held.
+func (u *uploads) removeExpired() []*upload {
+	expired := make([]*upload, 0)
+	for k, session := range u.sessions {
+		if time.Since(session.updated) > uploadTTL {
+			expired = append(expired, session)
+			delete(u.sessions, k)
+		}
+	}
+
+	return expired
+}
+
+// discardUploads discards uploads removed from the sessions.
+func discardUploads(sessions []*upload) {
+	for _, session := range sessions {
+		session.mu.Lock()
+		session.discard()
+		session.mu.Unlock()
+	}
+}
+
+// getOrCreate returns an existing upload or starts a new one. Must be called with the lock held.
+func (u *uploads) getOrCreate(key string, start, size int64) (*upload, error) {
+	if session, exists := u.sessions[key]; exists {
+		session.updated = time.Now()
+		return session, nil
+	}
+
+	if start != 0 {
+		return nil, fmt.Errorf("upload does not exist, it has to start at offset 0")
+	}
+
+	if err := os.MkdirAll(u.dir, 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp(u.dir, "upload-")
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+
+	session := &upload{path: file.Name(), size: size, updated: time.Now()}
+	u.sessions[key] = session
+	return session, nil
+}
+
+// status returns the number of bytes received by an upload.
+func (u *uploads) status(key string) (int64, bool) {
+	u.mu.Lock()
+	session, exists := u.sessions[key]
+	u.mu.Unlock()
+	if !exists {
+		return 0, false
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return session.received, true
+}
+
+// discard removes the temporary file of an upload. Must be called with the lock of the upload held.
+func (s *upload) discard() {
+	s.done = true
+	os.Remove(s.path)
+}
+
+// updateFromQuery reads update metadata of a binary upload from query parameters.
+func updateFromQuery(req *restful.Request) (*ModelUpdate, error) {
+	update := &ModelUpdate{ModelID: req.PathParameter("modelId"), ClientID: req.QueryParameter("clientId")}
+	if update.ClientID == "" {
+		return nil, fmt.Errorf("clientId query parameter is required")
+	}
+
+	var err error
+	if update.BaseVersion, err = strconv.Atoi(req.QueryParameter("baseVersion")); err != nil {
+		return nil, fmt.Errorf("invalid baseVersion query parameter: %v", err)
+	}
+
+	if value := req.QueryParameter("numSamples"); value != "" {
+		if update.NumSamples, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("invalid numSamples query parameter: %v", err)
+		}
+	}
+
+	// Metrics are passed as repeated metric=<name>:<value> parameters.
+	for _, value := range req.Request.URL.Query()["metric"] {
+		separator := strings.LastIndex(value, ":")
+		if separator < 0 {
+			return nil, fmt.Errorf("invalid metric query parameter: %s", value)
+		}
+		metric, err := strconv.ParseFloat(value[separator+1:], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric query parameter: %s", value)
+		}
+		if update.Metrics == nil {
+			update.Metrics = make(map[string]float64)
+		}
+		update.Metrics[value[:separator]] = metric
+	}
+
+	return update, nil
+}
+
+// formatDigestHeader returns the value of the Digest header for the given weights.
+func formatDigestHeader(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// parseDigestHeader parses a "sha-256=<base64>" Digest header into the "sha256:<hex>" format.
+func parseDigestHeader(value string) (string, error) {
+	for _, part := range strings.Split(value, ",") {
+		algorithm, encoded, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found || !strings.EqualFold(algorithm, "sha-256") {
+			continue
+		}
+
+		sum, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(sum) != sha256.Size {
+			return "", fmt.Errorf("invalid sha-256 digest: %s", encoded)
+		}
+
+		return digestAlgorithm + hex.EncodeToString(sum), nil
+	}
+
+	return "", fmt.Errorf("%s header with a sha-256 digest is required", digestHeader)
+}
+
+// parseContentRange parses a "bytes <start>-<end>/<size>" Content-Range header. Requests without the header
+// carry the whole payload, requests with it have to carry exactly the announced chunk.
+func parseContentRange(value string, contentLength int64) (start int64, size int64, err error) {
+	if value == "" {
+		if contentLength < 0 {
+			return 0, 0, fmt.Errorf("Content-Length or Content-Range header is required")
+		}
+
+		return 0, contentLength, nil
+	}
+
+	var end int64
+	if _, err := fmt.Sscanf(value, "bytes %d-%d/%d", &start, &end, &size); err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range header: %s", value)
+	}
+
+	if start < 0 || end < start || end >= size {
+		return 0, 0, fmt.Errorf("invalid Content-Range header: %s", value)
+	}
+
+	if length := end - start + 1; contentLength != length {
+		return 0, 0, fmt.Errorf("Content-Range header announces %d bytes, but Content-Length is %d", length,
+			contentLength)
+	}
+
+	return start, size, nil
+}
+
+// setReceivedRange sets the Range header describing the bytes of an upload received so far. The header is
+// omitted if nothing was received.
+func setReceivedRange(resp *restful.Response, received int64) {
+	if received > 0 {
+		resp.AddHeader("Range", fmt.Sprintf("bytes=0-%d", received-1))
+	}
+}
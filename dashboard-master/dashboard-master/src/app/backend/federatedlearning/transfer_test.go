@@ -0,0 +1,122 @@
+package federatedlearning
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDownloadWeights(t *testing.T) {
+	c := newTestCoordinator(t, 2)
+	server := newTestAPIServer(c)
+	defer server.Close()
+
+	model, _ := c.GetModel("test")
+	resp, err := http.Get(server.URL + "/api/v1/fl/model/test/weights")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(data, model.Weights) {
+		t.Fatalf("expected weights with status 200, got status %d with %v", resp.StatusCode, data)
+	}
+	if resp.Header.Get(digestHeader) != formatDigestHeader(model.Weights) || resp.ContentLength != int64(len(data)) {
+		t.Errorf("unexpected headers: %v", resp.Header)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/fl/model/test/versions/1/weights", nil)
+	req.Header.Set("Range", "bytes=4-")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent || !bytes.Equal(data, model.Weights[4:]) {
+		t.Errorf("expected partial content, got status %d with %v", resp.StatusCode, data)
+	}
+}
+
+func TestUploadUpdateWeights(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a")
+	server := newTestAPIServer(c)
+	defer server.Close()
+
+	url := server.URL + "/api/v1/fl/model/test/update/weights?clientId=a&baseVersion=1&numSamples=10"
+	weights := encodeWeights([]float32{1, 2})
+	put := func(chunk []byte, contentRange string, digest []byte) *http.Response {
+		req, _ := http.NewRequest(http.MethodPut, url, bytes.NewReader(chunk))
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set(digestHeader, formatDigestHeader(digest))
+		req.Header.Set("Authorization", "Bearer a")
+		if contentRange != "" {
+			req.Header.Set("Content-Range", contentRange)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := put(weights, "", []byte("other")); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected digest mismatch to be rejected, got status %d", resp.StatusCode)
+	}
+
+	resp := put(weights[:3], fmt.Sprintf("bytes 0-2/%d", len(weights)), weights)
+	if resp.StatusCode != http.StatusPermanentRedirect || resp.Header.Get("Range") != "bytes=0-2" {
+		t.Fatalf("expected incomplete upload, got status %d with range %q", resp.StatusCode, resp.Header.Get("Range"))
+	}
+
+	resp = put(weights[5:], fmt.Sprintf("bytes 5-7/%d", len(weights)), weights)
+	if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable || resp.Header.Get("Range") != "bytes=0-2" {
+		t.Errorf("expected chunk with gap to be rejected, got status %d with range %q", resp.StatusCode,
+			resp.Header.Get("Range"))
+	}
+
+	resp = put(weights[3:6], fmt.Sprintf("bytes 3-7/%d", len(weights)), weights)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected chunk shorter than its range to be rejected, got status %d", resp.StatusCode)
+	}
+
+	resp = put(weights[3:], fmt.Sprintf("bytes 3-7/%d", len(weights)), weights)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected completed upload to be accepted, got status %d", resp.StatusCode)
+	}
+
+	update := c.modelUpdates["test"][0]
+	if !bytes.Equal(update.WeightUpdate, weights) || update.NumSamples != 10 {
+		t.Errorf("unexpected update: %+v", update)
+	}
+}
+
+func TestUploadExpiry(t *testing.T) {
+	u := &uploads{dir: t.TempDir(), sessions: make(map[string]*upload)}
+	if _, _, err := u.write("test/a/digest", 0, 8, bytes.NewReader([]byte{1, 2})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	session := u.sessions["test/a/digest"]
+
+	u.expire()
+	if _, exists := u.status("test/a/digest"); !exists {
+		t.Fatal("expected recent upload to be kept")
+	}
+
+	session.updated = time.Now().Add(-uploadTTL - time.Second)
+	u.expire()
+	if _, exists := u.status("test/a/digest"); exists {
+		t.Error("expected expired upload to be discarded")
+	}
+	if _, err := os.Stat(session.path); !os.IsNotExist(err) {
+		t.Errorf("expected temporary file of expired upload to be removed, got %v", err)
+	}
+}
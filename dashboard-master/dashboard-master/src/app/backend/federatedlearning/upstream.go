@@ -0,0 +1,448 @@
+package federatedlearning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetricUpdates is the metric with which a coordinator reports the number of regional updates aggregated
+// into the weights it pushes to its upstream coordinator.
+const MetricUpdates = "updates"
+
+// UpstreamConfig links a model to a model of an upstream coordinator. The coordinator then trains the model
+// as a regional model: it takes part in the rounds of the upstream model as a single client, pushing the
+// weights it aggregated from its own clients, and adopts every new global version of the upstream model.
+type UpstreamConfig struct {
+	// ModelID is the ID of the model on the upstream coordinator. The model is not linked if it is empty.
+	ModelID string `json:"modelId,omitempty"`
+}
+
+// enabled returns true if the model is linked to an upstream model.
+func (c UpstreamConfig) enabled() bool {
+	return c.ModelID != ""
+}
+
+// UpstreamState is the progress of a model linked to an upstream model.
+type UpstreamState struct {
+	// ModelID is the ID of the upstream model.
+	ModelID string `json:"modelId"`
+	// ClientID is the ID the upstream coordinator registered this coordinator with. It is empty until the
+	// coordinator registered.
+	ClientID string `json:"clientId,omitempty"`
+	// GlobalVersion is the version of the upstream model that was adopted last.
+	GlobalVersion int `json:"globalVersion,omitempty"`
+	// LocalVersion is the local version holding the weights of the adopted global version.
+	LocalVersion int `json:"localVersion,omitempty"`
+	// PushedFor is the global version regional weights were last pushed for. Weights are pushed once for
+	// every global version.
+	PushedFor int `json:"pushedFor,omitempty"`
+	// NumSamples is the number of samples of the updates aggregated since the global version was adopted.
+	NumSamples int `json:"numSamples,omitempty"`
+	// NumUpdates is the number of updates aggregated since the global version was adopted.
+	NumUpdates int `json:"numUpdates,omitempty"`
+}
+
+// Upstream is the API of an upstream coordinator, as used by a regional coordinator acting as its client.
+type Upstream interface {
+	// Register enrolls the coordinator in an upstream model.
+	Register(ctx context.Context, registration *ClientRegistration) (*Client, error)
+	// Heartbeat tells the upstream coordinator that the coordinator is alive.
+	Heartbeat(ctx context.Context, clientID string) error
+	// FetchModel returns the current version of an upstream model without weights. If clientID is not
+	// empty, the coordinator is marked as training the version, and ErrNotSelected is returned if it was
+	// not selected for the current round.
+	FetchModel(ctx context.Context, modelID, clientID string) (*GlobalModel, error)
+	// DownloadWeights returns the weights of a version of an upstream model.
+	DownloadWeights(ctx context.Context, modelID string, version int) ([]byte, error)
+	// SubmitUpdate submits regional weights to the open round of an upstream model.
+	SubmitUpdate(ctx context.Context, update *ModelUpdate) error
+}
+
+// httpUpstream implements Upstream on top of the HTTP API of the upstream coordinator.
+type httpUpstream struct {
+	client   *http.Client
+	endpoint string
+}
+
+// NewHTTPUpstream creates an Upstream for the coordinator served at baseURL, e.g.
+// "https://global-dashboard/api/v1". The HTTP client is responsible for authenticating the requests.
+func NewHTTPUpstream(client *http.Client, baseURL string) Upstream {
+	return &httpUpstream{client: client, endpoint: strings.TrimSuffix(baseURL, "/") + "/fl"}
+}
+
+// Register implements Upstream interface. See Upstream for more information.
+func (u *httpUpstream) Register(ctx context.Context, registration *ClientRegistration) (*Client, error) {
+	client := new(Client)
+	if err := u.do(ctx, http.MethodPost, "/register", registration, client); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Heartbeat implements Upstream interface. See Upstream for more information.
+func (u *httpUpstream) Heartbeat(ctx context.Context, clientID string) error {
+	return u.do(ctx, http.MethodPost, "/clients/"+url.PathEscape(clientID)+"/heartbeat", nil, nil)
+}
+
+// FetchModel implements Upstream interface. See Upstream for more information.
+func (u *httpUpstream) FetchModel(ctx context.Context, modelID, clientID string) (*GlobalModel, error) {
+	path := "/model/" + url.PathEscape(modelID)
+	if clientID != "" {
+		path += "?clientId=" + url.QueryEscape(clientID)
+	}
+
+	model := new(GlobalModel)
+	if err := u.do(ctx, http.MethodGet, path, nil, model); err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// DownloadWeights implements Upstream interface. See Upstream for more information.
+func (u *httpUpstream) DownloadWeights(ctx context.Context, modelID string, version int) ([]byte, error) {
+	var weights bytes.Buffer
+	path := "/model/" + url.PathEscape(modelID) + "/versions/" + strconv.Itoa(version) + "/weights"
+	if err := u.do(ctx, http.MethodGet, path, nil, &weights); err != nil {
+		return nil, err
+	}
+
+	return weights.Bytes(), nil
+}
+
+// SubmitUpdate implements Upstream interface. See Upstream for more information.
+func (u *httpUpstream) SubmitUpdate(ctx context.Context, update *ModelUpdate) error {
+	return u.do(ctx, http.MethodPost, "/model/"+url.PathEscape(update.ModelID)+"/update", update, nil)
+}
+
+// do sends a request and decodes the response into out unless it is nil. Responses are decoded as JSON,
+// except for a bytes.Buffer which receives the raw body. Errors of the upstream coordinator are returned
+// wrapping the matching coordinator error.
+func (u *httpUpstream) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return upstreamError(resp, strings.TrimSpace(string(message)))
+	}
+
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *bytes.Buffer:
+		_, err := out.ReadFrom(resp.Body)
+		return err
+	default:
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+}
+
+// upstreamErrors are the errors recognized in responses of an upstream coordinator.
+var upstreamErrors = []error{ErrModelNotFound, ErrVersionNotFound, ErrClientNotRegistered, ErrClientNotEnrolled,
+	ErrClientMismatch, ErrUnauthenticated, ErrInvalidUpdate, ErrStaleUpdate, ErrDuplicateUpdate, ErrRoundNotOpen,
+	ErrRoundActive, ErrPrivacyBudgetExhausted}
+
+// upstreamError converts an error response of an upstream coordinator back into the error the upstream
+// coordinator returned, recognized by the beginning of the message.
+func upstreamError(resp *http.Response, message string) error {
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		wait := new(WaitResponse)
+		if err := json.Unmarshal([]byte(message), wait); err == nil && wait.Status == "wait" {
+			return &WaitError{ModelID: wait.ModelID, Round: wait.Round,
+				RetryAfter: time.Duration(wait.RetryAfterSeconds) * time.Second}
+		}
+	}
+
+	for _, err := range upstreamErrors {
+		if strings.HasPrefix(message, err.Error()) {
+			return fmt.Errorf("%w: upstream %s %s: %s", err, resp.Request.Method, resp.Request.URL.Path,
+				strings.TrimPrefix(strings.TrimPrefix(message, err.Error()), ": "))
+		}
+	}
+
+	return fmt.Errorf("upstream %s %s: %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status, message)
+}
+
+// tokenFileTransport authenticates requests with a bearer token read from a file.
+type tokenFileTransport struct {
+	path string
+	next http.RoundTripper
+}
+
+// NewTokenFileTransport creates a RoundTripper that authenticates requests with the bearer token stored in
+// the file at path, e.g. a projected ServiceAccount token. The file is read for every request because the
+// token may be rotated.
+func NewTokenFileTransport(path string, next http.RoundTripper) http.RoundTripper {
+	return &tokenFileTransport{path: path, next: next}
+}
+
+// RoundTrip implements http.RoundTripper interface.
+func (t *tokenFileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := os.ReadFile(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upstream token: %v", err)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+string(bytes.TrimSpace(token)))
+	return t.next.RoundTrip(req)
+}
+
+// RunUpstream synchronizes models linked to an upstream model with the upstream coordinator every interval
+// until stopCh is closed. It blocks, so it should be started in a separate goroutine.
+func (c *Coordinator) RunUpstream(upstream Upstream, interval time.Duration, stopCh <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			if err := c.SyncUpstream(ctx, upstream); err != nil {
+				log.Printf("Failed to synchronize with upstream coordinator: %v", err)
+			}
+		}
+	}
+}
+
+// SyncUpstream synchronizes every model linked to an upstream model once. The coordinator registers with
+// the upstream coordinator if needed, adopts a new global version, or pushes the regional weights trained
+// since the adopted global version. Models that fail to synchronize are retried on the next call.
+func (c *Coordinator) SyncUpstream(ctx context.Context, upstream Upstream) error {
+	c.mu.Lock()
+	linked := make([]string, 0)
+	for modelID, config := range c.configs {
+		if _, exists := c.models[modelID]; exists && config.Upstream.enabled() {
+			linked = append(linked, modelID)
+		}
+	}
+	c.mu.Unlock()
+
+	var errs []error
+	for _, modelID := range linked {
+		if err := c.syncUpstream(ctx, upstream, modelID); err != nil {
+			errs = append(errs, fmt.Errorf("model %s: %w", modelID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// syncUpstream synchronizes a single model with its upstream model. The upstream coordinator is called
+// without holding the lock.
+func (c *Coordinator) syncUpstream(ctx context.Context, upstream Upstream, modelID string) error {
+	c.mu.Lock()
+	state := *c.upstreamState(modelID)
+	local := *c.models[modelID]
+	c.mu.Unlock()
+
+	if state.ClientID == "" {
+		client, err := upstream.Register(ctx, &ClientRegistration{ModelID: state.ModelID,
+			NumSamples: state.NumSamples})
+		if err != nil {
+			return err
+		}
+
+		state.ClientID = client.ID
+		c.updateUpstream(modelID, state.ModelID, func(s *UpstreamState) { s.ClientID = client.ID })
+	} else if err := upstream.Heartbeat(ctx, state.ClientID); err != nil {
+		// The upstream coordinator forgot this coordinator, it registers again on the next call.
+		if errors.Is(err, ErrClientNotRegistered) {
+			c.updateUpstream(modelID, state.ModelID, func(s *UpstreamState) { s.ClientID = "" })
+		}
+		return err
+	}
+
+	global, err := upstream.FetchModel(ctx, state.ModelID, "")
+	if err != nil {
+		return err
+	}
+
+	if global.Version > state.GlobalVersion {
+		weights, err := upstream.DownloadWeights(ctx, state.ModelID, global.Version)
+		if err != nil {
+			return err
+		}
+		if digest := weightsDigest(weights); digest != global.Digest {
+			return fmt.Errorf("weights of global version %d have digest %s, expected %s", global.Version, digest,
+				global.Digest)
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.adoptGlobalVersion(modelID, global, weights)
+	}
+
+	// Regional weights are pushed once per global version, after at least one version was trained on top of
+	// it.
+	if local.Version == state.LocalVersion || state.PushedFor == state.GlobalVersion {
+		return nil
+	}
+
+	if _, err := upstream.FetchModel(ctx, state.ModelID, state.ClientID); err != nil {
+		if errors.Is(err, ErrNotSelected) {
+			return nil
+		}
+		return err
+	}
+
+	err = upstream.SubmitUpdate(ctx, &ModelUpdate{ClientID: state.ClientID, ModelID: state.ModelID,
+		BaseVersion: state.GlobalVersion, WeightUpdate: local.Weights, NumSamples: max(state.NumSamples, 1),
+		Metrics: map[string]float64{MetricUpdates: float64(state.NumUpdates)}})
+	if err != nil && !errors.Is(err, ErrDuplicateUpdate) {
+		// Stale pushes are dropped once the next global version is adopted.
+		return err
+	}
+
+	c.updateUpstream(modelID, state.ModelID, func(s *UpstreamState) {
+		if s.GlobalVersion == state.GlobalVersion {
+			s.PushedFor = state.GlobalVersion
+		}
+	})
+	log.Printf("Pushed version %d of model %s to global version %d of upstream model %s", local.Version, modelID,
+		state.GlobalVersion, state.ModelID)
+	return nil
+}
+
+// adoptGlobalVersion makes the weights of a global version the next version of a model. Pending regional
+// work is abandoned: a candidate is rejected and the active round is failed. Must be called with the lock
+// held.
+func (c *Coordinator) adoptGlobalVersion(modelID string, global *GlobalModel, weights []byte) error {
+	state := c.upstreamState(modelID)
+	if global.Version <= state.GlobalVersion {
+		return nil
+	}
+
+	round := c.rounds[modelID]
+	if round != nil && round.State == RoundAggregating {
+		return fmt.Errorf("%w: round %d of model %s is aggregating", ErrRoundActive, round.Number, modelID)
+	}
+
+	model := c.models[modelID]
+	if err := validateUpdate(model, &ModelUpdate{ClientID: state.ClientID, WeightUpdate: weights}); err != nil {
+		return fmt.Errorf("global version %d of model %s: %v", global.Version, state.ModelID, err)
+	}
+
+	version := &GlobalModel{
+		ID:              modelID,
+		Version:         c.latestVersion(modelID) + 1,
+		ParentVersion:   model.Version,
+		Weights:         weights,
+		Digest:          weightsDigest(weights),
+		Size:            len(weights),
+		CreatedAt:       c.now(),
+		Description:     model.Description,
+		Schema:          model.Schema,
+		UpstreamVersion: global.Version,
+	}
+	if c.store != nil {
+		if err := c.store.SaveWeights(version); err != nil {
+			return fmt.Errorf("failed to persist weights of model %s version %d: %v", modelID, version.Version, err)
+		}
+	}
+
+	reason := fmt.Sprintf("superseded by global version %d", global.Version)
+	if _, exists := c.candidates[modelID]; exists {
+		c.resolveCandidate(modelID, false, reason)
+	}
+	if round := c.rounds[modelID]; round != nil && round.Active() {
+		round.close(RoundFailed, c.now(), reason)
+	}
+
+	c.addVersion(version)
+	c.models[modelID] = version
+	// Momentum of the server optimizer points along regional versions.
+	c.setOptimizer(modelID, nil)
+	*state = UpstreamState{ModelID: state.ModelID, ClientID: state.ClientID, GlobalVersion: global.Version,
+		LocalVersion: version.Version}
+	c.openRound(modelID, c.configs[modelID].Round)
+	c.emit(Event{Type: EventModelVersion, ModelID: modelID, Round: c.rounds[modelID].Number,
+		Version: version.Version, Message: fmt.Sprintf("adopted global version %d", global.Version)})
+	c.persistRoundEnd(modelID)
+
+	log.Printf("Adopted global version %d of upstream model %s as version %d of model %s", global.Version,
+		state.ModelID, version.Version, modelID)
+	return nil
+}
+
+// upstreamState returns the upstream state of a linked model. The state is reset if the model was linked
+// to another upstream model. Must be called with the lock held.
+func (c *Coordinator) upstreamState(modelID string) *UpstreamState {
+	upstreamID := c.configs[modelID].Upstream.ModelID
+	if state, exists := c.upstreams[modelID]; exists && state.ModelID == upstreamID {
+		return state
+	}
+
+	state := &UpstreamState{ModelID: upstreamID}
+	c.upstreams[modelID] = state
+	return state
+}
+
+// updateUpstream updates the upstream state of a model unless the model was linked to another upstream
+// model in the meantime, and persists it.
+func (c *Coordinator) updateUpstream(modelID, upstreamID string, update func(*UpstreamState)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.models[modelID]; !exists || c.configs[modelID].Upstream.ModelID != upstreamID {
+		return
+	}
+
+	update(c.upstreamState(modelID))
+	if err := c.persistModel(modelID); err != nil {
+		log.Print(err)
+	}
+}
+
+// countUpstream adds the updates aggregated into a regional version to the upstream state of its model.
+// Must be called with the lock held.
+func (c *Coordinator) countUpstream(modelID string, updates []*ModelUpdate) {
+	if !c.configs[modelID].Upstream.enabled() {
+		return
+	}
+
+	state := c.upstreamState(modelID)
+	for _, update := range updates {
+		state.NumSamples += max(update.NumSamples, 1)
+		state.NumUpdates++
+	}
+}
@@ -0,0 +1,157 @@
+package federatedlearning
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// newTestRegion creates a regional coordinator whose model "regional" is linked to the model "global" of
+// the upstream coordinator served by server. It authenticates to the upstream coordinator as region.
+func newTestRegion(t *testing.T, serverURL, region string) (*Coordinator, Upstream) {
+	c := NewCoordinator()
+	spec := &ModelSpec{ID: "regional", Weights: encodeWeights([]float32{9, 9}), Config: ModelConfig{
+		Round:    RoundConfig{MinParticipants: 1, MaxParticipants: 1},
+		Upstream: UpstreamConfig{ModelID: "global"},
+	}}
+	if _, err := c.CreateModel(spec); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+
+	c.RegisterClient(&Identity{ClientID: region + "-xapp"}, &ClientRegistration{ModelID: "regional"})
+	return c, NewHTTPUpstream(&http.Client{Transport: bearerTransport(region)}, serverURL+"/api/v1")
+}
+
+func syncTestRegion(t *testing.T, c *Coordinator, upstream Upstream) {
+	if err := c.SyncUpstream(context.Background(), upstream); err != nil {
+		t.Fatalf("SyncUpstream(): unexpected error: %v", err)
+	}
+}
+
+func TestHierarchicalTraining(t *testing.T) {
+	global := NewCoordinator()
+	spec := &ModelSpec{ID: "global", Weights: encodeWeights([]float32{0, 0}),
+		Config: ModelConfig{Round: RoundConfig{MinParticipants: 2, MaxParticipants: 2}}}
+	if _, err := global.CreateModel(spec); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	server := newTestAPIServer(global)
+	defer server.Close()
+
+	east, eastUpstream := newTestRegion(t, server.URL, "east")
+	west, westUpstream := newTestRegion(t, server.URL, "west")
+
+	// The first synchronization registers the regions and adopts the initial global version.
+	for _, region := range []struct {
+		c        *Coordinator
+		upstream Upstream
+	}{{east, eastUpstream}, {west, westUpstream}} {
+		syncTestRegion(t, region.c, region.upstream)
+
+		model, _ := region.c.GetModel("regional")
+		if model.Version != 2 || model.ParentVersion != 1 || model.UpstreamVersion != 1 {
+			t.Fatalf("expected version 2 adopting global version 1, got version %d with parent %d adopting %d",
+				model.Version, model.ParentVersion, model.UpstreamVersion)
+		}
+		if weights, _ := decodeWeights(model.Weights); !reflect.DeepEqual(weights, []float32{0, 0}) {
+			t.Fatalf("expected global weights [0 0], got %v", weights)
+		}
+	}
+	if clients := global.ListClients(); len(clients) != 2 {
+		t.Fatalf("expected both regions registered upstream, got %d clients", len(clients))
+	}
+
+	// Nothing is pushed before a regional version was trained.
+	syncTestRegion(t, east, eastUpstream)
+	if round, _ := global.GetRound("global"); len(round.Participants) != 0 {
+		t.Fatalf("expected no pushed weights, got participants %v", round.Participants)
+	}
+
+	regionalUpdates := []struct {
+		region   *Coordinator
+		client   string
+		weights  []float32
+		samples  int
+		upstream Upstream
+	}{
+		{east, "east-xapp", []float32{1, 1}, 30, eastUpstream},
+		{west, "west-xapp", []float32{3, 3}, 10, westUpstream},
+	}
+	for _, u := range regionalUpdates {
+		err := u.region.SubmitModelUpdate(&ModelUpdate{ClientID: u.client, ModelID: "regional", BaseVersion: 2,
+			WeightUpdate: encodeWeights(u.weights), NumSamples: u.samples})
+		if err != nil {
+			t.Fatalf("SubmitModelUpdate(): unexpected error: %v", err)
+		}
+		syncTestRegion(t, u.region, u.upstream)
+	}
+
+	// Regional weights are weighted by the samples of the regions.
+	model, _ := global.GetModel("global")
+	if model.Version != 2 {
+		t.Fatalf("expected global version 2 after both regions pushed, got %d", model.Version)
+	}
+	if weights, _ := decodeWeights(model.Weights); !reflect.DeepEqual(weights, []float32{1.5, 1.5}) {
+		t.Fatalf("expected global weights [1.5 1.5], got %v", weights)
+	}
+	if updates := metricOf(model.Training, MetricUpdates); updates == nil || updates.Mean != 1 {
+		t.Fatalf("expected one regional update per region, got %+v", updates)
+	}
+
+	// Pushing again for the same global version is skipped.
+	east.mu.Lock()
+	pushedFor := east.upstreams["regional"].PushedFor
+	east.mu.Unlock()
+	if pushedFor != 1 {
+		t.Fatalf("expected weights pushed for global version 1, got %d", pushedFor)
+	}
+
+	syncTestRegion(t, east, eastUpstream)
+	model, _ = east.GetModel("regional")
+	if model.Version != 4 || model.ParentVersion != 3 || model.UpstreamVersion != 2 {
+		t.Fatalf("expected version 4 adopting global version 2, got version %d with parent %d adopting %d",
+			model.Version, model.ParentVersion, model.UpstreamVersion)
+	}
+	if weights, _ := decodeWeights(model.Weights); !reflect.DeepEqual(weights, []float32{1.5, 1.5}) {
+		t.Fatalf("expected global weights [1.5 1.5], got %v", weights)
+	}
+	if round, _ := east.GetRound("regional"); round.BaseVersion != 4 {
+		t.Fatalf("expected regional round training version 4, got %d", round.BaseVersion)
+	}
+
+	east.mu.Lock()
+	state := *east.upstreams["regional"]
+	east.mu.Unlock()
+	if state.NumSamples != 0 || state.NumUpdates != 0 || state.LocalVersion != 4 {
+		t.Fatalf("expected progress reset after adopting global version, got %+v", state)
+	}
+}
+
+func TestUpstreamReregisters(t *testing.T) {
+	global := NewCoordinator()
+	spec := &ModelSpec{ID: "global", Weights: encodeWeights([]float32{0, 0})}
+	if _, err := global.CreateModel(spec); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	server := newTestAPIServer(global)
+	defer server.Close()
+
+	region, upstream := newTestRegion(t, server.URL, "east")
+	region.mu.Lock()
+	region.upstreamState("regional").ClientID = "east"
+	region.mu.Unlock()
+
+	if err := region.SyncUpstream(context.Background(), upstream); !errors.Is(err, ErrClientNotRegistered) {
+		t.Fatalf("expected ErrClientNotRegistered for a forgotten registration, got %v", err)
+	}
+	syncTestRegion(t, region, upstream)
+
+	if _, err := global.GetClient("east"); err != nil {
+		t.Fatalf("expected region registered again, got %v", err)
+	}
+	if _, err := upstream.FetchModel(context.Background(), "missing", ""); !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("expected ErrModelNotFound from upstream, got %v", err)
+	}
+}
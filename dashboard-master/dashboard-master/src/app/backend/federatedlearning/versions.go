@@ -0,0 +1,253 @@
+package federatedlearning
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// retainedVersions is the number of latest versions of a model whose weights coordinators without a store
+// keep in memory. Only metadata of earlier versions is kept, apart from the current version.
+const retainedVersions = 10
+
+// CreateModel creates the first version of a model and opens its first training round.
+func (c *Coordinator) CreateModel(spec *ModelSpec) (*GlobalModel, error) {
+	if spec.ID == "" || strings.Contains(spec.ID, "/") {
+		return nil, fmt.Errorf("%w: id must be non-empty and must not contain '/': %q", ErrInvalidModel, spec.ID)
+	}
+
+	if _, err := decodeWeights(spec.Weights); err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrInvalidModel, spec.ID, err)
+	}
+	schema, err := weightsSchema(spec.Weights)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrInvalidModel, spec.ID, err)
+	}
+
+	if err := validateConfig(spec.Config); err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrInvalidModel, spec.ID, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.models[spec.ID]; exists {
+		return nil, fmt.Errorf("%w: %s", ErrModelExists, spec.ID)
+	}
+
+	model := &GlobalModel{
+		ID:          spec.ID,
+		Version:     1,
+		Weights:     spec.Weights,
+		Digest:      weightsDigest(spec.Weights),
+		Size:        len(spec.Weights),
+		CreatedAt:   c.now(),
+		Description: spec.Description,
+		Schema:      schema,
+	}
+
+	if c.store != nil {
+		if err := c.store.SaveWeights(model); err != nil {
+			return nil, fmt.Errorf("failed to persist weights of model %s: %v", model.ID, err)
+		}
+	}
+
+	c.models[model.ID] = model
+	c.addVersion(model)
+	c.configs[model.ID] = spec.Config
+	c.openRound(model.ID, spec.Config.Round)
+	if err := c.persistModel(model.ID); err != nil {
+		return nil, err
+	}
+
+	return c.published(model), nil
+}
+
+// ListModels returns the current version of every model, sorted by ID.
+func (c *Coordinator) ListModels() []*GlobalModel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	models := make([]*GlobalModel, 0, len(c.models))
+	for _, model := range c.models {
+		models = append(models, c.published(model))
+	}
+
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models
+}
+
+// ListVersions returns all versions of a model, oldest first. Weights of versions other than the current
+// one are not included if the coordinator is persistent, nor for versions older than the latest
+// retainedVersions otherwise.
+func (c *Coordinator) ListVersions(id string) ([]*GlobalModel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.models[id]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, id)
+	}
+
+	versions := make([]*GlobalModel, len(c.versions[id]))
+	for i, v := range c.versions[id] {
+		version := *v
+		versions[i] = &version
+	}
+
+	return versions, nil
+}
+
+// GetModelVersion returns a specific version of a model including its weights.
+func (c *Coordinator) GetModelVersion(id string, version int) (*GlobalModel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	model, err := c.getVersion(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	result := *model
+	return &result, nil
+}
+
+// RollbackModel makes an earlier version the current version of a model. The active round is failed and a
+// new round training the restored version is opened. Versions created afterwards continue the version
+// numbering, with the restored version as their parent.
+func (c *Coordinator) RollbackModel(id string, version int) (*GlobalModel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	model, err := c.getVersion(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	round := c.rounds[id]
+	if round != nil && round.State == RoundAggregating {
+		return nil, fmt.Errorf("%w: round %d of model %s is aggregating", ErrRoundActive, round.Number, id)
+	}
+
+	// Rolling back overrides the promotion policy: the candidate is promoted if it is the requested version
+	// and rejected otherwise.
+	if candidate, exists := c.candidates[id]; exists {
+		c.resolveCandidate(id, candidate.Version == version, fmt.Sprintf("model rolled back to version %d", version))
+		round = c.rounds[id]
+	}
+
+	if c.models[id].Version == version {
+		return c.published(model), nil
+	}
+
+	if round != nil && round.Active() {
+		round.close(RoundFailed, c.now(), fmt.Sprintf("model rolled back to version %d", version))
+	}
+
+	// Momentum of the server optimizer points along the abandoned versions.
+	c.models[id] = model
+	c.setOptimizer(id, nil)
+	c.openRound(id, c.configs[id].Round)
+	c.persistRoundEnd(id)
+	return c.published(model), nil
+}
+
+// getVersion returns a version of a model with its weights. Must be called with the lock held.
+func (c *Coordinator) getVersion(id string, version int) (*GlobalModel, error) {
+	if _, exists := c.models[id]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, id)
+	}
+
+	for _, v := range c.versions[id] {
+		if v.Version != version {
+			continue
+		}
+
+		if current := c.models[id]; current.Version == version {
+			return current, nil
+		}
+		if v.Weights != nil {
+			return v, nil
+		}
+		if c.store == nil {
+			return nil, fmt.Errorf("%w: weights of model %s version %d are no longer kept", ErrVersionNotFound, id,
+				version)
+		}
+
+		weights, err := c.store.LoadWeights(id, version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load weights of model %s version %d: %v", id, version, err)
+		}
+
+		result := *v
+		result.Weights = weights
+		return &result, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s version %d", ErrVersionNotFound, id, version)
+}
+
+// published returns a copy of the current version of a model as it is handed out, carrying the privacy
+// budget spent by the model and the proximal coefficient clients train with. Must be called with the lock
+// held.
+func (c *Coordinator) published(model *GlobalModel) *GlobalModel {
+	result := *model
+	if budget := c.privacyBudget(model.ID); budget != nil {
+		result.Privacy = budget.copy()
+	}
+	result.ProximalMu = c.configs[model.ID].ProximalMu
+
+	return &result
+}
+
+// addVersion signs a version and adds it to the history of its model. Persistent coordinators keep only
+// metadata of versions in memory and load weights from the store when needed, other coordinators drop
+// weights of versions older than the latest retainedVersions. Must be called with the lock held.
+func (c *Coordinator) addVersion(model *GlobalModel) {
+	if model.Signature == nil {
+		// Clients verifying signatures refuse the version until the coordinator signs it again.
+		if err := c.sign(model); err != nil {
+			log.Printf("Publishing model %s version %d unsigned: %v", model.ID, model.Version, err)
+		}
+	}
+
+	version := model
+	if c.store != nil {
+		metadata := *model
+		metadata.Weights = nil
+		version = &metadata
+	}
+
+	c.versions[model.ID] = append(c.versions[model.ID], version)
+	if c.store == nil {
+		c.dropWeights(model.ID)
+	}
+}
+
+// dropWeights replaces versions of a model older than the latest retainedVersions with their metadata.
+// Versions are replaced rather than modified because the current version of the model may be one of them.
+// Must be called with the lock held.
+func (c *Coordinator) dropWeights(id string) {
+	oldest := c.latestVersion(id) - retainedVersions
+	for i, v := range c.versions[id] {
+		if v.Version > oldest || v.Weights == nil {
+			continue
+		}
+
+		metadata := *v
+		metadata.Weights = nil
+		c.versions[id][i] = &metadata
+	}
+}
+
+// latestVersion returns the highest version number of a model. Must be called with the lock held.
+func (c *Coordinator) latestVersion(id string) int {
+	latest := 0
+	for _, v := range c.versions[id] {
+		if v.Version > latest {
+			latest = v.Version
+		}
+	}
+
+	return latest
+}
@@ -0,0 +1,125 @@
+package federatedlearning
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func trainTestRound(t *testing.T, c *Coordinator, weights []float32) *GlobalModel {
+	model, _ := c.GetModel("test")
+	for _, client := range []string{"a", "b"} {
+		err := c.SubmitModelUpdate(&ModelUpdate{ClientID: client, ModelID: "test", BaseVersion: model.Version,
+			WeightUpdate: encodeWeights(weights), NumSamples: 1})
+		if err != nil {
+			t.Fatalf("SubmitModelUpdate(): unexpected error: %v", err)
+		}
+	}
+
+	model, _ = c.GetModel("test")
+	return model
+}
+
+func testRollback(t *testing.T, c *Coordinator) {
+	trainTestRound(t, c, []float32{1, 1})
+	trainTestRound(t, c, []float32{2, 2})
+
+	if _, err := c.RollbackModel("test", 5); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected version not found error, got %v", err)
+	}
+
+	model, err := c.RollbackModel("test", 2)
+	if err != nil {
+		t.Fatalf("RollbackModel(): unexpected error: %v", err)
+	}
+	if weights, _ := decodeWeights(model.Weights); model.Version != 2 || !reflect.DeepEqual(weights, []float32{1, 1}) {
+		t.Fatalf("expected version 2 with weights [1 1], got version %d with %v", model.Version, weights)
+	}
+
+	round, _ := c.GetRound("test")
+	if round.BaseVersion != 2 || round.State != RoundOpen {
+		t.Errorf("expected open round of version 2 after rollback, got %+v", round)
+	}
+
+	model = trainTestRound(t, c, []float32{3, 3})
+	if model.Version != 4 || model.ParentVersion != 2 {
+		t.Errorf("expected version 4 with parent 2, got version %d with parent %d", model.Version, model.ParentVersion)
+	}
+
+	versions, _ := c.ListVersions("test")
+	numbers := make([]int, 0)
+	for _, v := range versions {
+		numbers = append(numbers, v.Version)
+	}
+	if !reflect.DeepEqual(numbers, []int{1, 2, 3, 4}) {
+		t.Errorf("expected versions [1 2 3 4], got %v", numbers)
+	}
+}
+
+func TestRollbackModel(t *testing.T) {
+	testRollback(t, newTestCoordinator(t, 2, "a", "b"))
+}
+
+func TestRollbackPersistentModel(t *testing.T) {
+	store, _ := NewFileStore(t.TempDir())
+	c := newTestPersistentCoordinator(t, store)
+	if _, err := c.CreateModel(&ModelSpec{ID: "test", Weights: encodeWeights([]float32{0, 0}),
+		Config: ModelConfig{Round: RoundConfig{MinParticipants: 2}}}); err != nil {
+		t.Fatalf("CreateModel(): unexpected error: %v", err)
+	}
+	for _, client := range []string{"a", "b"} {
+		c.RegisterClient(&Identity{ClientID: client}, &ClientRegistration{ModelID: "test"})
+	}
+
+	testRollback(t, c)
+
+	restored := newTestPersistentCoordinator(t, store)
+	if model, _ := restored.GetModel("test"); model.Version != 4 {
+		t.Errorf("expected restored version 4, got %d", model.Version)
+	}
+	if versions, _ := restored.ListVersions("test"); len(versions) != 4 {
+		t.Errorf("expected 4 restored versions, got %d", len(versions))
+	}
+}
+
+func TestRetainedVersions(t *testing.T) {
+	c := newTestCoordinator(t, 2, "a", "b")
+	for i := 0; i < retainedVersions+1; i++ {
+		trainTestRound(t, c, []float32{1, 1})
+	}
+
+	if _, err := c.GetModelVersion("test", 1); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected weights of version 1 to be dropped, got %v", err)
+	}
+	if _, err := c.RollbackModel("test", 2); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected rollback to version 2 to be rejected, got %v", err)
+	}
+	if model, err := c.RollbackModel("test", 3); err != nil || model.Weights == nil {
+		t.Errorf("expected rollback to version 3 with weights, got %v", err)
+	}
+	if versions, _ := c.ListVersions("test"); len(versions) != retainedVersions+2 {
+		t.Errorf("expected metadata of all %d versions, got %d", retainedVersions+2, len(versions))
+	}
+}
+
+func TestCreateModelInvalid(t *testing.T) {
+	c := newTestCoordinator(t, 2)
+
+	cases := []struct {
+		info     string
+		spec     *ModelSpec
+		expected error
+	}{
+		{"existing model", &ModelSpec{ID: "test"}, ErrModelExists},
+		{"missing id", &ModelSpec{}, ErrInvalidModel},
+		{"malformed weights", &ModelSpec{ID: "other", Weights: []byte{1}}, ErrInvalidModel},
+		{"unknown aggregator", &ModelSpec{ID: "other", Config: ModelConfig{
+			Aggregation: AggregationConfig{Algorithm: "unknown"}}}, ErrInvalidModel},
+	}
+
+	for _, tc := range cases {
+		if _, err := c.CreateModel(tc.spec); !errors.Is(err, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.info, tc.expected, err)
+		}
+	}
+}
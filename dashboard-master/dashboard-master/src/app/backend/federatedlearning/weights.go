@@ -0,0 +1,106 @@
+package federatedlearning
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"github.com/kubernetes/dashboard/src/app/backend/federatedlearning/tensor"
+)
+
+// Weights exchanged with the coordinator are either encoded as a tensor container, see package tensor, or as
+// a flat vector of little-endian float32 values. Aggregation works on the flattened values, the result is
+// encoded in the layout of the model.
+const float32Size = 4
+
+// digestAlgorithm prefixes digests of weights.
+const digestAlgorithm = "sha256:"
+
+// weightsDigest returns the SHA-256 digest of weights in the "sha256:<hex>" format.
+func weightsDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return digestAlgorithm + hex.EncodeToString(sum[:])
+}
+
+// decodeWeights decodes weights into a flat vector. Tensor containers are flattened in tensor order.
+func decodeWeights(data []byte) ([]float32, error) {
+	if tensor.IsEncoded(data) {
+		tensors, err := tensor.Decode(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return tensor.Flatten(tensors)
+	}
+
+	if len(data)%float32Size != 0 {
+		return nil, fmt.Errorf("invalid weights length %d: not a multiple of %d", len(data), float32Size)
+	}
+
+	weights := make([]float32, len(data)/float32Size)
+	for i := range weights {
+		weights[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*float32Size:]))
+	}
+
+	return weights, nil
+}
+
+// encodeWeights encodes weights as a flat little-endian float32 vector.
+func encodeWeights(weights []float32) []byte {
+	data := make([]byte, len(weights)*float32Size)
+	for i, w := range weights {
+		binary.LittleEndian.PutUint32(data[i*float32Size:], math.Float32bits(w))
+	}
+
+	return data
+}
+
+// weightsSchema returns the tensor layout of weights, or nil for flat vectors.
+func weightsSchema(data []byte) ([]tensor.Spec, error) {
+	if !tensor.IsEncoded(data) {
+		return nil, nil
+	}
+
+	tensors, err := tensor.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return tensor.Schema(tensors), nil
+}
+
+// encodeLike encodes a flat float32 vector in the given tensor layout. Weights are returned as they are if
+// schema is nil.
+func encodeLike(schema []tensor.Spec, data []byte) ([]byte, error) {
+	if schema == nil {
+		return data, nil
+	}
+
+	weights, err := decodeWeights(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tensors, err := tensor.Unflatten(schema, weights)
+	if err != nil {
+		return nil, err
+	}
+
+	return tensor.Encode(tensors)
+}
+
+// numWeights returns the number of weights of a model.
+func numWeights(model *GlobalModel) int {
+	if model.Schema == nil {
+		return len(model.Weights) / float32Size
+	}
+
+	n := 0
+	for _, spec := range model.Schema {
+		n += spec.Len()
+	}
+
+	return n
+}
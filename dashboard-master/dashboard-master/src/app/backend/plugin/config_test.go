@@ -25,6 +25,7 @@ import (
 	"github.com/emicklei/go-restful/v3"
 	authApi "github.com/kubernetes/dashboard/src/app/backend/auth/api"
 	clientapi "github.com/kubernetes/dashboard/src/app/backend/client/api"
+	flclientset "github.com/kubernetes/dashboard/src/app/backend/federatedlearning/client/clientset/versioned"
 	"github.com/kubernetes/dashboard/src/app/backend/plugin/client/clientset/versioned"
 	fakePluginClientset "github.com/kubernetes/dashboard/src/app/backend/plugin/client/clientset/versioned/fake"
 	v1 "k8s.io/api/authorization/v1"
@@ -103,6 +104,10 @@ func (cm *fakeClientManager) InsecurePluginClient() versioned.Interface {
 	return cm.pluginClient
 }
 
+func (cm *fakeClientManager) InsecureFederatedLearningClient() flclientset.Interface {
+	panic("implement me")
+}
+
 func (cm *fakeClientManager) CanI(req *restful.Request, ssar *v1.SelfSubjectAccessReview) bool {
 	panic("implement me")
 }
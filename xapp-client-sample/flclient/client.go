@@ -0,0 +1,380 @@
+// Package flclient is a client of the federated learning coordinator for xApps. Client wraps the HTTP API
+// of the coordinator, and Run drives the whole training loop of an xApp around a Trainer.
+package flclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTokenPath is the ServiceAccount token of the pod. The coordinator derives the client ID from
+	// the pod the token is bound to.
+	DefaultTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	// DefaultHeartbeatInterval is the default time between heartbeats. It has to be shorter than the client
+	// timeout of the coordinator.
+	DefaultHeartbeatInterval = 30 * time.Second
+	// DefaultPollInterval is the default time between checks for a new version once the client trained the
+	// current one.
+	DefaultPollInterval = 30 * time.Second
+	// DefaultChunkSize is the default size of the chunks weights are uploaded in.
+	DefaultChunkSize = 4 << 20
+	// DefaultShutdownTimeout is the default time given to an update whose training finished to be submitted
+	// after the client was stopped.
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+const (
+	// PromotionCandidate is the promotion state of versions served to canaries for evaluation.
+	PromotionCandidate = "candidate"
+	// PromotionBaseline is the promotion state of the current version served for evaluation to clients
+	// that are not canaries, as the baseline a candidate is compared with.
+	PromotionBaseline = "baseline"
+)
+
+// GlobalModel is a version of a model managed by the coordinator. Weights are downloaded separately.
+type GlobalModel struct {
+	ID            string    `json:"id"`
+	Version       int       `json:"version"`
+	ParentVersion int       `json:"parentVersion,omitempty"`
+	Digest        string    `json:"digest"`
+	Size          int       `json:"size"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Description   string    `json:"description"`
+	// ProximalMu is the FedProx coefficient of the proximal term mu/2 * ||w - w_global||^2 added to the local
+	// objective. Zero means plain local training.
+	ProximalMu float64 `json:"proximalMu,omitempty"`
+	// Promotion is PromotionCandidate if the client was picked to evaluate the version before it is
+	// promoted, and PromotionBaseline if it is asked to evaluate the current version while a candidate
+	// waits.
+	Promotion string `json:"promotion,omitempty"`
+	// Contributors are the IDs of the clients whose updates were aggregated into the version.
+	Contributors []string `json:"contributors,omitempty"`
+	// Signature signs the provenance of the version. It is nil if the coordinator does not sign versions.
+	Signature *Signature `json:"signature,omitempty"`
+}
+
+// ModelUpdate is the result of a local training round.
+type ModelUpdate struct {
+	// BaseVersion is the version of the model the update was trained from.
+	BaseVersion int
+	// Weights are the new weights, encoded like the weights of the model.
+	Weights []byte
+	// NumSamples is the number of local samples the update was trained on.
+	NumSamples int
+	// Metrics describe how local training went, e.g. "loss" and "accuracy" on the local data.
+	Metrics map[string]float64
+}
+
+// Evaluation is the score of a version on the held-out data of a client.
+type Evaluation struct {
+	NumSamples int                `json:"numSamples"`
+	Metrics    map[string]float64 `json:"metrics"`
+}
+
+// Backoff configures retries of requests that failed with a transient error: network errors, 429 and
+// server errors other than 503.
+type Backoff struct {
+	// Initial is the delay before the first retry. It defaults to one second.
+	Initial time.Duration
+	// Max is the maximum delay between retries. It defaults to one minute.
+	Max time.Duration
+	// Retries is the number of retries before giving up. It defaults to 5, negative disables retries.
+	Retries int
+}
+
+// Config configures a Client.
+type Config struct {
+	// CoordinatorURL is the base URL of the coordinator API, e.g. "http://kubernetes-dashboard/api/v1".
+	CoordinatorURL string
+	// ModelID is the model the client trains.
+	ModelID string
+	// NumSamples is the size of the local data set, reported at registration for client selection.
+	NumSamples int
+	// TokenPath is the file holding the bearer token of the client. It is read for every request because
+	// kubelet rotates it. It defaults to DefaultTokenPath.
+	TokenPath string
+	// HTTPClient sends the requests. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// HeartbeatInterval defaults to DefaultHeartbeatInterval.
+	HeartbeatInterval time.Duration
+	// PollInterval defaults to DefaultPollInterval.
+	PollInterval time.Duration
+	// ChunkSize defaults to DefaultChunkSize.
+	ChunkSize int64
+	// ShutdownTimeout defaults to DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+	Backoff         Backoff
+	// PublicKey is the key of the coordinator versions are verified with, see LoadPublicKey. If it is set,
+	// FetchModel refuses versions that are not signed with it, so their weights are never used.
+	PublicKey ed25519.PublicKey
+	// Logf logs progress of Run. It defaults to log.Printf.
+	Logf func(format string, args ...interface{})
+}
+
+// StatusError is returned when the coordinator answers a request with an error status.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+// Error implements error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode),
+		e.Message)
+}
+
+// WaitError is returned when the client was not selected for the current round and should try again later.
+type WaitError struct {
+	Round      int
+	RetryAfter time.Duration
+}
+
+// Error implements error interface.
+func (e *WaitError) Error() string {
+	return fmt.Sprintf("not selected for round %d, retry after %s", e.Round, e.RetryAfter)
+}
+
+// IsStatus returns true if err is a StatusError with the given status code.
+func IsStatus(err error, code int) bool {
+	var status *StatusError
+	return errors.As(err, &status) && status.StatusCode == code
+}
+
+// Client is a client of the federated learning coordinator. It is safe for concurrent use.
+type Client struct {
+	config   Config
+	endpoint string
+
+	mu sync.Mutex
+	id string
+}
+
+// New creates a Client from config, filling in defaults.
+func New(config Config) (*Client, error) {
+	if config.CoordinatorURL == "" || config.ModelID == "" {
+		return nil, fmt.Errorf("coordinator URL and model ID are required")
+	}
+
+	if config.TokenPath == "" {
+		config.TokenPath = DefaultTokenPath
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = DefaultChunkSize
+	}
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if config.Backoff.Initial <= 0 {
+		config.Backoff.Initial = time.Second
+	}
+	if config.Backoff.Max <= 0 {
+		config.Backoff.Max = time.Minute
+	}
+	if config.Backoff.Retries == 0 {
+		config.Backoff.Retries = 5
+	}
+	if config.Logf == nil {
+		config.Logf = log.Printf
+	}
+
+	return &Client{config: config, endpoint: strings.TrimSuffix(config.CoordinatorURL, "/") + "/fl"}, nil
+}
+
+// ID returns the ID the coordinator registered the client with. It is empty until Register succeeded.
+func (c *Client) ID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.id
+}
+
+// Register enrolls the client in its model.
+func (c *Client) Register(ctx context.Context) error {
+	registration := map[string]interface{}{"modelId": c.config.ModelID, "numSamples": c.config.NumSamples}
+	var client struct {
+		ID string `json:"id"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/register", registration, &client); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.id = client.ID
+	c.mu.Unlock()
+	return nil
+}
+
+// Heartbeat tells the coordinator that the client is alive.
+func (c *Client) Heartbeat(ctx context.Context) error {
+	return c.doJSON(ctx, http.MethodPost, "/clients/"+url.PathEscape(c.ID())+"/heartbeat", nil, nil)
+}
+
+// FetchModel returns the version of the model the client should train or evaluate, and marks the client as
+// training. It returns a WaitError if the client was not selected for the current round, and an error
+// wrapping ErrInvalidSignature if a public key is configured and the version is not signed with it.
+func (c *Client) FetchModel(ctx context.Context) (*GlobalModel, error) {
+	model := new(GlobalModel)
+	path := c.modelPath("") + "?clientId=" + url.QueryEscape(c.ID())
+	if err := c.doJSON(ctx, http.MethodGet, path, nil, model); err != nil {
+		return nil, err
+	}
+
+	if c.config.PublicKey != nil {
+		if _, err := VerifyModel(model, c.config.PublicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return model, nil
+}
+
+// SubmitEvaluation submits the score of a version on the held-out data of the client.
+func (c *Client) SubmitEvaluation(ctx context.Context, version int, evaluation *Evaluation) error {
+	body := map[string]interface{}{"clientId": c.ID(), "numSamples": evaluation.NumSamples,
+		"metrics": evaluation.Metrics}
+	return c.doJSON(ctx, http.MethodPost, c.modelPath("/versions/"+strconv.Itoa(version)+"/evaluation"), body, nil)
+}
+
+// modelPath returns the path of a resource of the model of the client.
+func (c *Client) modelPath(path string) string {
+	return "/model/" + url.PathEscape(c.config.ModelID) + path
+}
+
+// doJSON sends a JSON request with retries and decodes the JSON response into out unless it is nil.
+func (c *Client) doJSON(ctx context.Context, method, path string, in, out interface{}) error {
+	var data []byte
+	if in != nil {
+		var err error
+		if data, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	return c.retry(ctx, func() error {
+		resp, err := c.do(ctx, method, path, bytes.NewReader(data), nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if out == nil {
+			return nil
+		}
+		return json.NewDecoder(resp.Body).Decode(out)
+	})
+}
+
+// do sends a single authenticated request. Responses with an error status are returned as StatusError or
+// WaitError, successful responses have to be closed by the caller.
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, header http.Header) (*http.Response,
+	error) {
+	token, err := os.ReadFile(c.config.TokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ServiceAccount token: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+string(bytes.TrimSpace(token)))
+
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 || resp.StatusCode == http.StatusPermanentRedirect {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			var wait struct {
+				Round int `json:"round"`
+			}
+			json.NewDecoder(resp.Body).Decode(&wait)
+			return nil, &WaitError{Round: wait.Round, RetryAfter: time.Duration(seconds) * time.Second}
+		}
+	}
+
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return nil, &StatusError{Method: method, Path: req.URL.Path, StatusCode: resp.StatusCode,
+		Message: strings.TrimSpace(string(message))}
+}
+
+// retry calls op until it succeeds, fails with an error that is not transient, or the retries of the
+// backoff are used up. The delay doubles after every attempt.
+func (c *Client) retry(ctx context.Context, op func() error) error {
+	delay := c.config.Backoff.Initial
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || !transient(err) || attempt >= c.config.Backoff.Retries {
+			return err
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+		delay = min(2*delay, c.config.Backoff.Max)
+	}
+}
+
+// transient returns true if a request that failed with err may succeed when it is retried.
+func transient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.StatusCode == http.StatusTooManyRequests || status.StatusCode >= 500
+	}
+
+	var wait *WaitError
+	return !errors.As(err, &wait)
+}
+
+// sleep waits for the given duration or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,299 @@
+package flclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCoordinator serves the coordinator API for a single client. Every kind of request fails once with a
+// transient error before it succeeds, except model fetches which first ask the client to wait.
+type fakeCoordinator struct {
+	t       *testing.T
+	weights []byte
+	key     ed25519.PrivateKey
+
+	mu         sync.Mutex
+	failed     map[string]bool
+	heartbeats int
+	upload     []byte
+	query      map[string][]string
+	updates    chan []byte
+}
+
+func (f *fakeCoordinator) failOnce(w http.ResponseWriter, kind string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failed[kind] {
+		return false
+	}
+	f.failed[kind] = true
+	http.Error(w, "try again", http.StatusInternalServerError)
+	return true
+}
+
+func (f *fakeCoordinator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "client not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	switch route := req.Method + " " + req.URL.Path; route {
+	case "POST /api/v1/fl/register":
+		if !f.failOnce(w, route) {
+			json.NewEncoder(w).Encode(map[string]string{"id": "xapp-1"})
+		}
+	case "POST /api/v1/fl/clients/xapp-1/heartbeat":
+		f.mu.Lock()
+		f.heartbeats++
+		f.mu.Unlock()
+	case "GET /api/v1/fl/model/test":
+		f.mu.Lock()
+		waited := f.failed["wait"]
+		f.failed["wait"] = true
+		f.mu.Unlock()
+		if !waited {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "wait", "round": 1})
+			return
+		}
+
+		sum := sha256.Sum256(f.weights)
+		json.NewEncoder(w).Encode(signModel(f.key, &GlobalModel{ID: "test", Version: 1,
+			Digest: "sha256:" + hex.EncodeToString(sum[:]), ProximalMu: 0.1}))
+	case "GET /api/v1/fl/model/test/versions/1/weights":
+		f.mu.Lock()
+		interrupted := f.failed[route]
+		f.failed[route] = true
+		f.mu.Unlock()
+		if !interrupted {
+			// The connection breaks in the middle of the download.
+			w.Header().Set("Content-Length", fmt.Sprint(len(f.weights)))
+			w.Write(f.weights[:len(f.weights)/2])
+			return
+		}
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(f.weights))
+	case "HEAD /api/v1/fl/model/test/update/weights":
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(f.upload)-1))
+		w.WriteHeader(http.StatusPermanentRedirect)
+	case "PUT /api/v1/fl/model/test/update/weights":
+		var start, end, size int
+		fmt.Sscanf(req.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &size)
+		if start > 0 && f.failOnce(w, route) {
+			return
+		}
+
+		chunk, _ := io.ReadAll(req.Body)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if start != len(f.upload) {
+			http.Error(w, "chunk does not continue the upload", http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		f.upload = append(f.upload, chunk...)
+		if len(f.upload) < size {
+			w.WriteHeader(http.StatusPermanentRedirect)
+			return
+		}
+
+		f.query = req.URL.Query()
+		w.WriteHeader(http.StatusAccepted)
+		f.updates <- f.upload
+	default:
+		f.t.Errorf("unexpected request %s", route)
+		http.NotFound(w, req)
+	}
+}
+
+// signModel signs the provenance of a version the way the coordinator does.
+func signModel(key ed25519.PrivateKey, model *GlobalModel) *GlobalModel {
+	payload, _ := json.Marshal(&Provenance{ModelID: model.ID, Version: model.Version,
+		ParentVersion: model.ParentVersion, Digest: model.Digest, Contributors: model.Contributors})
+	model.Signature = &Signature{KeyID: "test", Algorithm: SignatureAlgorithm, Payload: payload,
+		Value: ed25519.Sign(key, payload)}
+	return model
+}
+
+func newTestKey(seed byte) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+}
+
+// testTrainer adds one to every byte of the weights.
+type testTrainer struct {
+	weights chan []byte
+}
+
+func (t *testTrainer) Train(ctx context.Context, model *GlobalModel, weights []byte) (*ModelUpdate, error) {
+	t.weights <- weights
+	update := make([]byte, len(weights))
+	for i, w := range weights {
+		update[i] = w + 1
+	}
+
+	return &ModelUpdate{Weights: update, NumSamples: 7, Metrics: map[string]float64{"loss": 0.5}}, nil
+}
+
+func (t *testTrainer) Evaluate(ctx context.Context, model *GlobalModel, weights []byte) (*Evaluation, error) {
+	return nil, fmt.Errorf("unexpected evaluation of version %d", model.Version)
+}
+
+func TestRun(t *testing.T) {
+	weights := make([]byte, 100)
+	for i := range weights {
+		weights[i] = byte(i)
+	}
+	coordinator := &fakeCoordinator{t: t, weights: weights, key: newTestKey(1), failed: make(map[string]bool),
+		updates: make(chan []byte, 1)}
+	server := httptest.NewServer(coordinator)
+	defer server.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(Config{CoordinatorURL: server.URL + "/api/v1", ModelID: "test", TokenPath: tokenPath,
+		HeartbeatInterval: time.Millisecond, PollInterval: time.Millisecond, ChunkSize: 32,
+		Backoff: Backoff{Initial: time.Millisecond}, PublicKey: newTestKey(1).Public().(ed25519.PublicKey),
+		Logf: t.Logf})
+	if err != nil {
+		t.Fatalf("New(): unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	trainer := &testTrainer{weights: make(chan []byte, 1)}
+	done := make(chan error)
+	go func() { done <- client.Run(ctx, trainer) }()
+
+	var update []byte
+	select {
+	case update = <-coordinator.updates:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the update")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+
+	if trained := <-trainer.weights; !bytes.Equal(trained, weights) {
+		t.Fatalf("expected resumed download of the weights, trained %v", trained)
+	}
+	for i, w := range update {
+		if w != weights[i]+1 {
+			t.Fatalf("expected resumed upload of the trained weights, got %v", update)
+		}
+	}
+
+	expected := map[string][]string{"clientId": {"xapp-1"}, "baseVersion": {"1"}, "numSamples": {"7"},
+		"metric": {"loss:0.5"}}
+	if !reflect.DeepEqual(coordinator.query, expected) {
+		t.Fatalf("expected update %v, got %v", expected, coordinator.query)
+	}
+	if client.ID() != "xapp-1" {
+		t.Fatalf("expected client ID xapp-1, got %q", client.ID())
+	}
+
+	coordinator.mu.Lock()
+	defer coordinator.mu.Unlock()
+	if coordinator.heartbeats == 0 {
+		t.Fatal("expected heartbeats")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	client, _ := New(Config{CoordinatorURL: "http://coordinator", ModelID: "test",
+		Backoff: Backoff{Initial: time.Millisecond, Retries: 2}})
+
+	tests := []struct {
+		err      error
+		attempts int
+	}{
+		{&StatusError{StatusCode: http.StatusBadGateway}, 3},
+		{&StatusError{StatusCode: http.StatusTooManyRequests}, 3},
+		{io.ErrUnexpectedEOF, 3},
+		{&StatusError{StatusCode: http.StatusConflict}, 1},
+		{&WaitError{RetryAfter: time.Second}, 1},
+	}
+	for _, test := range tests {
+		attempts := 0
+		err := client.retry(context.Background(), func() error {
+			attempts++
+			return test.err
+		})
+		if err != test.err || attempts != test.attempts {
+			t.Errorf("expected %d attempts for %v, got %d ending with %v", test.attempts, test.err, attempts, err)
+		}
+	}
+}
+
+func TestVerifyModel(t *testing.T) {
+	key := newTestKey(1)
+	publicKey := key.Public().(ed25519.PublicKey)
+	newModel := func() *GlobalModel {
+		return signModel(key, &GlobalModel{ID: "test", Version: 2, ParentVersion: 1, Digest: "sha256:00",
+			Contributors: []string{"a", "b"}})
+	}
+
+	provenance, err := VerifyModel(newModel(), publicKey)
+	expected := &Provenance{ModelID: "test", Version: 2, ParentVersion: 1, Digest: "sha256:00",
+		Contributors: []string{"a", "b"}}
+	if err != nil || !reflect.DeepEqual(provenance, expected) {
+		t.Fatalf("expected provenance %+v, got %+v with %v", expected, provenance, err)
+	}
+
+	tests := []struct {
+		info   string
+		tamper func(model *GlobalModel)
+	}{
+		{"unsigned", func(model *GlobalModel) { model.Signature = nil }},
+		{"wrong key", func(model *GlobalModel) { signModel(newTestKey(2), model) }},
+		{"other digest", func(model *GlobalModel) { model.Digest = "sha256:01" }},
+		{"other version", func(model *GlobalModel) { model.Version = 3 }},
+		{"other contributors", func(model *GlobalModel) { model.Contributors = []string{"a"} }},
+		{"tampered payload", func(model *GlobalModel) { model.Signature.Payload[0] = ' ' }},
+		{"other algorithm", func(model *GlobalModel) { model.Signature.Algorithm = "RS256" }},
+	}
+	for _, test := range tests {
+		model := newModel()
+		test.tamper(model)
+		if _, err := VerifyModel(model, publicKey); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: expected invalid signature, got %v", test.info, err)
+		}
+	}
+}
+
+func TestLoadPublicKey(t *testing.T) {
+	publicKey := newTestKey(1).Public().(ed25519.PublicKey)
+	der, _ := x509.MarshalPKIXPublicKey(publicKey)
+	path := filepath.Join(t.TempDir(), "signing.pub")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadPublicKey(path)
+	if err != nil || !loaded.Equal(publicKey) {
+		t.Fatalf("expected public key to be loaded, got %v", err)
+	}
+}
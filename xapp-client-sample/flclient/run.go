@@ -0,0 +1,160 @@
+package flclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Trainer trains and evaluates the model of an xApp on its local data.
+type Trainer interface {
+	// Train trains a version of the model starting from its weights. The result is submitted as an update
+	// based on the version. Training should stop when ctx is cancelled.
+	Train(ctx context.Context, model *GlobalModel, weights []byte) (*ModelUpdate, error)
+	// Evaluate scores a candidate or baseline version of the model on held-out data.
+	Evaluate(ctx context.Context, model *GlobalModel, weights []byte) (*Evaluation, error)
+}
+
+// Run registers the client and takes part in training until ctx is cancelled: it waits until the client is
+// selected for a round, downloads the model, trains it with trainer and submits the update, or evaluates
+// candidate and baseline versions it is picked for. Heartbeats are sent in the background. Once ctx is cancelled, an
+// update whose training already finished is still submitted within the shutdown timeout, and Run returns
+// nil.
+func (c *Client) Run(ctx context.Context, trainer Trainer) error {
+	if err := c.Register(ctx); err != nil {
+		return ignoreCanceled(ctx, err)
+	}
+	c.config.Logf("Registered with coordinator, client ID: %s", c.ID())
+
+	var wg sync.WaitGroup
+	heartbeatCtx, stopHeartbeats := context.WithCancel(ctx)
+	defer func() {
+		stopHeartbeats()
+		wg.Wait()
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.sendHeartbeats(heartbeatCtx)
+	}()
+
+	// trained is the last version the client trained or evaluated.
+	trained := 0
+	for ctx.Err() == nil {
+		version, err := c.runRound(ctx, trainer, trained)
+		var wait *WaitError
+		switch {
+		case err == nil && version == trained:
+			sleep(ctx, c.config.PollInterval)
+		case err == nil:
+			trained = version
+		case errors.As(err, &wait):
+			c.config.Logf("Not selected for round %d, retrying in %s", wait.Round, wait.RetryAfter)
+			sleep(ctx, wait.RetryAfter)
+		case IsStatus(err, http.StatusForbidden):
+			// The coordinator forgot the client, or the pod of the client was recreated.
+			c.config.Logf("Registering again: %v", err)
+			if err := c.Register(ctx); err != nil && ctx.Err() == nil {
+				c.config.Logf("Error registering with coordinator: %v", err)
+				sleep(ctx, c.config.PollInterval)
+			}
+		case ctx.Err() == nil:
+			c.config.Logf("Training round failed: %v", err)
+			sleep(ctx, c.config.PollInterval)
+		}
+	}
+
+	return nil
+}
+
+// runRound trains or evaluates the version served to the client unless it already did. It returns the
+// served version.
+func (c *Client) runRound(ctx context.Context, trainer Trainer, trained int) (int, error) {
+	model, err := c.FetchModel(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// The current version is evaluated as a baseline even if the client already trained it.
+	if model.Version == trained && model.Promotion != PromotionBaseline {
+		return trained, nil
+	}
+
+	var weights bytes.Buffer
+	if err := c.DownloadWeights(ctx, model, &weights); err != nil {
+		return 0, err
+	}
+
+	if model.Promotion == PromotionCandidate || model.Promotion == PromotionBaseline {
+		evaluation, err := trainer.Evaluate(ctx, model, weights.Bytes())
+		if err != nil {
+			return 0, err
+		}
+
+		submitCtx, cancel := c.submitContext(ctx)
+		defer cancel()
+		err = c.SubmitEvaluation(submitCtx, model.Version, evaluation)
+		if IsStatus(err, http.StatusConflict) {
+			err = nil
+		}
+		if err == nil {
+			c.config.Logf("Submitted evaluation of %s version %d", model.Promotion, model.Version)
+		}
+		return model.Version, err
+	}
+
+	update, err := trainer.Train(ctx, model, weights.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	update.BaseVersion = model.Version
+
+	// Updates are submitted even if the client is stopping, training them is the expensive part.
+	submitCtx, cancel := c.submitContext(ctx)
+	defer cancel()
+	if err := c.SubmitUpdate(submitCtx, update); err != nil {
+		// The round closed or moved on while training, the next version is trained instead.
+		if IsStatus(err, http.StatusConflict) {
+			c.config.Logf("Update of version %d was not accepted: %v", model.Version, err)
+			return model.Version, nil
+		}
+		return 0, err
+	}
+
+	c.config.Logf("Submitted update of version %d", model.Version)
+	return model.Version, nil
+}
+
+// submitContext returns a context for submitting finished work. It is not cancelled together with ctx but
+// expires after the shutdown timeout, so that the work is not lost when the client is stopped.
+func (c *Client) submitContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), c.config.ShutdownTimeout)
+}
+
+// sendHeartbeats sends heartbeats until ctx is cancelled.
+func (c *Client) sendHeartbeats(ctx context.Context) {
+	ticker := time.NewTicker(c.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Heartbeat(ctx); err != nil && ctx.Err() == nil {
+				c.config.Logf("Error sending heartbeat: %v", err)
+			}
+		}
+	}
+}
+
+// ignoreCanceled returns nil if err was caused by cancelling ctx.
+func ignoreCanceled(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,93 @@
+package flclient
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// SignatureAlgorithm is the algorithm the coordinator signs versions with.
+const SignatureAlgorithm = "Ed25519"
+
+// ErrInvalidSignature is returned for versions whose signature is missing or does not verify.
+var ErrInvalidSignature = errors.New("invalid model signature")
+
+// Signature is the signature of the provenance of a version.
+type Signature struct {
+	KeyID     string `json:"keyId"`
+	Algorithm string `json:"algorithm"`
+	// Payload is the signed Provenance, encoded as JSON.
+	Payload []byte `json:"payload"`
+	Value   []byte `json:"value"`
+}
+
+// Provenance is the record of a version signed by the coordinator.
+type Provenance struct {
+	ModelID       string   `json:"modelId"`
+	Version       int      `json:"version"`
+	ParentVersion int      `json:"parentVersion,omitempty"`
+	Digest        string   `json:"digest"`
+	Contributors  []string `json:"contributors,omitempty"`
+}
+
+// LoadPublicKey reads the PEM encoded PKIX public key versions are verified with, e.g. the "pub" entry of the
+// signing key secret of the coordinator.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded key found in %s", path)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ed25519Key, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("expected Ed25519 key in %s, got %T", path, key)
+	}
+
+	return ed25519Key, nil
+}
+
+// VerifyModel verifies the signature of a version with key and returns its provenance. The signed
+// provenance has to describe the version, so weights matching the digest of the version are the weights the
+// coordinator published.
+func VerifyModel(model *GlobalModel, key ed25519.PublicKey) (*Provenance, error) {
+	signature := model.Signature
+	if signature == nil {
+		return nil, fmt.Errorf("%w: version %d is not signed", ErrInvalidSignature, model.Version)
+	}
+	if signature.Algorithm != SignatureAlgorithm {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, signature.Algorithm)
+	}
+	if !ed25519.Verify(key, signature.Payload, signature.Value) {
+		return nil, fmt.Errorf("%w: version %d was not signed with the trusted key, signed by key %q",
+			ErrInvalidSignature, model.Version, signature.KeyID)
+	}
+
+	provenance := new(Provenance)
+	if err := json.Unmarshal(signature.Payload, provenance); err != nil {
+		return nil, fmt.Errorf("%w: malformed provenance: %v", ErrInvalidSignature, err)
+	}
+
+	expected := &Provenance{ModelID: model.ID, Version: model.Version, ParentVersion: model.ParentVersion,
+		Digest: model.Digest, Contributors: model.Contributors}
+	if !reflect.DeepEqual(provenance, expected) {
+		return nil, fmt.Errorf("%w: signed provenance %+v does not describe version %+v", ErrInvalidSignature,
+			provenance, expected)
+	}
+
+	return provenance, nil
+}
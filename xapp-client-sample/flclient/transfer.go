@@ -0,0 +1,152 @@
+package flclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// DownloadWeights streams the weights of a version of the model into w and verifies them against the
+// digest of the version. Interrupted downloads are resumed where they stopped.
+func (c *Client) DownloadWeights(ctx context.Context, model *GlobalModel, w io.Writer) error {
+	digest := sha256.New()
+	var received int64
+
+	path := c.modelPath("/versions/" + strconv.Itoa(model.Version) + "/weights")
+	err := c.retry(ctx, func() error {
+		header := http.Header{}
+		if received > 0 {
+			header.Set("Range", fmt.Sprintf("bytes=%d-", received))
+		}
+
+		resp, err := c.do(ctx, http.MethodGet, path, nil, header)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if received > 0 && resp.StatusCode != http.StatusPartialContent {
+			return fmt.Errorf("coordinator does not support resuming downloads, got %s", resp.Status)
+		}
+
+		n, err := io.Copy(io.MultiWriter(w, digest), resp.Body)
+		received += n
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if actual := "sha256:" + hex.EncodeToString(digest.Sum(nil)); actual != model.Digest {
+		return fmt.Errorf("weights digest %s does not match model digest %s", actual, model.Digest)
+	}
+
+	return nil
+}
+
+// SubmitUpdate uploads an update of the model. The weights are sent in chunks of the configured size, and
+// an upload interrupted by a transient error is resumed from the bytes the coordinator received.
+func (c *Client) SubmitUpdate(ctx context.Context, update *ModelUpdate) error {
+	return c.UploadWeights(ctx, update, bytes.NewReader(update.Weights), int64(len(update.Weights)))
+}
+
+// UploadWeights uploads an update whose weights are read from weights instead of update.Weights, so that
+// large weights do not have to be held in memory.
+func (c *Client) UploadWeights(ctx context.Context, update *ModelUpdate, weights io.ReaderAt, size int64) error {
+	digest := sha256.New()
+	if _, err := io.Copy(digest, io.NewSectionReader(weights, 0, size)); err != nil {
+		return fmt.Errorf("failed to read weights: %v", err)
+	}
+
+	query := url.Values{"clientId": {c.ID()}, "baseVersion": {strconv.Itoa(update.BaseVersion)},
+		"numSamples": {strconv.Itoa(update.NumSamples)}}
+	for name, value := range update.Metrics {
+		query.Add("metric", name+":"+strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	upload := &upload{client: c, path: c.modelPath("/update/weights") + "?" + query.Encode(), weights: weights,
+		size: size, digest: digestHeader(digest)}
+
+	return c.retry(ctx, func() error {
+		err := upload.send(ctx)
+		if err != nil && transient(err) {
+			// The next attempt continues from the bytes the coordinator received.
+			if resumeErr := upload.resume(ctx); resumeErr != nil {
+				return resumeErr
+			}
+		}
+		return err
+	})
+}
+
+// upload is a chunked upload of the weights of an update.
+type upload struct {
+	client  *Client
+	path    string
+	weights io.ReaderAt
+	size    int64
+	digest  string
+	// sent is the number of bytes the coordinator acknowledged.
+	sent int64
+}
+
+// send uploads the remaining chunks.
+func (u *upload) send(ctx context.Context) error {
+	for {
+		end := min(u.sent+u.client.config.ChunkSize, u.size)
+		header := http.Header{"Content-Type": {"application/octet-stream"}, "Digest": {u.digest}}
+		if u.size > 0 {
+			header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", u.sent, end-1, u.size))
+		}
+
+		// Chunks are buffered so that requests carry their Content-Length.
+		chunk := make([]byte, end-u.sent)
+		if _, err := u.weights.ReadAt(chunk, u.sent); err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read weights: %v", err)
+		}
+
+		resp, err := u.client.do(ctx, http.MethodPut, u.path, bytes.NewReader(chunk), header)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		u.sent = end
+		if resp.StatusCode != http.StatusPermanentRedirect {
+			return nil
+		}
+	}
+}
+
+// resume asks the coordinator how many bytes of the upload it received.
+func (u *upload) resume(ctx context.Context) error {
+	resp, err := u.client.do(ctx, http.MethodHead, u.path, nil, http.Header{"Digest": {u.digest}})
+	if IsStatus(err, http.StatusNotFound) {
+		u.sent = 0
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	var last int64
+	if _, err := fmt.Sscanf(resp.Header.Get("Range"), "bytes=0-%d", &last); err != nil {
+		u.sent = 0
+		return nil
+	}
+	u.sent = last + 1
+	return nil
+}
+
+// digestHeader returns the value of the Digest header for a SHA-256 hash.
+func digestHeader(digest hash.Hash) string {
+	return "sha-256=" + base64.StdEncoding.EncodeToString(digest.Sum(nil))
+}
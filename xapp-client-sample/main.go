@@ -1,137 +1,88 @@
-
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"encoding/binary"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"math"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+
+	"xapp-client-sample/flclient"
 )
 
 const (
-	coordinatorURL = "http://localhost:9090/api/v1/fl"
+	coordinatorURL = "http://localhost:9090/api/v1"
 	modelID        = "rrm-power-control"
+	// numSamples is the size of the local data set, reported to the coordinator for client selection.
+	numSamples = 100
+	// publicKeyPath is the public key of the coordinator, the "pub" entry of the secret named by its
+	// --fl-signing-key-secret argument, mounted from a ConfigMap. Versions not signed with it are refused.
+	publicKeyPath = "/etc/federated-learning/signing.pub"
 )
 
-// GlobalModel represents the master model managed by the coordinator.
-type GlobalModel struct {
-	ID          string    `json:"id"`
-	Version     int       `json:"version"`
-	Weights     []byte    `json:"-"`
-	CreatedAt   time.Time `json:"createdAt"`
-	Description string    `json:"description"`
-}
-
-// ModelUpdate is sent by an xApp client to the coordinator after a local training round.
-type ModelUpdate struct {
-	ClientID     string `json:"clientId"`
-	ModelID      string `json:"modelId"`
-	BaseVersion  int    `json:"baseVersion"`
-	WeightUpdate []byte `json:"weightUpdate"`
-}
-
-func main() {
-	// 1. Register with the coordinator
-	clientID, err := register()
-	if err != nil {
-		fmt.Printf("Error registering with coordinator: %v\n", err)
-		return
+// sampleTrainer simulates training and evaluation of the model on local data.
+type sampleTrainer struct{}
+
+// Train keeps the weights close to the global model with the FedProx proximal term
+// mu/2 * ||w - w_global||^2 if the coordinator asks for it (conceptual).
+func (sampleTrainer) Train(ctx context.Context, model *flclient.GlobalModel, weights []byte) (*flclient.ModelUpdate,
+	error) {
+	fmt.Printf("Training version %d with proximal coefficient %g...\n", model.Version, model.ProximalMu)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(5 * time.Second): // Simulate training
 	}
-	fmt.Printf("Registered with coordinator, client ID: %s\n", clientID)
 
-	// 2. Get the global model
-	model, err := getModel()
-	if err != nil {
-		fmt.Printf("Error getting model: %v\n", err)
-		return
-	}
-	fmt.Printf("Got model version %d\n", model.Version)
-
-	// 3. Train the model (conceptual)
-	fmt.Println("Training model...")
-	time.Sleep(5 * time.Second) // Simulate training
-	newWeights := []byte("new weights")
-
-	// 4. Submit the model update
-	if err := submitUpdate(clientID, model.Version, newWeights); err != nil {
-		fmt.Printf("Error submitting update: %v\n", err)
-		return
-	}
-	fmt.Println("Submitted model update")
+	return &flclient.ModelUpdate{
+		Weights:    encodeWeights([]float32{0.1, 0.2, 0.3}),
+		NumSamples: numSamples,
+		Metrics:    map[string]float64{"loss": 0.42, "accuracy": 0.87},
+	}, nil
 }
 
-func register() (string, error) {
-	reqBody, err := json.Marshal(map[string]string{"modelId": modelID})
-	if err != nil {
-		return "", err
-	}
+// Evaluate scores candidate and baseline versions on held-out data instead of training them (conceptual).
+func (sampleTrainer) Evaluate(ctx context.Context, model *flclient.GlobalModel, weights []byte) (*flclient.Evaluation,
+	error) {
+	fmt.Printf("Evaluating %s version %d...\n", model.Promotion, model.Version)
+	return &flclient.Evaluation{NumSamples: numSamples, Metrics: map[string]float64{"loss": 0.4, "accuracy": 0.88}},
+		nil
+}
 
-	resp, err := http.Post(fmt.Sprintf("%s/register", coordinatorURL), "application/json", bytes.NewBuffer(reqBody))
+func main() {
+	publicKey, err := flclient.LoadPublicKey(publicKeyPath)
 	if err != nil {
-		return "", err
+		fmt.Printf("Error loading public key of the coordinator: %v\n", err)
+		os.Exit(1)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return "", fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
-	}
-
-	var client struct {
-		ID string `json:"id"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&client); err != nil {
-		return "", err
-	}
-
-	return client.ID, nil
-}
-
-func getModel() (*GlobalModel, error) {
-	resp, err := http.Get(fmt.Sprintf("%s/model/%s", coordinatorURL, modelID))
+	client, err := flclient.New(flclient.Config{CoordinatorURL: coordinatorURL, ModelID: modelID,
+		NumSamples: numSamples, PublicKey: publicKey})
 	if err != nil {
-		return nil, err
+		fmt.Printf("Error creating client: %v\n", err)
+		os.Exit(1)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
-	}
+	// Stop taking part in training when the pod is terminated. An update whose training finished is still
+	// submitted.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	var model GlobalModel
-	if err := json.NewDecoder(resp.Body).Decode(&model); err != nil {
-		return nil, err
+	if err := client.Run(ctx, sampleTrainer{}); err != nil {
+		fmt.Printf("Error taking part in training: %v\n", err)
+		os.Exit(1)
 	}
-
-	return &model, nil
 }
 
-func submitUpdate(clientID string, baseVersion int, newWeights []byte) error {
-	update := ModelUpdate{
-		ClientID:     clientID,
-		ModelID:      modelID,
-		BaseVersion:  baseVersion,
-		WeightUpdate: newWeights,
-	}
-
-	reqBody, err := json.Marshal(update)
-	if err != nil {
-		return err
-	}
-
-	resp, err := http.Post(fmt.Sprintf("%s/model/%s/update", coordinatorURL, modelID), "application/json", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusAccepted {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+// encodeWeights encodes weights as the flat little-endian float32 vector expected by the coordinator.
+func encodeWeights(weights []float32) []byte {
+	data := make([]byte, len(weights)*4)
+	for i, w := range weights {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(w))
 	}
 
-	return nil
+	return data
 }